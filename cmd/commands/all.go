@@ -2,6 +2,7 @@ package commands
 
 import (
 	"fmt"
+	"inspection-tool/internal/inventory"
 	"inspection-tool/internal/k8s"
 	"inspection-tool/internal/server"
 	"inspection-tool/internal/ssh"
@@ -10,6 +11,7 @@ import (
 	"inspection-tool/pkg/utils"
 	"os"
 	"path/filepath"
+	"sort"
 	"strings"
 	"sync"
 	"time"
@@ -28,6 +30,14 @@ type AllOptions struct {
 	SSHUser     string
 	SSHPassword string
 	SSHPort     int
+	SSHAuth     SSHAuthOptions
+	Inventory   string
+	Checks      CheckOptions
+	Exit        ExitOptions
+	Limit       string
+
+	// K8s节点SSH地址优先顺序
+	NodeAddressTypes []string
 
 	// 报告相关
 	Output   string
@@ -47,8 +57,18 @@ func NewAllCommand() *cobra.Command {
   inspection-tool all --kubeconfig ~/.kube/config --ssh-user root --ssh-password pass
 
   # 同时巡检指定服务器和K8s集群
-  inspection-tool all --kubeconfig ~/.kube/config --hosts "192.168.1.10,192.168.1.11" --ssh-user root --ssh-password pass`,
+  inspection-tool all --kubeconfig ~/.kube/config --hosts "192.168.1.10,192.168.1.11" --ssh-user root --ssh-password pass
+
+  # 巡检主机清单中带prod标签的主机
+  inspection-tool all --kubeconfig ~/.kube/config --inventory inventory.yaml --limit prod`,
 		RunE: func(cmd *cobra.Command, args []string) error {
+			applyK8sDefaults(cmd, &opts.Kubeconfig, &opts.Namespaces)
+			applyReportDefaults(cmd, &opts.Output, &opts.Format, &opts.Detailed)
+			applySSHConfigDefaults(cmd, "ssh-user", "ssh-port", &opts.SSHUser, &opts.SSHPort, opts.SSHAuth)
+			applyPasswordEnv(&opts.SSHPassword)
+			if err := applyNodeAddressDefaults(cmd, &opts.NodeAddressTypes); err != nil {
+				return err
+			}
 			return runAllInspection(opts)
 		},
 	}
@@ -59,17 +79,34 @@ func NewAllCommand() *cobra.Command {
 	cmd.Flags().StringVar(&opts.Kubeconfig, "kubeconfig", defaultKubeconfig, "kubeconfig文件路径")
 	cmd.Flags().StringVar(&opts.Namespaces, "namespaces", "", "要检查的命名空间(逗号分隔)")
 	cmd.Flags().StringVar(&opts.Hosts, "hosts", "", "额外的服务器地址(逗号分隔)")
-	cmd.Flags().StringVar(&opts.SSHUser, "ssh-user", "root", "SSH用户名")
-	cmd.Flags().StringVar(&opts.SSHPassword, "ssh-password", "", "SSH密码")
-	cmd.Flags().IntVar(&opts.SSHPort, "ssh-port", 22, "SSH端口")
+	cmd.Flags().StringVar(&opts.SSHUser, "ssh-user", "root", "SSH用户名(未指定时取~/.ssh/config)")
+	cmd.Flags().StringVar(&opts.SSHPassword, "ssh-password", "", "SSH密码(也可通过环境变量"+passwordEnv+"提供)")
+	cmd.Flags().IntVar(&opts.SSHPort, "ssh-port", 22, "SSH端口(未指定时取~/.ssh/config)")
+	opts.SSHAuth.addFlags(cmd.Flags(), "ssh-")
+	opts.Checks.addFlags(cmd.Flags())
+	opts.Exit.addFlags(cmd.Flags())
+	cmd.Flags().StringVar(&opts.Inventory, "inventory", "", "主机清单文件(YAML或Ansible INI)")
+	cmd.Flags().StringSliceVar(&opts.NodeAddressTypes, "node-address-types", nil, "K8s节点SSH地址优先顺序(InternalIP,ExternalIP,Hostname, 默认取配置文件)")
+	cmd.Flags().StringVar(&opts.Limit, "limit", "", "按分组、标签或主机名筛选清单中的主机(逗号分隔, !表示排除)")
 	cmd.Flags().StringVar(&opts.Output, "output", "./reports", "报告输出目录")
-	cmd.Flags().StringVar(&opts.Format, "format", "json", "报告格式(json/yaml)")
+	cmd.Flags().StringVar(&opts.Format, "format", "json", "报告格式(json/yaml/html/markdown/text/csv/xlsx/junit/sarif)")
 	cmd.Flags().BoolVar(&opts.Detailed, "detailed", true, "生成详细报告")
+	addReportManifestFlag(cmd)
 
 	return cmd
 }
 
 func runAllInspection(opts *AllOptions) error {
+	result, err := opts.Exit.outcome()
+	if err != nil {
+		return err
+	}
+	plan, err := opts.Checks.selection()
+	if err != nil {
+		return err
+	}
+	attachHistory(plan, opts.Output)
+
 	fmt.Println("========================================")
 	fmt.Println("开始综合巡检")
 	fmt.Println("========================================")
@@ -97,6 +134,10 @@ func runAllInspection(opts *AllOptions) error {
 		Kubeconfig: opts.Kubeconfig,
 		Namespaces: namespaces,
 		Timeout:    60 * time.Second,
+		Thresholds: appConfig.K8s.Thresholds,
+		Checks:     plan.set,
+		Waivers:    plan.waivers,
+		History:    plan.history,
 	})
 	if err != nil {
 		return fmt.Errorf("创建K8s巡检器失败: %w", err)
@@ -105,6 +146,7 @@ func runAllInspection(opts *AllOptions) error {
 	k8sReport, err := k8sInspector.Inspect()
 	if err != nil {
 		fmt.Printf("警告: K8s巡检失败: %v\n\n", err)
+		result.addFailure("k8s")
 	} else {
 		fullReport.K8sReport = k8sReport
 		fmt.Printf("✓ K8s巡检完成: 发现 %d 个问题\n\n", len(k8sReport.Issues))
@@ -115,7 +157,10 @@ func runAllInspection(opts *AllOptions) error {
 	fmt.Println("----------------------------------------")
 
 	// 收集要巡检的主机列表
-	hosts := collectHosts(opts, k8sReport)
+	hosts, err := collectHosts(opts, k8sReport)
+	if err != nil {
+		return err
+	}
 	
 	if len(hosts) == 0 {
 		fmt.Println("警告: 没有可巡检的服务器")
@@ -123,13 +168,23 @@ func runAllInspection(opts *AllOptions) error {
 		fmt.Printf("准备巡检 %d 台服务器\n", len(hosts))
 		
 		// 并发巡检服务器
-		serverReports := inspectServersParallel(hosts, opts)
+		serverReports, err := inspectServersParallel(hosts, sshLogin{
+			User:     opts.SSHUser,
+			Password: opts.SSHPassword,
+			Port:     opts.SSHPort,
+			Auth:     opts.SSHAuth,
+		}, plan)
+		if err != nil {
+			return err
+		}
 		
 		if len(serverReports) > 0 {
-			// 合并第一个服务器报告到完整报告
-			// 实际应用中可能需要更复杂的合并逻辑
-			fullReport.ServerReport = serverReports[0]
-			
+			// 并发巡检的完成顺序不固定, 按主机排序保证报告稳定
+			sort.Slice(serverReports, func(i, j int) bool {
+				return serverReports[i].Host < serverReports[j].Host
+			})
+			fullReport.ServerReports = serverReports
+
 			totalIssues := 0
 			for _, sr := range serverReports {
 				totalIssues += len(sr.Issues)
@@ -144,115 +199,152 @@ func runAllInspection(opts *AllOptions) error {
 	// 生成报告
 	fmt.Println("正在生成综合报告...")
 	generator := report.NewGenerator(opts.Format, opts.Output, opts.Detailed)
+	generator.SetChecks(reportChecks(plan.set))
 	reportPath, err := generator.GenerateFullReport(fullReport)
 	if err != nil {
 		return fmt.Errorf("生成报告失败: %w", err)
 	}
+	recordHistory(opts.Output, fullReport, reportPath)
 
 	// 打印摘要
 	report.PrintSummary(fullReport)
 
 	fmt.Printf("\n✓ 综合报告已保存: %s\n", reportPath)
 
-	// 根据严重问题返回退出码
-	if fullReport.Summary.CriticalIssues > 0 {
-		return fmt.Errorf("发现 %d 个严重问题", fullReport.Summary.CriticalIssues)
+	if fullReport.K8sReport != nil {
+		result.addK8s(fullReport.K8sReport)
 	}
-
-	return nil
+	for _, sr := range fullReport.ServerReports {
+		result.addServer(sr)
+	}
+	return result.err()
 }
 
-// collectHosts 收集要巡检的主机列表
-func collectHosts(opts *AllOptions, k8sReport *models.K8sReport) []string {
-	hostsMap := make(map[string]bool)
+// collectHosts 收集要巡检的主机列表, 按主机清单、--hosts、K8s节点的顺序去重
+func collectHosts(opts *AllOptions, k8sReport *models.K8sReport) ([]*inventory.Host, error) {
+	var hosts []*inventory.Host
+	seen := make(map[string]*inventory.Host)
+	find := func(host *inventory.Host) *inventory.Host {
+		if existing, ok := seen[host.Name]; ok {
+			return existing
+		}
+		return seen[host.Target()]
+	}
+	add := func(host *inventory.Host) {
+		if find(host) != nil {
+			return
+		}
+		seen[host.Name] = host
+		seen[host.Target()] = host
+		hosts = append(hosts, host)
+	}
+
+	// 从主机清单添加
+	inventoryHosts, err := loadInventoryHosts(opts.Inventory, opts.Limit)
+	if err != nil {
+		return nil, err
+	}
+	for _, host := range inventoryHosts {
+		add(host)
+	}
 
 	// 从命令行参数添加
 	if opts.Hosts != "" {
-		hosts := strings.Split(opts.Hosts, ",")
-		for _, host := range hosts {
+		for _, host := range strings.Split(opts.Hosts, ",") {
 			host = strings.TrimSpace(host)
 			if host != "" {
-				hostsMap[host] = true
+				add(&inventory.Host{Name: host})
 			}
 		}
 	}
 
-	// 从K8s节点添加(如果提供了SSH密码)
-	if opts.SSHPassword != "" && k8sReport != nil {
-		for _, node := range k8sReport.Nodes {
-			// 尝试从节点获取IP地址
-			// 实际应该从node.Status.Addresses中获取InternalIP
-			// 这里简化处理
-			if node.Name != "" {
-				hostsMap[node.Name] = true
+	// 从K8s节点添加(如果提供了SSH凭据), 已在清单中的节点只补充节点名称
+	if hasCredentials(opts.SSHPassword, opts.SSHAuth) && k8sReport != nil {
+		for _, nodeHost := range nodeHosts(k8sReport.Nodes, opts.NodeAddressTypes) {
+			if existing := find(nodeHost); existing != nil {
+				if existing.Node == "" {
+					existing.Node = nodeHost.Node
+				}
+				continue
 			}
+			add(nodeHost)
 		}
 	}
 
-	// 转换为切片
-	hosts := make([]string, 0, len(hostsMap))
-	for host := range hostsMap {
-		hosts = append(hosts, host)
-	}
-
-	return hosts
+	return hosts, nil
 }
 
 // inspectServersParallel 并发巡检服务器
-func inspectServersParallel(hosts []string, opts *AllOptions) []*models.ServerReport {
+func inspectServersParallel(hosts []*inventory.Host, login sshLogin, plan *checkPlan) ([]*models.ServerReport, error) {
+	// 使用相同跳板机的目标主机共享跳板连接, ssh_config中为主机配置的ProxyJump优先于全局跳板机
+	jumps := ssh.NewJumpPool()
+	defer jumps.Close()
+	hops, err := jumpHosts(newSSHConfig("", login.Port, login.User, login.Password, login.Auth), login.Auth)
+	if err != nil {
+		return nil, fmt.Errorf("跳板机配置错误: %w", err)
+	}
+
 	var wg sync.WaitGroup
 	var mu sync.Mutex
 	reports := make([]*models.ServerReport, 0)
 
 	// 限制并发数
-	semaphore := make(chan struct{}, 5)
+	semaphore := make(chan struct{}, appConfig.Server.Concurrency)
 
 	for _, host := range hosts {
 		wg.Add(1)
-		go func(h string) {
+		go func(h *inventory.Host) {
 			defer wg.Done()
 			semaphore <- struct{}{}        // 获取信号量
 			defer func() { <-semaphore }() // 释放信号量
 
-			fmt.Printf("  → 正在巡检: %s\n", h)
-
-			// 创建SSH客户端
-			sshClient, err := ssh.NewClient(&ssh.Config{
-				Host:     h,
-				Port:     opts.SSHPort,
-				User:     opts.SSHUser,
-				Password: opts.SSHPassword,
-				Timeout:  30 * time.Second,
-			})
-			if err != nil {
-				fmt.Printf("  ✗ %s: SSH连接失败 - %v\n", h, err)
-				return
-			}
-			defer sshClient.Close()
-
-			// 创建巡检器
-			inspector, err := server.NewInspector(sshClient)
-			if err != nil {
-				fmt.Printf("  ✗ %s: 创建巡检器失败 - %v\n", h, err)
-				return
-			}
-			defer inspector.Close()
+			fmt.Printf("  → 正在巡检: %s\n", h.Name)
 
-			// 执行巡检
-			serverReport, err := inspector.Inspect()
-			if err != nil {
-				fmt.Printf("  ✗ %s: 巡检失败 - %v\n", h, err)
-				return
-			}
+			sshConfig := hostSSHConfig(h, login.Port, login.User, login.Password, login.Auth)
+			sshConfig.JumpHosts = hops
+			sshConfig.JumpPool = jumps
+			serverReport, err := inspectHost(h, sshConfig, plan)
+			applyHostMeta(serverReport, h)
 
 			mu.Lock()
 			reports = append(reports, serverReport)
 			mu.Unlock()
 
-			fmt.Printf("  ✓ %s: 完成 (%d个问题)\n", h, len(serverReport.Issues))
+			if err != nil {
+				fmt.Printf("  ✗ %s: %s - %v\n", h.Name, serverReport.Status, err)
+				return
+			}
+			fmt.Printf("  ✓ %s: 完成 (%d个问题)\n", h.Name, len(serverReport.Issues))
 		}(host)
 	}
 
 	wg.Wait()
-	return reports
+	return reports, nil
+}
+
+// inspectHost 巡检单台主机, 连接或巡检失败时返回记录了失败状态的报告和错误
+func inspectHost(h *inventory.Host, sshConfig *ssh.Config, plan *checkPlan) (*models.ServerReport, error) {
+	// 创建SSH客户端
+	sshClient, err := ssh.NewClient(sshConfig)
+	if err != nil {
+		return connectFailedReport(h.Target(), err, plan.waivers), fmt.Errorf("SSH连接失败: %w", err)
+	}
+	defer sshClient.Close()
+
+	// 创建巡检器
+	inspector, err := server.NewInspector(sshClient, hostThresholds(h))
+	if err != nil {
+		return inspectFailedReport(h.Target(), err, plan.waivers), fmt.Errorf("创建巡检器失败: %w", err)
+	}
+	defer inspector.Close()
+	inspector.SetChecks(plan.set)
+	inspector.SetWaivers(plan.waivers)
+	inspector.SetHistory(plan.history)
+
+	// 执行巡检
+	serverReport, err := inspector.Inspect()
+	if err != nil {
+		return inspectFailedReport(h.Target(), err, plan.waivers), fmt.Errorf("巡检失败: %w", err)
+	}
+	return serverReport, nil
 }
@@ -0,0 +1,142 @@
+package commands
+
+import (
+	"fmt"
+	"inspection-tool/internal/checks"
+	"inspection-tool/internal/rules"
+	"inspection-tool/internal/waivers"
+	"inspection-tool/pkg/report"
+	"os"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+)
+
+// CheckOptions 检查项选择参数
+type CheckOptions struct {
+	Enable  []string
+	Skip    []string
+	Rules   string // 自定义规则文件, 为空时取配置文件checks.rules_file
+	Waivers string // 问题豁免文件, 为空时取配置文件checks.waivers_file
+}
+
+// addFlags 注册 --checks、--skip-checks、--rules 和 --waivers 参数
+func (o *CheckOptions) addFlags(flags *pflag.FlagSet) {
+	flags.StringSliceVar(&o.Enable, "checks", nil, "只执行指定的检查项(逗号分隔, 支持通配符和ID前缀, 如 server.disk,k8s.pod.*)")
+	flags.StringSliceVar(&o.Skip, "skip-checks", nil, "跳过指定的检查项(逗号分隔, 支持通配符和ID前缀)")
+	flags.StringVar(&o.Rules, "rules", "", "自定义规则文件(默认取配置文件checks.rules_file)")
+	flags.StringVar(&o.Waivers, "waivers", "", "问题豁免文件(默认取配置文件checks.waivers_file)")
+}
+
+// checkPlan 本次巡检启用的检查项、豁免和趋势类检查项读取的历史记录
+type checkPlan struct {
+	set     *checks.Set
+	waivers *waivers.List
+	history checks.History // 未启用趋势类检查项或读取失败时为nil
+}
+
+// selection 加载自定义规则, 并按参数从内置检查项和规则中选择本次执行的检查项, 同时加载豁免
+func (o *CheckOptions) selection() (*checkPlan, error) {
+	registry := checks.Default
+	rulesFile := o.Rules
+	if rulesFile == "" {
+		rulesFile = appConfig.Checks.RulesFile
+	}
+	if rulesFile != "" {
+		custom, err := rules.Load(rulesFile)
+		if err != nil {
+			return nil, fmt.Errorf("加载规则文件失败: %w", err)
+		}
+		registry = registry.Clone()
+		for _, c := range custom {
+			if err := registry.Register(c); err != nil {
+				return nil, fmt.Errorf("加载规则文件失败: %w", err)
+			}
+		}
+	}
+
+	set, err := registry.Select(o.Enable, o.Skip)
+	if err != nil {
+		return nil, fmt.Errorf("检查项选择错误: %w", err)
+	}
+	plan := &checkPlan{set: set}
+
+	waiversFile := o.Waivers
+	if waiversFile == "" {
+		waiversFile = appConfig.Checks.WaiversFile
+	}
+	if waiversFile != "" {
+		list, err := waivers.Load(waiversFile)
+		if err != nil {
+			return nil, fmt.Errorf("加载豁免文件失败: %w", err)
+		}
+		plan.waivers = list
+	}
+	return plan, nil
+}
+
+// reportChecks 转换为报告使用的检查项列表, junit和sarif报告据此列出通过的检查项
+func reportChecks(set *checks.Set) []report.CheckInfo {
+	var infos []report.CheckInfo
+	for _, c := range set.Checks() {
+		infos = append(infos, report.CheckInfo{
+			ID:       c.ID(),
+			Category: c.Category(),
+			Severity: c.Severity(),
+			Target:   string(c.Target()),
+		})
+	}
+	return infos
+}
+
+// NewChecksCommand 创建检查项列表命令
+func NewChecksCommand() *cobra.Command {
+	var target string
+	opts := &CheckOptions{}
+
+	cmd := &cobra.Command{
+		Use:   "checks",
+		Short: "列出可用的检查项",
+		Long:  `列出已注册的检查项及其ID、适用对象、类别和默认级别, ID可用于 --checks 和 --skip-checks。`,
+		Example: `  # 列出所有检查项
+  inspection-tool checks
+
+  # 只列出服务器检查项
+  inspection-tool checks --target server
+
+  # 预览某次巡检会执行的检查项
+  inspection-tool checks --skip-checks server.network`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			plan, err := opts.selection()
+			if err != nil {
+				return err
+			}
+			return listChecks(plan.set, target)
+		},
+	}
+
+	cmd.Flags().StringVar(&target, "target", "", "只列出指定对象的检查项(server/k8s)")
+	opts.addFlags(cmd.Flags())
+
+	return cmd
+}
+
+// listChecks 打印检查项列表
+func listChecks(set *checks.Set, target string) error {
+	switch target {
+	case "", string(checks.TargetServer), string(checks.TargetK8s):
+	default:
+		return fmt.Errorf("未知的检查对象: %s", target)
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "ID\tTARGET\tCATEGORY\tSEVERITY")
+	for _, c := range set.Checks() {
+		if target != "" && string(c.Target()) != target {
+			continue
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", c.ID(), c.Target(), c.Category(), c.Severity())
+	}
+	return w.Flush()
+}
@@ -1,6 +1,10 @@
 package commands
 
 import (
+	"fmt"
+	"inspection-tool/internal/ssh"
+	"inspection-tool/internal/waivers"
+	"inspection-tool/pkg/models"
 	"testing"
 )
 
@@ -116,3 +120,140 @@ func TestNewAllCommand(t *testing.T) {
 		t.Error("Expected non-empty short description")
 	}
 }
+
+func TestCollectHostsNodeAddresses(t *testing.T) {
+	opts := &AllOptions{
+		Hosts:            "10.0.0.2",
+		SSHPassword:      "test",
+		NodeAddressTypes: []string{"ExternalIP", "InternalIP"},
+	}
+	k8sReport := &models.K8sReport{
+		Nodes: []models.NodeMetrics{
+			{Name: "node-1", InternalIP: "10.0.0.1", ExternalIP: "1.2.3.4"},
+			{Name: "node-2", InternalIP: "10.0.0.2"},
+		},
+	}
+
+	hosts, err := collectHosts(opts, k8sReport)
+	if err != nil {
+		t.Fatalf("collectHosts failed: %v", err)
+	}
+
+	if len(hosts) != 2 {
+		t.Fatalf("Expected 2 hosts, got %d", len(hosts))
+	}
+
+	// --hosts中已有的地址只补充节点名称
+	if hosts[0].Target() != "10.0.0.2" || hosts[0].Node != "node-2" {
+		t.Errorf("Expected 10.0.0.2 linked to node-2, got %+v", hosts[0])
+	}
+
+	if hosts[1].Target() != "1.2.3.4" || hosts[1].Node != "node-1" {
+		t.Errorf("Expected node-1 via ExternalIP, got %+v", hosts[1])
+	}
+}
+
+func TestConnectFailedReport(t *testing.T) {
+	tests := []struct {
+		err    error
+		status string
+	}{
+		{fmt.Errorf("failed to dial: %w", ssh.ErrAuthFailed), models.HostStatusAuthFailed},
+		{fmt.Errorf("failed to dial: %w", ssh.ErrTimeout), models.HostStatusTimeout},
+		{fmt.Errorf("failed to dial: %w", &ssh.HostKeyChangedError{Host: "web01"}), models.HostStatusAuthFailed},
+		{fmt.Errorf("failed to dial: connection refused"), models.HostStatusUnreachable},
+	}
+
+	for _, tt := range tests {
+		report := connectFailedReport("web01", tt.err, nil)
+		if report.Status != tt.status {
+			t.Errorf("connectFailedReport(%v) status = %s, expected %s", tt.err, report.Status, tt.status)
+		}
+		if !report.Failed() || len(report.Issues) != 1 || report.Issues[0].Level != "critical" {
+			t.Errorf("Expected a single critical issue, got %+v", report.Issues)
+		}
+	}
+
+	if report := inspectFailedReport("web01", fmt.Errorf("exit status 1"), nil); report.Status != models.HostStatusInspectionFailed {
+		t.Errorf("Expected inspection_failed, got %s", report.Status)
+	}
+
+	// 连接失败的问题同样应用豁免
+	list, err := waivers.Parse([]byte(`
+waivers:
+  - host: old-box
+    reason: 待下线
+    owner: ops
+    expires: "2099-12-31"
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if report := connectFailedReport("old-box", fmt.Errorf("connection refused"), list); !report.Issues[0].Suppressed {
+		t.Errorf("Expected waived connection failure, got %+v", report.Issues)
+	}
+	if report := connectFailedReport("web01", fmt.Errorf("connection refused"), list); report.Issues[0].Suppressed {
+		t.Errorf("Expected other hosts not to be waived, got %+v", report.Issues)
+	}
+}
+
+func TestExitPolicy(t *testing.T) {
+	healthy := &models.ServerReport{Host: "10.0.0.1", Status: models.HostStatusWarning, Issues: []models.Issue{
+		{Level: models.SeverityWarning, Category: "memory", Message: "内存使用率过高"},
+		{Level: models.SeverityCritical, Category: "disk", Message: "磁盘使用率过高", Suppressed: true},
+	}}
+	unreachable := connectFailedReport("10.0.0.2", fmt.Errorf("connection refused"), nil)
+	changed := connectFailedReport("10.0.0.3", &ssh.HostKeyChangedError{Host: "10.0.0.3"}, nil)
+	waived := connectFailedReport("10.0.0.4", fmt.Errorf("connection refused"), nil)
+	waived.Issues[0].Suppressed = true
+	degraded := &models.K8sReport{Issues: []models.Issue{
+		{Level: models.SeverityWarning, Category: "etcd", ResourceKind: models.ResourceComponent, ResourceName: "etcd"},
+	}}
+
+	tests := []struct {
+		failOn  string
+		servers []*models.ServerReport
+		k8s     *models.K8sReport
+		code    int
+	}{
+		{"critical", []*models.ServerReport{healthy}, nil, ExitOK},
+		{"warning", []*models.ServerReport{healthy}, nil, ExitIssuesFound},
+		{"never", []*models.ServerReport{healthy}, nil, ExitOK},
+		{"critical", []*models.ServerReport{healthy, unreachable}, nil, ExitCollectionFailed},
+		{"warning", []*models.ServerReport{healthy, unreachable}, nil, ExitIssuesFound},
+		{"never", []*models.ServerReport{unreachable}, nil, ExitCollectionFailed},
+		// 主机密钥变更是发现的问题, 被豁免的不可达主机不计为采集失败
+		{"critical", []*models.ServerReport{changed}, nil, ExitIssuesFound},
+		{"never", []*models.ServerReport{changed}, nil, ExitCollectionFailed},
+		{"critical", []*models.ServerReport{healthy, waived}, nil, ExitOK},
+		// 组件指标获取失败是采集失败, 不计入问题
+		{"warning", nil, degraded, ExitCollectionFailed},
+	}
+
+	for _, tt := range tests {
+		result, err := (&ExitOptions{FailOn: tt.failOn}).outcome()
+		if err != nil {
+			t.Fatalf("outcome(%s) failed: %v", tt.failOn, err)
+		}
+		for _, sr := range tt.servers {
+			result.addServer(sr)
+		}
+		if tt.k8s != nil {
+			result.addK8s(tt.k8s)
+		}
+		if code := ExitCode(result.err()); code != tt.code {
+			t.Errorf("fail-on=%s servers=%d k8s=%v: exit code %d, expected %d", tt.failOn, len(tt.servers), tt.k8s != nil, code, tt.code)
+		}
+	}
+
+	if _, err := (&ExitOptions{FailOn: "info"}).outcome(); err == nil {
+		t.Error("Expected error for invalid --fail-on")
+	}
+	wrapped := fmt.Errorf("任务 nightly: %w", &ExitError{Code: ExitCollectionFailed, Err: fmt.Errorf("1 个巡检对象采集失败")})
+	if code := ExitCode(wrapped); code != ExitCollectionFailed {
+		t.Errorf("Expected wrapped ExitError to keep code %d, got %d", ExitCollectionFailed, code)
+	}
+	if ExitCode(fmt.Errorf("bad config")) != ExitToolError {
+		t.Error("Expected plain errors to be tool errors")
+	}
+}
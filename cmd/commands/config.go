@@ -0,0 +1,65 @@
+package commands
+
+import (
+	"fmt"
+	"inspection-tool/internal/config"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+// appConfig 当前生效的配置, 由根命令在执行子命令前加载
+var appConfig = config.Default()
+
+// InitConfig 加载配置文件, path为空时按默认位置查找
+func InitConfig(path string) error {
+	cfg, err := config.Load(path)
+	if err != nil {
+		return fmt.Errorf("加载配置失败: %w", err)
+	}
+	appConfig = cfg
+	return nil
+}
+
+// AppConfig 返回当前生效的配置
+func AppConfig() *config.Config {
+	return appConfig
+}
+
+// applyReportDefaults 命令行未显式指定时使用配置文件中的报告选项
+func applyReportDefaults(cmd *cobra.Command, output, format *string, detailed *bool) {
+	if !cmd.Flags().Changed("output") && appConfig.Report.OutputDir != "" {
+		*output = appConfig.Report.OutputDir
+	}
+	if !cmd.Flags().Changed("format") && appConfig.Report.Format != "" {
+		*format = appConfig.Report.Format
+	}
+	if !cmd.Flags().Changed("detailed") {
+		*detailed = appConfig.Report.Detailed
+	}
+}
+
+// applyK8sDefaults 命令行未显式指定时使用配置文件中的K8s选项
+func applyK8sDefaults(cmd *cobra.Command, kubeconfig, namespaces *string) {
+	if !cmd.Flags().Changed("kubeconfig") && appConfig.K8s.Kubeconfig != "" {
+		*kubeconfig = config.ExpandHome(appConfig.K8s.Kubeconfig)
+	}
+	if !cmd.Flags().Changed("namespaces") && len(appConfig.K8s.Namespaces) > 0 {
+		*namespaces = strings.Join(appConfig.K8s.Namespaces, ",")
+	}
+}
+
+// applyNodeAddressDefaults 命令行未显式指定时使用配置文件中的节点地址优先顺序
+func applyNodeAddressDefaults(cmd *cobra.Command, types *[]string) error {
+	if !cmd.Flags().Changed("node-address-types") {
+		*types = appConfig.K8s.NodeAddressTypes
+		return nil
+	}
+	return config.ValidateNodeAddressTypes(*types)
+}
+
+// sshTimeout 配置的SSH连接超时
+func sshTimeout() time.Duration {
+	return time.Duration(appConfig.Server.Timeout) * time.Second
+}
@@ -0,0 +1,351 @@
+package commands
+
+import (
+	"fmt"
+	"inspection-tool/internal/config"
+	"inspection-tool/internal/metrics"
+	"inspection-tool/internal/schedule"
+	"inspection-tool/pkg/report"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+// DaemonOptions 守护进程选项
+type DaemonOptions struct {
+	RunOnStart  bool   // 启动后立即执行一次所有任务
+	MetricsAddr string // Prometheus指标接口的监听地址, 为空时不启用
+}
+
+// NewDaemonCommand 创建守护进程命令
+func NewDaemonCommand() *cobra.Command {
+	opts := &DaemonOptions{}
+
+	cmd := &cobra.Command{
+		Use:   "daemon",
+		Short: "按定时规则持续执行巡检",
+		Long: `按配置文件中 daemon.jobs 的定时规则执行 server、k8s、all 巡检, 报告和历史记录与直接执行命令时相同,
+每次巡检后按 report.retention_days 清理旧报告。
+
+任务依次执行, 同一任务上一次尚未结束时不会重复执行。
+收到 SIGHUP 时重新加载配置文件(有任务在执行时等其结束后加载), 加载失败时继续使用原配置;
+收到 SIGINT 或 SIGTERM 时不再开始新任务, 等待正在执行的巡检结束后退出, 再次收到时立即退出。
+
+指定 --metrics-addr 时在 /metrics 以Prometheus文本格式提供每台服务器和每个集群最近一次的巡检结果,
+启动时从各任务输出目录的巡检历史加载最近一次的json或yaml报告。`,
+		Example: `  # 使用配置文件中的任务
+  inspection-tool daemon --config /etc/inspection-tool/config.yaml
+
+  # 启动后立即执行一次
+  inspection-tool daemon --run-on-start
+
+  # 提供Prometheus指标接口
+  inspection-tool daemon --metrics-addr :9105`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if !cmd.Flags().Changed("metrics-addr") {
+				opts.MetricsAddr = appConfig.Daemon.MetricsAddr
+			}
+			return runDaemon(opts)
+		},
+	}
+
+	cmd.Flags().BoolVar(&opts.RunOnStart, "run-on-start", false, "启动后立即执行一次所有任务")
+	cmd.Flags().StringVar(&opts.MetricsAddr, "metrics-addr", "", "Prometheus指标接口的监听地址, 如 :9105(默认取配置项 daemon.metrics_addr)")
+
+	return cmd
+}
+
+// runDaemon 运行守护进程直到收到退出信号
+func runDaemon(opts *DaemonOptions) error {
+	jobs, err := newScheduledJobs(appConfig)
+	if err != nil {
+		return err
+	}
+	if len(jobs) == 0 {
+		return fmt.Errorf("未配置定时任务, 请在配置文件的 daemon.jobs 中添加")
+	}
+
+	if opts.MetricsAddr != "" {
+		exporter := metrics.NewExporter()
+		loaded := make(map[string]bool)
+		for _, job := range jobs {
+			if loaded[job.output] {
+				continue
+			}
+			loaded[job.output] = true
+			if err := loadLatestReports(exporter, job.output); err != nil {
+				fmt.Printf("警告: 加载 %s 中的巡检结果失败: %v\n", job.output, err)
+			}
+		}
+		srv, err := startMetricsServer(opts.MetricsAddr, exporter)
+		if err != nil {
+			return err
+		}
+		defer srv.Close()
+		metricsExporter = exporter
+		logf("指标接口: http://%s/metrics", opts.MetricsAddr)
+	}
+
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, syscall.SIGHUP, syscall.SIGINT, syscall.SIGTERM)
+	defer signal.Stop(signals)
+
+	d := &daemon{
+		jobs:   jobs,
+		run:    runDaemonJob,
+		reload: reloadDaemonConfig,
+		logf:   logf,
+	}
+	if appConfig.File != "" {
+		d.logf("已加载配置文件: %s", appConfig.File)
+	}
+	return d.loop(signals, opts.RunOnStart)
+}
+
+// scheduledJob 已解析定时规则的任务
+type scheduledJob struct {
+	config.DaemonJob
+	schedule schedule.Schedule
+	output   string    // 报告输出目录
+	next     time.Time // 下次执行时间
+}
+
+// newScheduledJobs 解析配置中的任务, 并校验任务的命令行参数
+func newScheduledJobs(cfg *config.Config) ([]*scheduledJob, error) {
+	jobs := make([]*scheduledJob, 0, len(cfg.Daemon.Jobs))
+	for i, job := range cfg.Daemon.Jobs {
+		if job.Name == "" {
+			job.Name = fmt.Sprintf("%s-%d", job.Command, i+1)
+		}
+		s, err := cfg.JobSchedule(job)
+		if err != nil {
+			return nil, fmt.Errorf("任务 %s: %w", job.Name, err)
+		}
+		cmd, err := newInspectionCommand(job.Command)
+		if err != nil {
+			return nil, fmt.Errorf("任务 %s: %w", job.Name, err)
+		}
+		if err := cmd.ParseFlags(job.Args); err != nil {
+			return nil, fmt.Errorf("任务 %s 的参数无效: %w", job.Name, err)
+		}
+		output := cmd.Flags().Lookup("output")
+		if !output.Changed && cfg.Report.OutputDir != "" {
+			output.Value.Set(cfg.Report.OutputDir)
+		}
+		jobs = append(jobs, &scheduledJob{DaemonJob: job, schedule: s, output: output.Value.String()})
+	}
+	return jobs, nil
+}
+
+// newInspectionCommand 创建 server、k8s 或 all 巡检命令
+func newInspectionCommand(command string) (*cobra.Command, error) {
+	switch command {
+	case "server":
+		return NewServerCommand(), nil
+	case "k8s":
+		return NewK8sCommand(), nil
+	case "all":
+		return NewAllCommand(), nil
+	default:
+		return nil, fmt.Errorf("无效的命令: %s (可选 server/k8s/all)", command)
+	}
+}
+
+// runDaemonJob 以任务的命令行参数执行一次巡检, 然后清理报告输出目录中的旧报告
+func runDaemonJob(job config.DaemonJob) error {
+	cmd, err := newInspectionCommand(job.Command)
+	if err != nil {
+		return err
+	}
+	// args为nil时cobra会使用进程的命令行参数
+	cmd.SetArgs(append([]string{}, job.Args...))
+	cmd.SilenceUsage = true
+	cmd.SilenceErrors = true
+	runErr := cmd.Execute()
+
+	if flag := cmd.Flags().Lookup("output"); flag != nil && flag.Value.String() != "" {
+		if err := report.CleanupOldReports(flag.Value.String(), appConfig.Report.RetentionDays); err != nil && !os.IsNotExist(err) {
+			fmt.Printf("警告: 清理旧报告失败: %v\n", err)
+		}
+	}
+	return runErr
+}
+
+// reloadDaemonConfig 重新加载配置文件, 成功后替换当前配置并返回新的任务列表
+func reloadDaemonConfig() ([]*scheduledJob, error) {
+	cfg, err := config.Load(appConfig.File)
+	if err != nil {
+		return nil, err
+	}
+	jobs, err := newScheduledJobs(cfg)
+	if err != nil {
+		return nil, err
+	}
+	if len(jobs) == 0 {
+		return nil, fmt.Errorf("未配置定时任务")
+	}
+	appConfig = cfg
+	return jobs, nil
+}
+
+// logf 输出带时间的日志
+func logf(format string, args ...interface{}) {
+	fmt.Printf("[%s] %s\n", time.Now().Format("2006-01-02 15:04:05"), fmt.Sprintf(format, args...))
+}
+
+// daemon 按定时规则依次执行任务, 同一时间只执行一个任务
+type daemon struct {
+	jobs   []*scheduledJob
+	run    func(job config.DaemonJob) error
+	reload func() ([]*scheduledJob, error)
+	logf   func(format string, args ...interface{})
+}
+
+// loop 调度任务直到收到SIGINT或SIGTERM, 正在执行的任务结束后返回, 再次收到时立即返回错误
+func (d *daemon) loop(signals <-chan os.Signal, runOnStart bool) error {
+	now := time.Now()
+	for _, job := range d.jobs {
+		job.next = job.schedule.Next(now)
+		if runOnStart {
+			job.next = now
+		}
+	}
+	d.logJobs()
+
+	var (
+		queue         []*scheduledJob
+		running       *scheduledJob
+		done          = make(chan error, 1)
+		stopping      bool
+		reloadPending bool
+	)
+
+	for {
+		if running == nil {
+			if stopping {
+				d.logf("守护进程已退出")
+				return nil
+			}
+			if reloadPending {
+				reloadPending = false
+				d.reloadJobs()
+				queue = nil
+			}
+			if len(queue) > 0 {
+				running, queue = queue[0], queue[1:]
+				d.logf("开始执行任务 %s", running.Name)
+				go func(job config.DaemonJob) {
+					done <- d.run(job)
+				}(running.DaemonJob)
+			}
+		}
+
+		var timer *time.Timer
+		var due <-chan time.Time
+		if next := d.nextTime(); !stopping && !next.IsZero() {
+			timer = time.NewTimer(time.Until(next))
+			due = timer.C
+		}
+
+		select {
+		case <-due:
+			queue = d.enqueueDue(queue, running, time.Now())
+
+		case err := <-done:
+			switch ExitCode(err) {
+			case ExitOK:
+				d.logf("任务 %s 完成", running.Name)
+			case ExitIssuesFound, ExitCollectionFailed:
+				d.logf("任务 %s 完成: %v", running.Name, err)
+			default:
+				d.logf("任务 %s 失败: %v", running.Name, err)
+			}
+			running = nil
+
+		case sig := <-signals:
+			switch {
+			case sig == syscall.SIGHUP && running == nil:
+				d.reloadJobs()
+				queue = nil
+			case sig == syscall.SIGHUP:
+				d.logf("收到 %s, 任务 %s 结束后重新加载配置", sig, running.Name)
+				reloadPending = true
+			case stopping:
+				return fmt.Errorf("再次收到 %s, 不等待任务 %s 结束, 立即退出", sig, running.Name)
+			default:
+				stopping = true
+				queue = nil
+				if running != nil {
+					d.logf("收到 %s, 等待任务 %s 结束后退出", sig, running.Name)
+				}
+			}
+		}
+
+		if timer != nil {
+			timer.Stop()
+		}
+	}
+}
+
+// nextTime 最早的下次执行时间, 没有时返回零值
+func (d *daemon) nextTime() time.Time {
+	var next time.Time
+	for _, job := range d.jobs {
+		if !job.next.IsZero() && (next.IsZero() || job.next.Before(next)) {
+			next = job.next
+		}
+	}
+	return next
+}
+
+// enqueueDue 将到期的任务加入队列并计算其下次执行时间, 已在执行或排队的任务不重复加入
+func (d *daemon) enqueueDue(queue []*scheduledJob, running *scheduledJob, now time.Time) []*scheduledJob {
+	for _, job := range d.jobs {
+		if job.next.IsZero() || job.next.After(now) {
+			continue
+		}
+		job.next = job.schedule.Next(now)
+
+		pending := job == running
+		for _, queued := range queue {
+			pending = pending || queued == job
+		}
+		if pending {
+			d.logf("任务 %s 上一次尚未结束, 跳过本次执行", job.Name)
+			continue
+		}
+		queue = append(queue, job)
+	}
+	return queue
+}
+
+// reloadJobs 重新加载配置, 失败时继续使用原来的任务
+func (d *daemon) reloadJobs() {
+	jobs, err := d.reload()
+	if err != nil {
+		d.logf("重新加载配置失败, 继续使用原配置: %v", err)
+		return
+	}
+	now := time.Now()
+	for _, job := range jobs {
+		job.next = job.schedule.Next(now)
+	}
+	d.jobs = jobs
+	d.logf("已重新加载配置")
+	d.logJobs()
+}
+
+// logJobs 输出各任务的下次执行时间
+func (d *daemon) logJobs() {
+	for _, job := range d.jobs {
+		if job.next.IsZero() {
+			d.logf("任务 %s (%s): 没有下次执行时间", job.Name, job.Command)
+			continue
+		}
+		d.logf("任务 %s (%s): 下次执行 %s", job.Name, job.Command, job.next.Format("2006-01-02 15:04:05"))
+	}
+}
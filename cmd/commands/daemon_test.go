@@ -0,0 +1,182 @@
+package commands
+
+import (
+	"encoding/json"
+	"fmt"
+	"inspection-tool/internal/config"
+	"inspection-tool/internal/history"
+	"inspection-tool/internal/metrics"
+	"inspection-tool/internal/schedule"
+	"inspection-tool/pkg/models"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"syscall"
+	"testing"
+	"time"
+)
+
+func TestNewScheduledJobs(t *testing.T) {
+	cfg := config.Default()
+	cfg.Daemon.Jobs = []config.DaemonJob{
+		{Command: "server", Schedule: "*/5 * * * *", Args: []string{"--inventory", "hosts.yaml"}},
+		{Name: "cluster", Command: "k8s"},
+	}
+	jobs, err := newScheduledJobs(cfg)
+	if err != nil {
+		t.Fatalf("newScheduledJobs failed: %v", err)
+	}
+	if len(jobs) != 2 || jobs[0].Name != "server-1" || jobs[1].Name != "cluster" {
+		t.Errorf("unexpected jobs: %+v", jobs)
+	}
+
+	// 参数在启动时校验
+	cfg.Daemon.Jobs[0].Args = []string{"--no-such-flag"}
+	if _, err := newScheduledJobs(cfg); err == nil {
+		t.Error("expected error for unknown flag")
+	}
+}
+
+func TestDaemonLoop(t *testing.T) {
+	var (
+		mu      sync.Mutex
+		runs    []string
+		started = make(chan string, 10)
+		release = make(chan struct{})
+		reloads int
+	)
+	d := &daemon{
+		jobs: []*scheduledJob{
+			{DaemonJob: config.DaemonJob{Name: "slow", Command: "server"}, schedule: schedule.Every(20 * time.Millisecond)},
+		},
+		run: func(job config.DaemonJob) error {
+			mu.Lock()
+			runs = append(runs, job.Name)
+			mu.Unlock()
+			started <- job.Name
+			<-release
+			return &ExitError{Code: ExitIssuesFound, Err: fmt.Errorf("发现 1 个critical及以上级别的问题")}
+		},
+		reload: func() ([]*scheduledJob, error) {
+			reloads++
+			return nil, fmt.Errorf("broken config")
+		},
+		logf: func(format string, args ...interface{}) {},
+	}
+
+	signals := make(chan os.Signal, 1)
+	result := make(chan error, 1)
+	go func() { result <- d.loop(signals, true) }()
+
+	select {
+	case <-started:
+	case <-time.After(time.Second):
+		t.Fatal("job did not start")
+	}
+
+	// 任务执行期间到期的同一任务不重复执行, 配置在任务结束后重新加载, 退出时等待任务结束
+	time.Sleep(60 * time.Millisecond)
+	signals <- syscall.SIGHUP
+	signals <- syscall.SIGTERM
+	select {
+	case err := <-result:
+		t.Fatalf("loop returned before job finished: %v", err)
+	case <-time.After(50 * time.Millisecond):
+	}
+	close(release)
+
+	select {
+	case err := <-result:
+		if err != nil {
+			t.Errorf("loop returned error: %v", err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("loop did not return after job finished")
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	if len(runs) != 1 {
+		t.Errorf("expected 1 run, got %v", runs)
+	}
+	if reloads != 0 {
+		t.Errorf("expected no reload while stopping, got %d", reloads)
+	}
+}
+
+func TestDaemonReload(t *testing.T) {
+	ran := make(chan string, 10)
+	d := &daemon{
+		jobs: []*scheduledJob{
+			{DaemonJob: config.DaemonJob{Name: "old", Command: "server"}, schedule: schedule.Every(time.Hour)},
+		},
+		run: func(job config.DaemonJob) error {
+			ran <- job.Name
+			return nil
+		},
+		reload: func() ([]*scheduledJob, error) {
+			return []*scheduledJob{
+				{DaemonJob: config.DaemonJob{Name: "new", Command: "k8s"}, schedule: schedule.Every(10 * time.Millisecond)},
+			}, nil
+		},
+		logf: func(format string, args ...interface{}) {},
+	}
+
+	signals := make(chan os.Signal, 1)
+	result := make(chan error, 1)
+	go func() { result <- d.loop(signals, false) }()
+
+	signals <- syscall.SIGHUP
+	select {
+	case name := <-ran:
+		if name != "new" {
+			t.Errorf("expected reloaded job to run, got %s", name)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("reloaded job did not run")
+	}
+
+	signals <- syscall.SIGINT
+	select {
+	case <-result:
+	case <-time.After(time.Second):
+		t.Fatal("loop did not return")
+	}
+}
+
+func TestLoadLatestReports(t *testing.T) {
+	dir := t.TempDir()
+	store, err := history.Open(dir)
+	if err != nil {
+		t.Fatalf("Open failed: %v", err)
+	}
+
+	// 同一台服务器的两次巡检, 只加载较新的一次; html报告无法加载
+	base := time.Date(2024, 1, 1, 8, 0, 0, 0, time.UTC)
+	for i, usage := range []float64{10, 20} {
+		sr := &models.ServerReport{Host: "10.0.0.1", Timestamp: base.Add(time.Duration(i) * time.Hour), CPU: models.CPUMetrics{UsagePercent: usage}}
+		path := filepath.Join(dir, fmt.Sprintf("server_%d.json", i))
+		data, _ := json.Marshal(sr)
+		if err := os.WriteFile(path, data, 0644); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := store.Add(serverInspection(sr), path); err != nil {
+			t.Fatalf("Add failed: %v", err)
+		}
+	}
+	html := &models.ServerReport{Host: "10.0.0.2", Timestamp: base}
+	if _, err := store.Add(serverInspection(html), filepath.Join(dir, "server.html")); err != nil {
+		t.Fatalf("Add failed: %v", err)
+	}
+
+	exporter := metrics.NewExporter()
+	if err := loadLatestReports(exporter, dir); err != nil {
+		t.Fatalf("loadLatestReports failed: %v", err)
+	}
+	var sb strings.Builder
+	exporter.Write(&sb)
+	if out := sb.String(); !strings.Contains(out, `inspection_cpu_usage_percent{host="10.0.0.1"} 20`) || strings.Contains(out, "10.0.0.2") {
+		t.Errorf("unexpected metrics:\n%s", out)
+	}
+}
@@ -0,0 +1,72 @@
+package commands
+
+import (
+	"fmt"
+	"inspection-tool/internal/diff"
+	"inspection-tool/pkg/report"
+	"io"
+	"os"
+
+	"github.com/spf13/cobra"
+)
+
+// DiffOptions 报告差异选项
+type DiffOptions struct {
+	Format    string
+	Output    string
+	MinChange float64
+}
+
+// NewDiffCommand 创建报告差异命令
+func NewDiffCommand() *cobra.Command {
+	opts := &DiffOptions{}
+
+	cmd := &cobra.Command{
+		Use:   "diff <old-report> <new-report>",
+		Short: "比较两次巡检的报告",
+		Long: `比较两次巡检的报告(json或yaml, 完整报告、服务器报告或K8s报告均可), 输出:
+- 按指纹比较的新增、已解决和持续存在的问题
+- 显著变化的指标(磁盘、内存、CPU使用率, 节点使用率, Pod数量和重启次数)
+- 服务器状态和节点就绪状态的变化
+- 新增或消失的服务器、挂载点、节点和Pod`,
+		Example: `  # 与昨天的报告比较
+  inspection-tool diff reports/inspection_all_20240101_020000.json reports/inspection_all_20240102_020000.json
+
+  # 输出Markdown, 只显示变化超过10个百分点的指标
+  inspection-tool diff old.json new.json --format markdown --min-change 10`,
+		Args: cobra.ExactArgs(2),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runDiff(args[0], args[1], opts)
+		},
+	}
+
+	cmd.Flags().StringVar(&opts.Format, "format", "text", "输出格式(text/markdown/json)")
+	cmd.Flags().StringVar(&opts.Output, "output", "", "输出文件(默认输出到标准输出)")
+	cmd.Flags().Float64Var(&opts.MinChange, "min-change", diff.DefaultMinChange, "百分比指标的显著变化阈值(百分点)")
+
+	return cmd
+}
+
+func runDiff(oldPath, newPath string, opts *DiffOptions) error {
+	oldReport, err := report.Load(oldPath)
+	if err != nil {
+		return fmt.Errorf("读取旧报告失败: %w", err)
+	}
+	newReport, err := report.Load(newPath)
+	if err != nil {
+		return fmt.Errorf("读取新报告失败: %w", err)
+	}
+
+	result := diff.Compare(oldReport, newReport, diff.Options{MinChange: opts.MinChange})
+
+	var w io.Writer = os.Stdout
+	if opts.Output != "" {
+		f, err := os.Create(opts.Output)
+		if err != nil {
+			return fmt.Errorf("创建输出文件失败: %w", err)
+		}
+		defer f.Close()
+		w = f
+	}
+	return diff.Write(w, result, opts.Format)
+}
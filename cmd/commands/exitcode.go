@@ -0,0 +1,135 @@
+package commands
+
+import (
+	"errors"
+	"fmt"
+	"inspection-tool/pkg/models"
+	"inspection-tool/pkg/utils"
+	"strings"
+
+	"github.com/spf13/pflag"
+)
+
+// 退出码, server、k8s 和 all 命令一致, 多种情况同时出现时取靠前的一种
+const (
+	ExitOK               = 0
+	ExitToolError        = 1 // 参数、配置错误, 或报告无法生成等工具自身的错误
+	ExitIssuesFound      = 2 // 发现达到 --fail-on 级别的问题
+	ExitCollectionFailed = 3 // 部分或全部巡检对象采集失败, 如主机不可达、采集步骤失败
+)
+
+// ExitError 带退出码的错误, 由 main 转换为进程退出码
+type ExitError struct {
+	Code int
+	Err  error
+}
+
+func (e *ExitError) Error() string { return e.Err.Error() }
+func (e *ExitError) Unwrap() error { return e.Err }
+
+// ExitCode 错误对应的退出码, 错误链中没有 ExitError 时视为工具错误
+func ExitCode(err error) int {
+	if err == nil {
+		return ExitOK
+	}
+	var exitErr *ExitError
+	if errors.As(err, &exitErr) {
+		return exitErr.Code
+	}
+	return ExitToolError
+}
+
+// ExitOptions 退出码策略参数
+type ExitOptions struct {
+	FailOn string // critical, warning, never
+}
+
+// addFlags 注册 --fail-on 参数
+func (o *ExitOptions) addFlags(flags *pflag.FlagSet) {
+	flags.StringVar(&o.FailOn, "fail-on", "critical", "发现该级别及以上的问题时以退出码2退出(critical/warning/never)")
+}
+
+// outcome 解析 --fail-on, 返回用于汇总巡检结果的对象
+func (o *ExitOptions) outcome() (*outcome, error) {
+	switch o.FailOn {
+	case "critical", "warning":
+		return &outcome{failOn: models.Severity(o.FailOn)}, nil
+	case "never":
+		return &outcome{}, nil
+	default:
+		return nil, fmt.Errorf("无效的 --fail-on: %s (可选 critical/warning/never)", o.FailOn)
+	}
+}
+
+// outcome 汇总本次巡检的问题和采集失败, 决定退出码
+type outcome struct {
+	failOn   models.Severity // 为空表示问题不影响退出码
+	issues   int
+	failures []string
+}
+
+// addServer 统计服务器报告, 未采集到指标的服务器和未完整执行的采集步骤记为采集失败
+// 未采集到指标的服务器: 失败问题全部被豁免时不计为采集失败; 主机密钥变更等安全问题按 --fail-on 计为发现的问题
+func (o *outcome) addServer(sr *models.ServerReport) {
+	if sr.Failed() {
+		var security []models.Issue
+		waived := true
+		for _, issue := range sr.Issues {
+			if issue.Category == "security" {
+				security = append(security, issue)
+			}
+			if !issue.Suppressed {
+				waived = false
+			}
+		}
+		if !waived || len(sr.Issues) == 0 {
+			o.addFailure(sr.Host)
+		}
+		o.addIssues(security)
+		return
+	}
+	for _, c := range sr.Collectors {
+		if c.Status != models.CollectorOK {
+			o.addFailure(fmt.Sprintf("%s/%s", sr.Host, c.Name))
+		}
+	}
+	o.addIssues(sr.Issues)
+}
+
+// addK8s 统计K8s报告, 控制平面组件指标获取失败记为采集失败
+func (o *outcome) addK8s(r *models.K8sReport) {
+	for _, issue := range r.Issues {
+		if issue.CollectionFailure() {
+			o.addFailure(fmt.Sprintf("k8s/%s", issue.ResourceName))
+		}
+	}
+	o.addIssues(r.Issues)
+}
+
+// addIssues 统计达到 --fail-on 级别的问题
+func (o *outcome) addIssues(issues []models.Issue) {
+	if o.failOn != "" {
+		o.issues += utils.CountIssues(issues, o.failOn)
+	}
+}
+
+// addFailure 记录采集失败的巡检对象
+func (o *outcome) addFailure(name string) {
+	o.failures = append(o.failures, name)
+}
+
+// err 巡检结果对应的错误, 问题优先于采集失败
+func (o *outcome) err() error {
+	if o.issues > 0 {
+		return &ExitError{Code: ExitIssuesFound, Err: fmt.Errorf("发现 %d 个%s及以上级别的问题", o.issues, o.failOn)}
+	}
+	if len(o.failures) > 0 {
+		return &ExitError{Code: ExitCollectionFailed, Err: fmt.Errorf("%d 个巡检对象采集失败: %s", len(o.failures), strings.Join(o.failures, ", "))}
+	}
+	return nil
+}
+
+// collectionFailed 整个巡检对象采集失败且没有可用报告时的错误
+func collectionFailed(err error) error {
+	return &ExitError{Code: ExitCollectionFailed, Err: err}
+}
@@ -0,0 +1,281 @@
+package commands
+
+import (
+	"encoding/json"
+	"fmt"
+	"inspection-tool/internal/history"
+	"inspection-tool/internal/trend"
+	"inspection-tool/pkg/models"
+	"io"
+	"os"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+// HistoryOptions 历史记录查询选项
+type HistoryOptions struct {
+	Output string // 报告输出目录, 历史记录位于其中的history子目录
+	Format string // text, json
+}
+
+// apply 命令行未显式指定时使用配置文件中的报告输出目录, 并校验输出格式
+func (o *HistoryOptions) apply(cmd *cobra.Command) error {
+	if !cmd.Flags().Changed("output") && appConfig.Report.OutputDir != "" {
+		o.Output = appConfig.Report.OutputDir
+	}
+	switch o.Format {
+	case "text", "json":
+		return nil
+	default:
+		return fmt.Errorf("不支持的输出格式: %s (可选 text/json)", o.Format)
+	}
+}
+
+// NewHistoryCommand 创建历史记录查询命令
+func NewHistoryCommand() *cobra.Command {
+	opts := &HistoryOptions{}
+
+	cmd := &cobra.Command{
+		Use:   "history",
+		Short: "查询历次巡检的记录",
+		Long: `每次巡检保存报告时, 指标和问题同时记录到报告输出目录下的history子目录,
+超过配置项 report.retention_days 的记录在下次巡检时删除。`,
+		Example: `  # 列出最近10次巡检
+  inspection-tool history runs --last 10
+
+  # 查看服务器的磁盘使用率变化
+  inspection-tool history host 192.168.1.100 --metric disk.usage_percent
+
+  # 查看仍存在的问题及首次出现时间
+  inspection-tool history issues --host 192.168.1.100`,
+	}
+
+	cmd.PersistentFlags().StringVar(&opts.Output, "output", "./reports", "报告输出目录")
+	cmd.PersistentFlags().StringVar(&opts.Format, "format", "text", "输出格式(text/json)")
+
+	cmd.AddCommand(newHistoryRunsCommand(opts))
+	cmd.AddCommand(newHistoryHostCommand(opts))
+	cmd.AddCommand(newHistoryIssuesCommand(opts))
+
+	return cmd
+}
+
+func newHistoryRunsCommand(opts *HistoryOptions) *cobra.Command {
+	var last int
+
+	cmd := &cobra.Command{
+		Use:   "runs",
+		Short: "列出历次巡检",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if err := opts.apply(cmd); err != nil {
+				return err
+			}
+			store, err := history.Open(opts.Output)
+			if err != nil {
+				return err
+			}
+			runs, err := store.Runs()
+			if err != nil {
+				return err
+			}
+			if len(runs) == 0 {
+				return fmt.Errorf("%s 中没有巡检记录", store.Dir())
+			}
+			if last > 0 && len(runs) > last {
+				runs = runs[len(runs)-last:]
+			}
+			return writeRuns(os.Stdout, runs, opts.Format)
+		},
+	}
+
+	cmd.Flags().IntVar(&last, "last", 0, "只列出最近的N次巡检")
+	return cmd
+}
+
+func newHistoryHostCommand(opts *HistoryOptions) *cobra.Command {
+	var metric string
+
+	cmd := &cobra.Command{
+		Use:   "host <host>",
+		Short: "查看服务器或集群的指标变化",
+		Long: `按时间顺序列出巡检对象的指标, 巡检对象为服务器地址或集群API地址。
+指标名称与报告中的字段一致, 如 cpu.usage_percent、memory.usage_percent、disk.usage_percent、
+disk.inodes_percent、node.pod_percent; --metric 也可以是前缀, 如 disk。`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if err := opts.apply(cmd); err != nil {
+				return err
+			}
+			records, err := loadHistory(opts.Output)
+			if err != nil {
+				return err
+			}
+			series := history.MetricSeries(records, args[0], metric)
+			if len(series) == 0 {
+				return fmt.Errorf("没有 %s 的指标记录", args[0])
+			}
+			return writeSeries(os.Stdout, series, opts.Format)
+		},
+	}
+
+	cmd.Flags().StringVar(&metric, "metric", "", "只显示指定的指标或指标前缀")
+	return cmd
+}
+
+func newHistoryIssuesCommand(opts *HistoryOptions) *cobra.Command {
+	var host, check string
+	var all bool
+
+	cmd := &cobra.Command{
+		Use:   "issues",
+		Short: "查看问题的首次出现时间",
+		Long:  `按指纹汇总历次巡检中的问题, 列出首次和最近出现的时间及出现次数。默认只列出仍存在的问题。`,
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if err := opts.apply(cmd); err != nil {
+				return err
+			}
+			records, err := loadHistory(opts.Output)
+			if err != nil {
+				return err
+			}
+			var issues []*history.IssueHistory
+			for _, h := range history.IssueHistories(records) {
+				if !all && !h.Active {
+					continue
+				}
+				if host != "" && h.Target != host {
+					continue
+				}
+				if check != "" && h.CheckID != check && !strings.HasPrefix(h.CheckID, check+".") {
+					continue
+				}
+				issues = append(issues, h)
+			}
+			return writeIssueHistories(os.Stdout, issues, opts.Format)
+		},
+	}
+
+	cmd.Flags().StringVar(&host, "host", "", "只列出指定巡检对象的问题")
+	cmd.Flags().StringVar(&check, "check", "", "只列出指定检查项(ID或前缀)的问题")
+	cmd.Flags().BoolVar(&all, "all", false, "同时列出已解决的问题")
+	return cmd
+}
+
+// loadHistory 读取报告输出目录下的历史记录
+func loadHistory(outputDir string) ([]*history.Record, error) {
+	store, err := history.Open(outputDir)
+	if err != nil {
+		return nil, err
+	}
+	records, err := store.Records()
+	if err != nil {
+		return nil, err
+	}
+	if len(records) == 0 {
+		return nil, fmt.Errorf("%s 中没有巡检记录", store.Dir())
+	}
+	return records, nil
+}
+
+// recordHistory 将保存的报告记录到历史记录和报告清单并清理过期记录, 失败时只打印警告
+// 提供 /metrics 接口时同时更新其中的巡检结果
+func recordHistory(outputDir string, inspection *models.InspectionReport, reportPath string) {
+	metricsExporter.Update(inspection)
+	if err := appendReportManifest(reportPath); err != nil {
+		fmt.Printf("警告: 写入报告清单失败: %v\n", err)
+	}
+
+	store, err := history.Open(outputDir)
+	if err == nil {
+		_, err = store.Add(inspection, reportPath)
+	}
+	if err == nil {
+		_, err = store.Prune(appConfig.Report.RetentionDays, time.Now())
+	}
+	if err != nil {
+		fmt.Printf("警告: 记录巡检历史失败: %v\n", err)
+	}
+}
+
+// attachHistory 启用了容量预测检查项时加载预测窗口内的历史记录, 读取失败时只打印警告并跳过预测
+func attachHistory(plan *checkPlan, outputDir string) {
+	if !trend.Enabled(plan.set) {
+		return
+	}
+	store, err := history.Open(outputDir)
+	var records []*history.Record
+	if err == nil {
+		records, err = store.RecordsSince(time.Now().AddDate(0, 0, -appConfig.Trend.WindowDays))
+	}
+	if err != nil {
+		fmt.Printf("警告: 读取巡检历史失败, 跳过容量预测: %v\n", err)
+		return
+	}
+	plan.history = trend.NewHistory(records, appConfig.Trend)
+}
+
+// serverInspection 将单台服务器的报告包装为历史记录使用的巡检报告
+func serverInspection(sr *models.ServerReport) *models.InspectionReport {
+	return &models.InspectionReport{Timestamp: sr.Timestamp, Type: "server", ServerReport: sr}
+}
+
+func writeRuns(w io.Writer, runs []history.Run, format string) error {
+	if format == "json" {
+		return writeJSON(w, runs)
+	}
+
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "TIME\tTYPE\tTARGETS\tCRITICAL\tWARNING\tINFO\tFAILED\tREPORT")
+	for _, r := range runs {
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%d\t%d\t%d\t%d\t%s\n", r.Timestamp.Format("2006-01-02 15:04:05"),
+			r.Type, strings.Join(r.Targets, ","), r.Critical, r.Warning, r.Info, len(r.Failed), r.Report)
+	}
+	return tw.Flush()
+}
+
+func writeSeries(w io.Writer, series []*history.Series, format string) error {
+	if format == "json" {
+		return writeJSON(w, series)
+	}
+
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "METRIC\tRESOURCE\tTIME\tVALUE")
+	for _, s := range series {
+		for _, p := range s.Points {
+			fmt.Fprintf(tw, "%s\t%s\t%s\t%.2f\n", s.Metric, s.Resource, p.Timestamp.Format("2006-01-02 15:04:05"), p.Value)
+		}
+	}
+	return tw.Flush()
+}
+
+func writeIssueHistories(w io.Writer, issues []*history.IssueHistory, format string) error {
+	if format == "json" {
+		if issues == nil {
+			issues = []*history.IssueHistory{}
+		}
+		return writeJSON(w, issues)
+	}
+
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "FIRST SEEN\tLAST SEEN\tRUNS\tLEVEL\tTARGET\tCHECK\tRESOURCE\tMESSAGE")
+	for _, h := range issues {
+		checkID := h.CheckID
+		if checkID == "" {
+			checkID = h.Category
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%d\t%s\t%s\t%s\t%s\t%s\n", h.FirstSeen.Format("2006-01-02 15:04:05"),
+			h.LastSeen.Format("2006-01-02 15:04:05"), h.Runs, h.Level, h.Target, checkID, h.Resource, h.Message)
+	}
+	return tw.Flush()
+}
+
+func writeJSON(w io.Writer, v interface{}) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(v)
+}
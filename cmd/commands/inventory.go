@@ -0,0 +1,84 @@
+package commands
+
+import (
+	"fmt"
+	"inspection-tool/internal/config"
+	"inspection-tool/internal/inventory"
+	"inspection-tool/internal/ssh"
+	"inspection-tool/pkg/models"
+)
+
+// loadInventoryHosts 加载主机清单并按 --limit 筛选
+func loadInventoryHosts(file, limit string) ([]*inventory.Host, error) {
+	if file == "" {
+		if limit != "" {
+			return nil, fmt.Errorf("--limit 需要配合 --inventory 使用")
+		}
+		return nil, nil
+	}
+
+	inv, err := inventory.Load(file, appConfig.Server.Thresholds)
+	if err != nil {
+		return nil, fmt.Errorf("加载主机清单失败: %w", err)
+	}
+
+	hosts, err := inv.Select(limit)
+	if err != nil {
+		return nil, fmt.Errorf("主机筛选失败: %w", err)
+	}
+	if len(hosts) == 0 {
+		return nil, fmt.Errorf("主机清单中没有匹配 %q 的主机", limit)
+	}
+	return hosts, nil
+}
+
+// hostSSHConfig 构建清单主机的SSH配置, 清单中的主机设置优先于命令行参数
+func hostSSHConfig(host *inventory.Host, port int, user, password string, auth SSHAuthOptions) *ssh.Config {
+	if host.Port != 0 {
+		port = host.Port
+	}
+	if host.User != "" {
+		user = host.User
+	}
+	if host.Password != "" {
+		password = host.Password
+	}
+
+	sshConfig := newSSHConfig(host.Target(), port, user, password, auth)
+	if len(host.KeyFiles) > 0 {
+		sshConfig.KeyFiles = host.KeyFiles
+	}
+	return sshConfig
+}
+
+// hostThresholds 主机生效的阈值, 分组未覆盖时使用配置文件中的全局阈值
+func hostThresholds(host *inventory.Host) *config.ServerThresholds {
+	if host.Thresholds != nil {
+		return host.Thresholds
+	}
+	return &appConfig.Server.Thresholds
+}
+
+// applyHostMeta 在报告中记录主机的分组和标签
+func applyHostMeta(serverReport *models.ServerReport, host *inventory.Host) {
+	serverReport.Group = host.Group()
+	serverReport.Tags = host.Tags
+	serverReport.NodeName = host.Node
+}
+
+// nodeHosts 将K8s节点转换为待巡检主机, 按地址优先顺序选择SSH连接地址
+func nodeHosts(nodes []models.NodeMetrics, addressTypes []string) []*inventory.Host {
+	hosts := make([]*inventory.Host, 0, len(nodes))
+	for i := range nodes {
+		node := &nodes[i]
+		if node.Name == "" {
+			continue
+		}
+		hosts = append(hosts, &inventory.Host{
+			Name:    node.Name,
+			Address: node.Address(addressTypes),
+			Node:    node.Name,
+		})
+	}
+	return hosts
+}
@@ -5,8 +5,10 @@ import (
 	"inspection-tool/internal/k8s"
 	"inspection-tool/pkg/models"
 	"inspection-tool/pkg/report"
+	"inspection-tool/pkg/utils"
 	"os"
 	"path/filepath"
+	"sort"
 	"strings"
 	"time"
 
@@ -24,6 +26,12 @@ type K8sOptions struct {
 	SSHUser        string
 	SSHPassword    string
 	SSHPort        int
+	SSHAuth        SSHAuthOptions
+	Checks         CheckOptions
+	Exit           ExitOptions
+
+	// Worker节点SSH地址优先顺序
+	NodeAddressTypes []string
 }
 
 // NewK8sCommand 创建Kubernetes巡检命令
@@ -41,8 +49,18 @@ func NewK8sCommand() *cobra.Command {
   inspection-tool k8s --kubeconfig ~/.kube/config --namespaces default,kube-system
 
   # 同时巡检worker节点服务器资源
-  inspection-tool k8s --kubeconfig ~/.kube/config --inspect-workers --ssh-user root --ssh-password pass`,
+  inspection-tool k8s --kubeconfig ~/.kube/config --inspect-workers --ssh-user root --ssh-password pass
+
+  # 使用私钥巡检worker节点
+  inspection-tool k8s --kubeconfig ~/.kube/config --inspect-workers --ssh-user root --ssh-key ~/.ssh/id_rsa`,
 		RunE: func(cmd *cobra.Command, args []string) error {
+			applyK8sDefaults(cmd, &opts.Kubeconfig, &opts.Namespaces)
+			applyReportDefaults(cmd, &opts.Output, &opts.Format, &opts.Detailed)
+			applySSHConfigDefaults(cmd, "ssh-user", "ssh-port", &opts.SSHUser, &opts.SSHPort, opts.SSHAuth)
+			applyPasswordEnv(&opts.SSHPassword)
+			if err := applyNodeAddressDefaults(cmd, &opts.NodeAddressTypes); err != nil {
+				return err
+			}
 			return runK8sInspection(opts)
 		},
 	}
@@ -54,17 +72,32 @@ func NewK8sCommand() *cobra.Command {
 	cmd.Flags().StringVar(&opts.Kubeconfig, "kubeconfig", defaultKubeconfig, "kubeconfig文件路径")
 	cmd.Flags().StringVar(&opts.Namespaces, "namespaces", "", "要检查的命名空间(逗号分隔,为空则检查所有)")
 	cmd.Flags().StringVar(&opts.Output, "output", "./reports", "报告输出目录")
-	cmd.Flags().StringVar(&opts.Format, "format", "json", "报告格式(json/yaml)")
+	cmd.Flags().StringVar(&opts.Format, "format", "json", "报告格式(json/yaml/html/markdown/text/csv/xlsx/junit/sarif)")
 	cmd.Flags().BoolVar(&opts.Detailed, "detailed", true, "生成详细报告")
+	addReportManifestFlag(cmd)
 	cmd.Flags().BoolVar(&opts.InspectWorkers, "inspect-workers", false, "同时巡检worker节点服务器资源")
 	cmd.Flags().StringVar(&opts.SSHUser, "ssh-user", "root", "Worker节点SSH用户名")
-	cmd.Flags().StringVar(&opts.SSHPassword, "ssh-password", "", "Worker节点SSH密码")
+	cmd.Flags().StringVar(&opts.SSHPassword, "ssh-password", "", "Worker节点SSH密码(也可通过环境变量"+passwordEnv+"提供)")
 	cmd.Flags().IntVar(&opts.SSHPort, "ssh-port", 22, "Worker节点SSH端口")
+	opts.SSHAuth.addFlags(cmd.Flags(), "ssh-")
+	opts.Checks.addFlags(cmd.Flags())
+	opts.Exit.addFlags(cmd.Flags())
+	cmd.Flags().StringSliceVar(&opts.NodeAddressTypes, "node-address-types", nil, "Worker节点SSH地址优先顺序(InternalIP,ExternalIP,Hostname, 默认取配置文件)")
 
 	return cmd
 }
 
 func runK8sInspection(opts *K8sOptions) error {
+	result, err := opts.Exit.outcome()
+	if err != nil {
+		return err
+	}
+	plan, err := opts.Checks.selection()
+	if err != nil {
+		return err
+	}
+	attachHistory(plan, opts.Output)
+
 	fmt.Println("========================================")
 	fmt.Println("开始Kubernetes巡检")
 	fmt.Println("========================================")
@@ -91,6 +124,10 @@ func runK8sInspection(opts *K8sOptions) error {
 		Kubeconfig: opts.Kubeconfig,
 		Namespaces: namespaces,
 		Timeout:    60 * time.Second,
+		Thresholds: appConfig.K8s.Thresholds,
+		Checks:     plan.set,
+		Waivers:    plan.waivers,
+		History:    plan.history,
 	})
 	if err != nil {
 		return fmt.Errorf("创建K8s巡检器失败: %w", err)
@@ -101,15 +138,18 @@ func runK8sInspection(opts *K8sOptions) error {
 	fmt.Println("正在执行集群巡检...")
 	k8sReport, err := inspector.Inspect()
 	if err != nil {
-		return fmt.Errorf("K8s巡检失败: %w", err)
+		return collectionFailed(fmt.Errorf("K8s巡检失败: %w", err))
 	}
 	fmt.Println("集群巡检完成")
 
 	// 如果需要巡检worker节点
-	if opts.InspectWorkers && opts.SSHPassword != "" {
+	var workerReports []*models.ServerReport
+	if opts.InspectWorkers && hasCredentials(opts.SSHPassword, opts.SSHAuth) {
 		fmt.Println("正在巡检Worker节点服务器资源...")
-		if err := inspectWorkerNodes(k8sReport, opts); err != nil {
+		workerReports, err = inspectWorkerNodes(k8sReport, opts, plan)
+		if err != nil {
 			fmt.Printf("警告: Worker节点巡检失败: %v\n", err)
+			result.addFailure("workers")
 		} else {
 			fmt.Println("Worker节点巡检完成")
 		}
@@ -118,53 +158,56 @@ func runK8sInspection(opts *K8sOptions) error {
 	// 生成报告
 	fmt.Println("正在生成报告...")
 	generator := report.NewGenerator(opts.Format, opts.Output, opts.Detailed)
+	generator.SetChecks(reportChecks(plan.set))
 	reportPath, err := generator.GenerateK8sReport(k8sReport)
 	if err != nil {
 		return fmt.Errorf("生成报告失败: %w", err)
 	}
+	recordHistory(opts.Output, &models.InspectionReport{Timestamp: k8sReport.Timestamp, Type: "k8s", K8sReport: k8sReport}, reportPath)
 
 	// 打印摘要
 	report.PrintK8sSummary(k8sReport)
 
 	fmt.Printf("\n报告已保存: %s\n", reportPath)
 
-	// 根据问题数量返回退出码
-	if len(k8sReport.Issues) > 0 {
-		criticalCount := 0
-		for _, issue := range k8sReport.Issues {
-			if issue.Level == "critical" {
-				criticalCount++
-			}
-		}
-		if criticalCount > 0 {
-			return fmt.Errorf("发现 %d 个严重问题", criticalCount)
+	result.addK8s(k8sReport)
+
+	// 保存Worker节点报告
+	for _, sr := range workerReports {
+		utils.UpdateServerStatus(sr)
+		if path, err := generator.GenerateServerReport(sr); err != nil {
+			fmt.Printf("警告: 保存节点 %s 报告失败: %v\n", sr.NodeName, err)
+		} else {
+			recordHistory(opts.Output, serverInspection(sr), path)
+			fmt.Printf("节点 %s (%s): %s, 报告已保存: %s\n", sr.NodeName, sr.Host, sr.Status, path)
 		}
+		result.addServer(sr)
 	}
 
-	return nil
+	return result.err()
 }
 
-func inspectWorkerNodes(k8sReport *models.K8sReport, opts *K8sOptions) error {
-	// 获取所有worker节点的IP
-	workerIPs := []string{}
-	for _, node := range k8sReport.Nodes {
-		// 从节点标签或注解中提取IP
-		// 这里简化处理,实际应该从node.Status.Addresses中获取
-		if node.Name != "" {
-			// 尝试从节点名称或标签获取IP
-			// 实际实现中应该更完善
-			workerIPs = append(workerIPs, node.Name)
-		}
+// inspectWorkerNodes 通过SSH并发巡检集群节点, 连接地址按 --node-address-types 的优先顺序选择
+func inspectWorkerNodes(k8sReport *models.K8sReport, opts *K8sOptions, plan *checkPlan) ([]*models.ServerReport, error) {
+	hosts := nodeHosts(k8sReport.Nodes, opts.NodeAddressTypes)
+	if len(hosts) == 0 {
+		return nil, fmt.Errorf("未找到worker节点")
 	}
 
-	if len(workerIPs) == 0 {
-		return fmt.Errorf("未找到worker节点")
-	}
+	fmt.Printf("发现 %d 个worker节点\n", len(hosts))
 
-	fmt.Printf("发现 %d 个worker节点\n", len(workerIPs))
+	reports, err := inspectServersParallel(hosts, sshLogin{
+		User:     opts.SSHUser,
+		Password: opts.SSHPassword,
+		Port:     opts.SSHPort,
+		Auth:     opts.SSHAuth,
+	}, plan)
+	if err != nil {
+		return nil, err
+	}
 
-	// 这里可以并发巡检多个worker节点
-	// 为简化代码,这里只做示例
-	
-	return nil
+	sort.Slice(reports, func(i, j int) bool {
+		return reports[i].NodeName < reports[j].NodeName
+	})
+	return reports, nil
 }
@@ -0,0 +1,76 @@
+package commands
+
+import (
+	"fmt"
+	"inspection-tool/internal/history"
+	"inspection-tool/internal/metrics"
+	"inspection-tool/pkg/report"
+	"net"
+	"net/http"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// metricsExporter 提供 /metrics 接口时保存最近一次的巡检结果, 每次保存报告时更新, 未启用时为nil
+var metricsExporter *metrics.Exporter
+
+// startMetricsServer 在addr上提供 /metrics 接口, 监听失败时返回错误
+func startMetricsServer(addr string, exporter *metrics.Exporter) (*http.Server, error) {
+	ln, err := net.Listen("tcp", addr)
+	if err != nil {
+		return nil, fmt.Errorf("监听 %s 失败: %w", addr, err)
+	}
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", exporter)
+
+	srv := &http.Server{Handler: mux, ReadHeaderTimeout: 10 * time.Second}
+	go func() {
+		if err := srv.Serve(ln); err != nil && err != http.ErrServerClosed {
+			fmt.Printf("警告: 指标接口退出: %v\n", err)
+		}
+	}()
+	return srv, nil
+}
+
+// loadLatestReports 按报告输出目录中的巡检历史, 加载每个巡检对象最近一次的报告
+// 只能加载json和yaml格式的报告, 其他格式的报告跳过, 直到下次巡检后才有数据
+func loadLatestReports(exporter *metrics.Exporter, outputDir string) error {
+	store, err := history.Open(outputDir)
+	if err != nil {
+		return err
+	}
+	runs, err := store.Runs()
+	if err != nil {
+		return err
+	}
+
+	seen := make(map[string]bool)
+	for i := len(runs) - 1; i >= 0; i-- {
+		run := runs[i]
+		pending := false
+		for _, target := range run.Targets {
+			pending = pending || !seen[target]
+		}
+		if !pending || !loadable(run.Report) {
+			continue
+		}
+		inspection, err := report.Load(run.Report)
+		if err != nil {
+			continue
+		}
+		exporter.Update(inspection)
+		for _, target := range run.Targets {
+			seen[target] = true
+		}
+	}
+	return nil
+}
+
+func loadable(path string) bool {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".json", ".yaml", ".yml":
+		return true
+	}
+	return false
+}
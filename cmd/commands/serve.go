@@ -0,0 +1,356 @@
+package commands
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"inspection-tool/internal/api"
+	"inspection-tool/internal/config"
+	"inspection-tool/internal/metrics"
+	"inspection-tool/pkg/report"
+	"io"
+	"net"
+	"net/http"
+	"os"
+	"os/exec"
+	"os/signal"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+// ServeOptions HTTP API选项
+type ServeOptions struct {
+	Addr        string
+	Token       string
+	Concurrency int
+	QueueSize   int
+	Output      string // 报告输出目录, 巡检报告保存在这里, 历史报告也从这里读取
+}
+
+// apply 命令行未显式指定时使用配置文件中的选项
+func (o *ServeOptions) apply(cmd *cobra.Command) error {
+	flags := cmd.Flags()
+	if !flags.Changed("addr") {
+		o.Addr = appConfig.API.Addr
+	}
+	if !flags.Changed("token") {
+		o.Token = appConfig.API.Token
+	}
+	if !flags.Changed("concurrency") {
+		o.Concurrency = appConfig.API.Concurrency
+	}
+	if !flags.Changed("queue-size") {
+		o.QueueSize = appConfig.API.QueueSize
+	}
+	if !flags.Changed("output") && appConfig.Report.OutputDir != "" {
+		o.Output = appConfig.Report.OutputDir
+	}
+	if o.Token == "" {
+		return fmt.Errorf("必须设置访问令牌: 配置项 api.token、环境变量 %s_API_TOKEN 或 --token", config.EnvPrefix)
+	}
+	return nil
+}
+
+// NewServeCommand 创建HTTP API命令
+func NewServeCommand() *cobra.Command {
+	opts := &ServeOptions{}
+
+	cmd := &cobra.Command{
+		Use:   "serve",
+		Short: "提供触发巡检和获取报告的HTTP API",
+		Long: `提供HTTP API, 供其他工具按需发起巡检、查询进度和获取报告:
+
+  POST /api/v1/inspections              发起巡检, 参数与命令行相同
+  GET  /api/v1/inspections/{id}         巡检状态和进度
+  GET  /api/v1/inspections/{id}/report  巡检报告, ?format= 指定格式
+  GET  /api/v1/reports                  历史报告列表
+  GET  /metrics                         Prometheus指标
+
+所有接口(/healthz除外)需要请求头 Authorization: Bearer <token>。
+每次巡检在独立的子进程中执行, 最多同时执行 --concurrency 个, 其余排队, 队列已满时返回429。
+收到 SIGINT 或 SIGTERM 时不再接受新的巡检并取消排队中的巡检, 等待执行中的巡检结束后退出, 再次收到时立即退出。`,
+		Example: `  # 令牌建议通过环境变量设置, 避免出现在进程列表中
+  INSPECTION_API_TOKEN=secret inspection-tool serve --addr :8080
+
+  # 发起巡检
+  curl -H "Authorization: Bearer secret" -d '{"command":"server","options":{"inventory":"hosts.yaml"}}' \
+    http://localhost:8080/api/v1/inspections`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if err := opts.apply(cmd); err != nil {
+				return err
+			}
+			return runServe(opts)
+		},
+	}
+
+	cmd.Flags().StringVar(&opts.Addr, "addr", ":8080", "监听地址(默认取配置项 api.addr)")
+	cmd.Flags().StringVar(&opts.Token, "token", "", "访问令牌(默认取配置项 api.token)")
+	cmd.Flags().IntVar(&opts.Concurrency, "concurrency", 2, "同时执行的巡检数量(默认取配置项 api.concurrency)")
+	cmd.Flags().IntVar(&opts.QueueSize, "queue-size", 100, "等待执行的巡检数量上限(默认取配置项 api.queue_size)")
+	cmd.Flags().StringVar(&opts.Output, "output", "./reports", "报告输出目录")
+
+	return cmd
+}
+
+// runServe 运行HTTP API直到收到退出信号
+func runServe(opts *ServeOptions) error {
+	exporter := metrics.NewExporter()
+	if err := loadLatestReports(exporter, opts.Output); err != nil {
+		fmt.Printf("警告: 加载 %s 中的巡检结果失败: %v\n", opts.Output, err)
+	}
+
+	apiServer, err := api.New(api.Options{
+		Token:       opts.Token,
+		OutputDir:   opts.Output,
+		Concurrency: opts.Concurrency,
+		QueueSize:   opts.QueueSize,
+		Run:         inspectionRunner(opts.Output),
+		Validate:    validateInspectionArgs,
+		Exporter:    exporter,
+	})
+	if err != nil {
+		return err
+	}
+
+	ln, err := net.Listen("tcp", opts.Addr)
+	if err != nil {
+		return fmt.Errorf("监听 %s 失败: %w", opts.Addr, err)
+	}
+	srv := &http.Server{Handler: apiServer.Handler(), ReadHeaderTimeout: 10 * time.Second}
+	serveErr := make(chan error, 1)
+	go func() {
+		serveErr <- srv.Serve(ln)
+	}()
+	logf("HTTP API: http://%s", ln.Addr())
+
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, syscall.SIGINT, syscall.SIGTERM)
+	defer signal.Stop(signals)
+
+	select {
+	case err := <-serveErr:
+		return fmt.Errorf("HTTP服务退出: %w", err)
+	case sig := <-signals:
+		logf("收到 %s, 等待执行中的巡检结束后退出", sig)
+	}
+
+	// 再次收到信号时中止执行中的巡检
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go func() {
+		select {
+		case <-signals:
+			cancel()
+		case <-ctx.Done():
+		}
+	}()
+
+	shutdownCtx, shutdownCancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer shutdownCancel()
+	srv.Shutdown(shutdownCtx)
+	if err := apiServer.Shutdown(ctx); err != nil {
+		return fmt.Errorf("未等待巡检结束, 立即退出")
+	}
+	logf("HTTP API已退出")
+	return nil
+}
+
+// validateInspectionArgs 在加入队列前按对应命令解析参数; 报告输出目录和格式由服务决定, 不能指定
+func validateInspectionArgs(command string, args []string) error {
+	cmd, err := newInspectionCommand(command)
+	if err != nil {
+		return err
+	}
+	if err := cmd.ParseFlags(args); err != nil {
+		return fmt.Errorf("参数无效: %w", err)
+	}
+	if rest := cmd.Flags().Args(); len(rest) > 0 {
+		return fmt.Errorf("多余的参数: %s", strings.Join(rest, " "))
+	}
+	for _, name := range []string{"output", "format", reportManifestFlag} {
+		if cmd.Flags().Changed(name) {
+			return fmt.Errorf("--%s 由服务指定, 不能在请求中设置", name)
+		}
+	}
+	return nil
+}
+
+// reportManifestFlag 隐藏参数, serve 执行巡检时由子进程把保存的报告路径逐行写入该文件
+const reportManifestFlag = "report-manifest"
+
+// reportManifest 当前命令的 --report-manifest, 为空时不记录
+var reportManifest string
+
+// addReportManifestFlag 为 server、k8s 和 all 命令添加隐藏的 --report-manifest 参数
+func addReportManifestFlag(cmd *cobra.Command) {
+	cmd.Flags().StringVar(&reportManifest, reportManifestFlag, "", "保存的报告路径逐行写入该文件(供serve使用)")
+	cmd.Flags().MarkHidden(reportManifestFlag)
+}
+
+// appendReportManifest 把保存的报告路径追加到 --report-manifest 指定的文件
+func appendReportManifest(reportPath string) error {
+	if reportManifest == "" {
+		return nil
+	}
+	f, err := os.OpenFile(reportManifest, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0o600)
+	if err != nil {
+		return err
+	}
+	if _, err := fmt.Fprintln(f, reportPath); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// readReportManifest 从offset开始读取子进程写入的完整行, 返回报告路径和已读取到的位置
+func readReportManifest(path string, offset int64) ([]string, int64, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, offset, err
+	}
+	defer f.Close()
+	if _, err := f.Seek(offset, io.SeekStart); err != nil {
+		return nil, offset, err
+	}
+	data, err := io.ReadAll(f)
+	if err != nil {
+		return nil, offset, err
+	}
+	var paths []string
+	rest := string(data)
+	for {
+		line, next, ok := strings.Cut(rest, "\n")
+		if !ok {
+			break
+		}
+		offset += int64(len(line)) + 1
+		rest = next
+		if line = strings.TrimSpace(line); line != "" {
+			paths = append(paths, line)
+		}
+	}
+	return paths, offset, nil
+}
+
+// secretEnvs 通过环境变量传给子进程的密码类参数, 避免出现在子进程的命令行中
+var secretEnvs = map[string]string{
+	"password":           passwordEnv,
+	"ssh-password":       passwordEnv,
+	"key-passphrase":     keyPassphraseEnv,
+	"ssh-key-passphrase": keyPassphraseEnv,
+}
+
+// splitSecretArgs 从参数中取出密码类参数, 返回其余参数和对应的环境变量
+func splitSecretArgs(args []string) ([]string, []string) {
+	var rest, env []string
+	for i := 0; i < len(args); i++ {
+		name, value, hasValue := strings.Cut(strings.TrimPrefix(args[i], "--"), "=")
+		key, ok := secretEnvs[name]
+		if !ok || !strings.HasPrefix(args[i], "--") {
+			rest = append(rest, args[i])
+			continue
+		}
+		if !hasValue && i+1 < len(args) {
+			i++
+			value = args[i]
+		}
+		env = append(env, key+"="+value)
+	}
+	return rest, env
+}
+
+// inspectionRunner 在子进程中执行巡检, 报告以json格式保存到outputDir, 结束后清理旧报告
+// 子进程保存的报告路径通过 --report-manifest 指定的临时文件传回, 不依赖输出内容; 密码通过环境变量传递
+func inspectionRunner(outputDir string) api.RunFunc {
+	return func(ctx context.Context, command string, args []string, log func(string), saved func(string)) (int, error) {
+		exe, err := os.Executable()
+		if err != nil {
+			return 0, err
+		}
+		manifest, err := os.CreateTemp("", "inspection-reports-*")
+		if err != nil {
+			return 0, fmt.Errorf("创建报告清单文件失败: %w", err)
+		}
+		manifest.Close()
+		defer os.Remove(manifest.Name())
+
+		argv := []string{command}
+		if appConfig.File != "" {
+			argv = append(argv, "--config", appConfig.File)
+		}
+		args, secrets := splitSecretArgs(args)
+		argv = append(argv, args...)
+		argv = append(argv, "--output", outputDir, "--format", "json", "--"+reportManifestFlag, manifest.Name())
+
+		pr, pw := io.Pipe()
+		proc := exec.CommandContext(ctx, exe, argv...)
+		proc.Env = append(os.Environ(), secrets...)
+		proc.Stdout = pw
+		proc.Stderr = pw
+
+		scanned := make(chan struct{})
+		go func() {
+			defer close(scanned)
+			scanner := bufio.NewScanner(pr)
+			for scanner.Scan() {
+				log(scanner.Text())
+			}
+			io.Copy(io.Discard, pr)
+		}()
+
+		// 执行期间定期读取报告清单, 巡检进度中的已保存报告数量随之更新
+		var offset int64
+		readSaved := func() {
+			paths, next, err := readReportManifest(manifest.Name(), offset)
+			if err != nil {
+				log(fmt.Sprintf("警告: 读取报告清单失败: %v", err))
+				return
+			}
+			offset = next
+			for _, path := range paths {
+				saved(path)
+			}
+		}
+		done := make(chan struct{})
+		polled := make(chan struct{})
+		go func() {
+			defer close(polled)
+			ticker := time.NewTicker(time.Second)
+			defer ticker.Stop()
+			for {
+				select {
+				case <-ticker.C:
+					readSaved()
+				case <-done:
+					return
+				}
+			}
+		}()
+
+		err = proc.Run()
+		pw.Close()
+		<-scanned
+		close(done)
+		<-polled
+		readSaved()
+
+		if err := report.CleanupOldReports(outputDir, appConfig.Report.RetentionDays); err != nil && !os.IsNotExist(err) {
+			log(fmt.Sprintf("警告: 清理旧报告失败: %v", err))
+		}
+
+		var exitErr *exec.ExitError
+		if errors.As(err, &exitErr) && ctx.Err() == nil {
+			return exitErr.ExitCode(), nil
+		}
+		if err != nil {
+			return 0, err
+		}
+		return ExitOK, nil
+	}
+}
@@ -0,0 +1,84 @@
+package commands
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestValidateInspectionArgs(t *testing.T) {
+	tests := []struct {
+		command string
+		args    []string
+		wantErr bool
+	}{
+		{"server", []string{"--inventory", "hosts.yaml", "--detailed"}, false},
+		{"k8s", []string{"--kubeconfig=/root/.kube/config"}, false},
+		{"all", nil, false},
+		{"deploy", nil, true},
+		{"server", []string{"--no-such-flag"}, true},
+		{"server", []string{"extra"}, true},
+		{"server", []string{"--output", "/tmp"}, true},
+		{"k8s", []string{"--format=html"}, true},
+		{"all", []string{"--report-manifest", "/tmp/reports.txt"}, true},
+	}
+	for _, tt := range tests {
+		err := validateInspectionArgs(tt.command, tt.args)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("validateInspectionArgs(%s, %v) error = %v, wantErr %v", tt.command, tt.args, err, tt.wantErr)
+		}
+	}
+}
+
+func TestReportManifest(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "reports.txt")
+	defer func(old string) { reportManifest = old }(reportManifest)
+
+	reportManifest = ""
+	if err := appendReportManifest("/reports/ignored.json"); err != nil {
+		t.Fatalf("appendReportManifest without manifest: %v", err)
+	}
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Fatalf("Expected no manifest file, got %v", err)
+	}
+
+	reportManifest = path
+	for _, p := range []string{"/reports/a.json", "/reports/b.json"} {
+		if err := appendReportManifest(p); err != nil {
+			t.Fatalf("appendReportManifest(%s): %v", p, err)
+		}
+	}
+	paths, offset, err := readReportManifest(path, 0)
+	if err != nil || !reflect.DeepEqual(paths, []string{"/reports/a.json", "/reports/b.json"}) {
+		t.Fatalf("readReportManifest = %v (%v)", paths, err)
+	}
+
+	// 未写完的行留到下次读取
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_APPEND, 0o600)
+	if err != nil {
+		t.Fatal(err)
+	}
+	f.WriteString("/reports/c")
+	paths, offset, err = readReportManifest(path, offset)
+	if err != nil || len(paths) != 0 {
+		t.Fatalf("Expected partial line to be skipped, got %v (%v)", paths, err)
+	}
+	f.WriteString(".json\n")
+	f.Close()
+	paths, _, err = readReportManifest(path, offset)
+	if err != nil || !reflect.DeepEqual(paths, []string{"/reports/c.json"}) {
+		t.Fatalf("Expected only the new report, got %v (%v)", paths, err)
+	}
+}
+
+func TestSplitSecretArgs(t *testing.T) {
+	args, env := splitSecretArgs([]string{"--inventory", "hosts.yaml", "--password=p1", "--ssh-key-passphrase", "k1", "--detailed"})
+	if !reflect.DeepEqual(args, []string{"--inventory", "hosts.yaml", "--detailed"}) {
+		t.Errorf("Expected secrets removed from args, got %v", args)
+	}
+	want := []string{passwordEnv + "=p1", keyPassphraseEnv + "=k1"}
+	if !reflect.DeepEqual(env, want) {
+		t.Errorf("Expected %v, got %v", want, env)
+	}
+}
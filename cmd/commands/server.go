@@ -2,11 +2,13 @@ package commands
 
 import (
 	"fmt"
+	"inspection-tool/internal/inventory"
 	"inspection-tool/internal/server"
 	"inspection-tool/internal/ssh"
+	"inspection-tool/pkg/models"
 	"inspection-tool/pkg/report"
 	"inspection-tool/pkg/utils"
-	"time"
+	"strings"
 
 	"github.com/spf13/cobra"
 )
@@ -20,6 +22,13 @@ type ServerOptions struct {
 	Output   string
 	Format   string
 	Detailed bool
+	Auth     SSHAuthOptions
+	Checks   CheckOptions
+	Exit     ExitOptions
+
+	// 主机清单
+	Inventory string
+	Limit     string
 }
 
 // NewServerCommand 创建服务器巡检命令
@@ -33,103 +42,207 @@ func NewServerCommand() *cobra.Command {
 		Example: `  # 基本用法
   inspection-tool server --host 192.168.1.100 --user root --password yourpass
 
+  # 使用私钥认证(也会自动尝试ssh-agent和~/.ssh下的默认私钥)
+  inspection-tool server --host 192.168.1.100 --user root --key ~/.ssh/id_ed25519
+
+  # 使用~/.ssh/config中的主机别名(HostName、User、Port、IdentityFile、ProxyJump)
+  inspection-tool server --host db01
+
+  # 巡检主机清单中db分组的主机
+  inspection-tool server --inventory inventory.yaml --limit db
+
   # 指定端口和输出格式
   inspection-tool server --host 192.168.1.100 --user root --password yourpass --port 2222 --format yaml
 
   # 详细输出
   inspection-tool server --host 192.168.1.100 --user root --password yourpass --detailed`,
 		RunE: func(cmd *cobra.Command, args []string) error {
+			applyReportDefaults(cmd, &opts.Output, &opts.Format, &opts.Detailed)
+			applySSHConfigDefaults(cmd, "user", "port", &opts.User, &opts.Port, opts.Auth)
+			applyPasswordEnv(&opts.Password)
 			return runServerInspection(opts)
 		},
 	}
 
-	cmd.Flags().StringVar(&opts.Host, "host", "", "服务器地址(必需)")
-	cmd.Flags().StringVar(&opts.User, "user", "root", "SSH用户名")
-	cmd.Flags().StringVar(&opts.Password, "password", "", "SSH密码(必需)")
-	cmd.Flags().IntVar(&opts.Port, "port", 22, "SSH端口")
+	cmd.Flags().StringVar(&opts.Host, "host", "", "服务器地址(未指定--inventory时必需)")
+	cmd.Flags().StringVar(&opts.User, "user", "root", "SSH用户名(未指定时取~/.ssh/config)")
+	cmd.Flags().StringVar(&opts.Password, "password", "", "SSH密码(也可通过环境变量"+passwordEnv+"提供)")
+	cmd.Flags().IntVar(&opts.Port, "port", 22, "SSH端口(未指定时取~/.ssh/config)")
 	cmd.Flags().StringVar(&opts.Output, "output", "./reports", "报告输出目录")
-	cmd.Flags().StringVar(&opts.Format, "format", "json", "报告格式(json/yaml)")
+	cmd.Flags().StringVar(&opts.Format, "format", "json", "报告格式(json/yaml/html/markdown/text/csv/xlsx/junit/sarif)")
 	cmd.Flags().BoolVar(&opts.Detailed, "detailed", true, "生成详细报告")
-
-	cmd.MarkFlagRequired("host")
-	cmd.MarkFlagRequired("password")
+	addReportManifestFlag(cmd)
+	opts.Auth.addFlags(cmd.Flags(), "")
+	opts.Checks.addFlags(cmd.Flags())
+	opts.Exit.addFlags(cmd.Flags())
+	cmd.Flags().StringVar(&opts.Inventory, "inventory", "", "主机清单文件(YAML或Ansible INI)")
+	cmd.Flags().StringVar(&opts.Limit, "limit", "", "按分组、标签或主机名筛选清单中的主机(逗号分隔, !表示排除)")
 
 	return cmd
 }
 
 func runServerInspection(opts *ServerOptions) error {
+	result, err := opts.Exit.outcome()
+	if err != nil {
+		return err
+	}
+	plan, err := opts.Checks.selection()
+	if err != nil {
+		return err
+	}
+	attachHistory(plan, opts.Output)
+
+	hosts, err := serverTargets(opts)
+	if err != nil {
+		return err
+	}
+
+	// 使用相同跳板机的服务器共享跳板连接
+	jumps := ssh.NewJumpPool()
+	defer jumps.Close()
+
+	var broken []string
+	for _, host := range hosts {
+		serverReport, err := inspectServer(host, opts, plan, jumps)
+		if err != nil && serverReport == nil {
+			// 配置错误或报告无法生成, 属于工具错误
+			if len(hosts) == 1 {
+				return err
+			}
+			fmt.Printf("✗ %s: %v\n\n", host.Name, err)
+			broken = append(broken, host.Name)
+			continue
+		}
+		if err != nil {
+			fmt.Printf("✗ %s: %v\n\n", host.Name, err)
+		}
+		result.addServer(serverReport)
+	}
+
+	if len(broken) > 0 {
+		return fmt.Errorf("%d 台服务器巡检出错: %s", len(broken), strings.Join(broken, ", "))
+	}
+	return result.err()
+}
+
+// serverTargets 确定要巡检的主机: 指定--inventory时从清单中筛选, --host可进一步限定为清单中的某台主机
+func serverTargets(opts *ServerOptions) ([]*inventory.Host, error) {
+	hosts, err := loadInventoryHosts(opts.Inventory, opts.Limit)
+	if err != nil {
+		return nil, err
+	}
+
+	if opts.Host == "" {
+		if len(hosts) == 0 {
+			return nil, fmt.Errorf("必须指定 --host 或 --inventory")
+		}
+		return hosts, nil
+	}
+
+	for _, host := range hosts {
+		if host.Name == opts.Host || host.Target() == opts.Host {
+			return []*inventory.Host{host}, nil
+		}
+	}
+	return []*inventory.Host{{Name: opts.Host}}, nil
+}
+
+// inspectServer 巡检单台服务器并保存报告, 连接或巡检失败时同时返回记录了失败状态的报告和错误
+func inspectServer(host *inventory.Host, opts *ServerOptions, plan *checkPlan, jumps *ssh.JumpPool) (*models.ServerReport, error) {
+	sshConfig := hostSSHConfig(host, opts.Port, opts.User, opts.Password, opts.Auth)
+	hops, err := jumpHosts(sshConfig, opts.Auth)
+	if err != nil {
+		return nil, fmt.Errorf("跳板机配置错误: %w", err)
+	}
+	sshConfig.JumpHosts = hops
+	sshConfig.JumpPool = jumps
+
+	// 解析~/.ssh/config后的实际连接目标
+	target, err := sshConfig.Resolve()
+	if err != nil {
+		return nil, fmt.Errorf("解析SSH配置失败: %w", err)
+	}
+
 	fmt.Println("========================================")
 	fmt.Println("开始服务器巡检")
 	fmt.Println("========================================")
-	fmt.Printf("目标主机: %s:%d\n", opts.Host, opts.Port)
-	fmt.Printf("用户: %s\n", opts.User)
+	fmt.Printf("目标主机: %s:%d\n", target.Host, target.Port)
+	fmt.Printf("用户: %s\n", target.User)
+	if host.Group() != "" {
+		fmt.Printf("分组: %s\n", host.Group())
+	}
 	fmt.Println("========================================")
 
 	// 验证配置
-	if err := utils.ValidateConfig(opts.Host, opts.User, opts.Password, opts.Port); err != nil {
-		return fmt.Errorf("配置验证失败: %w", err)
+	if err := utils.ValidateConfig(target.Host, target.User, sshConfig.Password, target.Port); err != nil {
+		return nil, fmt.Errorf("配置验证失败: %w", err)
 	}
 
 	// 创建SSH客户端
 	fmt.Println("正在连接服务器...")
-	sshClient, err := ssh.NewClient(&ssh.Config{
-		Host:     opts.Host,
-		Port:     opts.Port,
-		User:     opts.User,
-		Password: opts.Password,
-		Timeout:  30 * time.Second,
-	})
+	sshClient, err := ssh.NewClient(sshConfig)
 	if err != nil {
-		return fmt.Errorf("SSH连接失败: %w", err)
+		return saveFailedReport(connectFailedReport(host.Target(), err, plan.waivers), host, opts, plan), fmt.Errorf("SSH连接失败: %w", err)
 	}
 	defer sshClient.Close()
 
 	// 测试连接
 	if err := sshClient.TestConnection(); err != nil {
-		return fmt.Errorf("连接测试失败: %w", err)
+		return saveFailedReport(connectFailedReport(host.Target(), err, plan.waivers), host, opts, plan), fmt.Errorf("连接测试失败: %w", err)
 	}
 	fmt.Println("连接成功")
 
 	// 创建巡检器
-	inspector, err := server.NewInspector(sshClient)
+	inspector, err := server.NewInspector(sshClient, hostThresholds(host))
 	if err != nil {
-		return fmt.Errorf("创建巡检器失败: %w", err)
+		return saveFailedReport(inspectFailedReport(host.Target(), err, plan.waivers), host, opts, plan), fmt.Errorf("创建巡检器失败: %w", err)
 	}
 	defer inspector.Close()
+	inspector.SetChecks(plan.set)
+	inspector.SetWaivers(plan.waivers)
+	inspector.SetHistory(plan.history)
 
 	// 执行巡检
 	fmt.Println("正在执行巡检...")
 	serverReport, err := inspector.Inspect()
 	if err != nil {
-		return fmt.Errorf("巡检失败: %w", err)
+		return saveFailedReport(inspectFailedReport(host.Target(), err, plan.waivers), host, opts, plan), fmt.Errorf("巡检失败: %w", err)
 	}
+	applyHostMeta(serverReport, host)
+	utils.UpdateServerStatus(serverReport)
 	fmt.Println("巡检完成")
 
 	// 生成报告
 	fmt.Println("正在生成报告...")
 	generator := report.NewGenerator(opts.Format, opts.Output, opts.Detailed)
+	generator.SetChecks(reportChecks(plan.set))
 	reportPath, err := generator.GenerateServerReport(serverReport)
 	if err != nil {
-		return fmt.Errorf("生成报告失败: %w", err)
+		return nil, fmt.Errorf("生成报告失败: %w", err)
 	}
 
+	recordHistory(opts.Output, serverInspection(serverReport), reportPath)
+
 	// 打印摘要
 	report.PrintServerSummary(serverReport)
 
 	fmt.Printf("\n 报告已保存: %s\n", reportPath)
 
-	// 根据问题数量返回退出码
-	if len(serverReport.Issues) > 0 {
-		criticalCount := 0
-		for _, issue := range serverReport.Issues {
-			if issue.Level == "critical" {
-				criticalCount++
-			}
-		}
-		if criticalCount > 0 {
-			return fmt.Errorf("发现 %d 个严重问题", criticalCount)
-		}
-	}
+	return serverReport, nil
+}
 
-	return nil
+// saveFailedReport 保存连接或巡检失败的报告, 失败的主机同样留有记录
+func saveFailedReport(serverReport *models.ServerReport, host *inventory.Host, opts *ServerOptions, plan *checkPlan) *models.ServerReport {
+	applyHostMeta(serverReport, host)
+	generator := report.NewGenerator(opts.Format, opts.Output, opts.Detailed)
+	generator.SetChecks(reportChecks(plan.set))
+	reportPath, err := generator.GenerateServerReport(serverReport)
+	if err != nil {
+		fmt.Printf("警告: 保存失败报告出错: %v\n", err)
+		return serverReport
+	}
+	recordHistory(opts.Output, serverInspection(serverReport), reportPath)
+	report.PrintServerSummary(serverReport)
+	fmt.Printf("\n 报告已保存: %s\n", reportPath)
+	return serverReport
 }
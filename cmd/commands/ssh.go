@@ -0,0 +1,226 @@
+package commands
+
+import (
+	"errors"
+	"fmt"
+	"inspection-tool/internal/ssh"
+	"inspection-tool/internal/waivers"
+	"inspection-tool/pkg/models"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+)
+
+// keyPassphraseEnv 私钥密码环境变量, 避免密码出现在命令行历史中
+const keyPassphraseEnv = "INSPECTION_SSH_KEY_PASSPHRASE"
+
+// passwordEnv SSH密码环境变量, 未指定 --password/--ssh-password 时使用
+const passwordEnv = "INSPECTION_SSH_PASSWORD"
+
+// SSHAuthOptions SSH认证选项(密码以外的认证方式)
+type SSHAuthOptions struct {
+	KeyFiles            []string
+	KeyPassphrase       string
+	UseAgent            bool
+	KeyboardInteractive bool
+	HostKeyPolicy       string
+	KnownHostsFile      string
+	Jump                string
+	UseSSHConfig        bool
+}
+
+// sshLogin 命令行指定的SSH登录参数, 主机清单中的设置优先
+type sshLogin struct {
+	User     string
+	Password string
+	Port     int
+	Auth     SSHAuthOptions
+}
+
+// addFlags 注册SSH认证参数, prefix用于区分 server(--key) 与 k8s/all(--ssh-key) 的命名
+func (o *SSHAuthOptions) addFlags(flags *pflag.FlagSet, prefix string) {
+	flags.StringSliceVar(&o.KeyFiles, prefix+"key", nil, "SSH私钥文件(可重复指定, 默认尝试~/.ssh/id_ed25519、id_ecdsa、id_rsa)")
+	flags.StringVar(&o.KeyPassphrase, prefix+"key-passphrase", "", "私钥密码(也可通过环境变量"+keyPassphraseEnv+"提供)")
+	flags.BoolVar(&o.UseAgent, prefix+"agent", true, "使用SSH_AUTH_SOCK指向的ssh-agent")
+	flags.BoolVar(&o.KeyboardInteractive, prefix+"keyboard-interactive", true, "允许使用密码应答keyboard-interactive认证")
+	flags.StringVar(&o.HostKeyPolicy, prefix+"host-key-policy", "", "主机密钥校验策略(strict/tofu/insecure, 默认取配置文件, 未配置为tofu)")
+	flags.StringVar(&o.KnownHostsFile, prefix+"known-hosts", "", "known_hosts文件路径(默认~/.ssh/known_hosts)")
+	flags.BoolVar(&o.UseSSHConfig, "ssh-config", true, "从~/.ssh/config读取主机别名的HostName、User、Port、IdentityFile和ProxyJump")
+	flags.StringVar(&o.Jump, prefix+"jump", "", "跳板机, 格式[user@]host[:port], 多跳用逗号分隔(默认取配置文件server.jump_hosts)")
+}
+
+// newSSHConfig 构建SSH客户端配置, 认证顺序: ssh-agent → 私钥 → 密码 → keyboard-interactive
+func newSSHConfig(host string, port int, user, password string, auth SSHAuthOptions) *ssh.Config {
+	passphrase := auth.KeyPassphrase
+	if passphrase == "" {
+		passphrase = os.Getenv(keyPassphraseEnv)
+	}
+
+	hostKeyPolicy := auth.HostKeyPolicy
+	if hostKeyPolicy == "" {
+		hostKeyPolicy = appConfig.Server.HostKeyPolicy
+	}
+	knownHostsFile := auth.KnownHostsFile
+	if knownHostsFile == "" {
+		knownHostsFile = appConfig.Server.KnownHostsFile
+	}
+
+	return &ssh.Config{
+		Host:                host,
+		Port:                port,
+		User:                user,
+		Password:            password,
+		Timeout:             sshTimeout(),
+		KeyFiles:            auth.KeyFiles,
+		KeyPassphrase:       passphrase,
+		UseDefaultKeys:      true,
+		UseAgent:            auth.UseAgent,
+		KeyboardInteractive: auth.KeyboardInteractive,
+		HostKeyPolicy:       hostKeyPolicy,
+		KnownHostsFile:      knownHostsFile,
+		UseSSHConfig:        auth.UseSSHConfig,
+		SSHConfigFile:       appConfig.Server.SSHConfigFile,
+	}
+}
+
+// jumpHosts 解析跳板机列表: 命令行参数优先, 否则使用配置文件中的server.jump_hosts
+// 命令行指定的跳板机继承目标主机的私钥和agent配置; 配置文件中每一跳可单独指定用户、密码和私钥
+func jumpHosts(base *ssh.Config, auth SSHAuthOptions) ([]*ssh.Config, error) {
+	if auth.Jump != "" {
+		return ssh.ParseJumpSpec(auth.Jump, base)
+	}
+
+	var hops []*ssh.Config
+	for _, jh := range appConfig.Server.JumpHosts {
+		hop := &ssh.Config{
+			Host:                jh.Host,
+			Port:                jh.Port,
+			User:                jh.User,
+			Password:            jh.Password,
+			Timeout:             base.Timeout,
+			KeyFiles:            jh.KeyFiles,
+			KeyPassphrase:       jh.KeyPassphrase,
+			UseDefaultKeys:      base.UseDefaultKeys,
+			UseAgent:            base.UseAgent,
+			KeyboardInteractive: base.KeyboardInteractive,
+			HostKeyPolicy:       base.HostKeyPolicy,
+			KnownHostsFile:      base.KnownHostsFile,
+			UseSSHConfig:        base.UseSSHConfig,
+			SSHConfigFile:       base.SSHConfigFile,
+		}
+		if hop.User == "" {
+			hop.User = base.User
+		}
+		if len(hop.KeyFiles) == 0 {
+			hop.KeyFiles = base.KeyFiles
+		}
+		if hop.KeyPassphrase == "" {
+			hop.KeyPassphrase = base.KeyPassphrase
+		}
+		hops = append(hops, hop)
+	}
+	return hops, nil
+}
+
+// hostKeyChangedReport 主机密钥变更时生成只包含严重问题的服务器报告, 避免静默连接到可疑主机
+func hostKeyChangedReport(host string, changed *ssh.HostKeyChangedError, list *waivers.List) *models.ServerReport {
+	report := &models.ServerReport{
+		Host:      host,
+		Status:    models.HostStatusAuthFailed,
+		Timestamp: time.Now(),
+		Issues: []models.Issue{{
+			Level:      "critical",
+			Category:   "security",
+			Message:    fmt.Sprintf("SSH主机密钥已变更: %s", host),
+			Details:    fmt.Sprintf("当前指纹: %s; 已记录: %s", changed.Fingerprint, strings.Join(changed.Known, ", ")),
+			Timestamp:  time.Now(),
+			Suggestion: "确认服务器是否重装或存在中间人攻击, 核实后更新known_hosts",
+		}},
+	}
+	report.Issues = list.Apply(report.Issues, host)
+	models.IdentifyIssues(report.Issues, host)
+	return report
+}
+
+// connectFailedReport SSH连接失败时生成服务器报告, 按错误类型区分不可达、认证失败和超时, 问题同样应用豁免
+func connectFailedReport(host string, err error, list *waivers.List) *models.ServerReport {
+	if changed, ok := ssh.IsHostKeyChanged(err); ok {
+		return hostKeyChangedReport(host, changed, list)
+	}
+
+	switch {
+	case errors.Is(err, ssh.ErrAuthFailed):
+		return failedServerReport(host, models.HostStatusAuthFailed, err,
+			fmt.Sprintf("SSH认证失败: %s", host), "检查用户名、密码、私钥或ssh-agent配置", list)
+	case errors.Is(err, ssh.ErrHostKeyUnknown):
+		return failedServerReport(host, models.HostStatusAuthFailed, err,
+			fmt.Sprintf("SSH主机密钥未知: %s", host), "核实主机密钥后加入known_hosts, 或使用tofu策略", list)
+	case ssh.IsTimeout(err):
+		return failedServerReport(host, models.HostStatusTimeout, err,
+			fmt.Sprintf("SSH连接超时: %s", host), "检查网络连通性、防火墙和服务器负载, 或增大server.timeout", list)
+	default:
+		return failedServerReport(host, models.HostStatusUnreachable, err,
+			fmt.Sprintf("主机不可达: %s", host), "检查服务器是否在线、SSH服务是否运行以及网络和防火墙配置", list)
+	}
+}
+
+// inspectFailedReport 已连接但巡检失败时生成服务器报告
+func inspectFailedReport(host string, err error, list *waivers.List) *models.ServerReport {
+	return failedServerReport(host, models.HostStatusInspectionFailed, err,
+		fmt.Sprintf("服务器巡检失败: %s", host), "检查巡检用户权限以及/proc、df等命令是否可用", list)
+}
+
+// failedServerReport 生成只包含一个严重问题的失败报告, 使失败的主机计入摘要和退出码
+func failedServerReport(host, status string, err error, message, suggestion string, list *waivers.List) *models.ServerReport {
+	report := &models.ServerReport{
+		Host:      host,
+		Status:    status,
+		Timestamp: time.Now(),
+		Issues: []models.Issue{{
+			Level:      "critical",
+			Category:   "connectivity",
+			Message:    message,
+			Details:    err.Error(),
+			Timestamp:  time.Now(),
+			Suggestion: suggestion,
+		}},
+	}
+	report.Issues = list.Apply(report.Issues, host)
+	models.IdentifyIssues(report.Issues, host)
+	return report
+}
+
+// applySSHConfigDefaults 启用ssh_config且未显式指定用户名、端口时清空默认值, 交由ssh_config解析
+// ssh_config中也未配置时连接时使用root和22
+func applySSHConfigDefaults(cmd *cobra.Command, userFlag, portFlag string, user *string, port *int, auth SSHAuthOptions) {
+	if !auth.UseSSHConfig {
+		return
+	}
+	if !cmd.Flags().Changed(userFlag) {
+		*user = ""
+	}
+	if !cmd.Flags().Changed(portFlag) {
+		*port = 0
+	}
+}
+
+// applyPasswordEnv 命令行未指定SSH密码时使用环境变量中的密码
+func applyPasswordEnv(password *string) {
+	if *password == "" {
+		*password = os.Getenv(passwordEnv)
+	}
+}
+
+// hasCredentials 判断是否提供了任何可用的SSH凭据
+func hasCredentials(password string, auth SSHAuthOptions) bool {
+	if password != "" || len(auth.KeyFiles) > 0 {
+		return true
+	}
+	if auth.UseAgent && os.Getenv("SSH_AUTH_SOCK") != "" {
+		return true
+	}
+	return len(ssh.DefaultKeyFiles()) > 0
+}
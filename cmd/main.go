@@ -16,6 +16,8 @@ var (
 )
 
 func main() {
+	var configFile string
+
 	rootCmd := &cobra.Command{
 		Use:   "inspection-tool",
 		Short: "服务器和Kubernetes巡检工具",
@@ -37,15 +39,29 @@ func main() {
   # 混合巡检
   inspection-tool all --kubeconfig ~/.kube/config`,
 		Version: fmt.Sprintf("%s (commit: %s, built: %s)", version, commit, date),
+		PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+			// 参数解析通过后出错不再打印用法, 如发现问题或主机不可达
+			cmd.SilenceUsage = true
+			return commands.InitConfig(configFile)
+		},
+		// 错误由 main 统一输出一次
+		SilenceErrors: true,
 	}
 
+	rootCmd.PersistentFlags().StringVar(&configFile, "config", "", "配置文件路径(默认查找 ./configs/config.yaml)")
+
 	// 添加子命令
 	rootCmd.AddCommand(commands.NewServerCommand())
 	rootCmd.AddCommand(commands.NewK8sCommand())
 	rootCmd.AddCommand(commands.NewAllCommand())
+	rootCmd.AddCommand(commands.NewChecksCommand())
+	rootCmd.AddCommand(commands.NewDiffCommand())
+	rootCmd.AddCommand(commands.NewHistoryCommand())
+	rootCmd.AddCommand(commands.NewDaemonCommand())
+	rootCmd.AddCommand(commands.NewServeCommand())
 
 	if err := rootCmd.Execute(); err != nil {
-		fmt.Fprintln(os.Stderr, err)
-		os.Exit(1)
+		fmt.Fprintln(os.Stderr, "Error:", err)
+		os.Exit(commands.ExitCode(err))
 	}
 }
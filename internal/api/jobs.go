@@ -0,0 +1,348 @@
+package api
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"inspection-tool/pkg/models"
+	"inspection-tool/pkg/report"
+	"inspection-tool/pkg/utils"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// 巡检任务状态
+const (
+	StatusQueued    = "queued"
+	StatusRunning   = "running"
+	StatusCompleted = "completed" // 命令执行结束, 是否发现问题见exit_code
+	StatusFailed    = "failed"    // 参数、配置等工具错误(退出码1)或命令无法执行
+	StatusCanceled  = "canceled"  // 服务停止时仍在排队
+)
+
+const (
+	// maxJobs 保留的任务数量, 超过时删除最早结束的任务
+	maxJobs = 1000
+	// maxLogLines 每个任务保留的输出行数
+	maxLogLines = 500
+)
+
+// Request 启动巡检的请求, Args和Options都是命令行参数, 可以同时使用
+type Request struct {
+	Command string                 `json:"command"`           // server, k8s, all
+	Args    []string               `json:"args,omitempty"`    // 如 ["--inventory", "hosts.yaml"]
+	Options map[string]interface{} `json:"options,omitempty"` // 以参数名为键, 如 {"inventory": "hosts.yaml", "detailed": true}
+}
+
+// commandArgs 合并Args和Options为命令行参数, Options按参数名排序, 数组值重复传入同一参数
+func (r *Request) commandArgs() ([]string, error) {
+	switch r.Command {
+	case "server", "k8s", "all":
+	default:
+		return nil, fmt.Errorf("command must be server, k8s or all, got %q", r.Command)
+	}
+
+	args := append([]string{}, r.Args...)
+	names := make([]string, 0, len(r.Options))
+	for name := range r.Options {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		values, ok := r.Options[name].([]interface{})
+		if !ok {
+			values = []interface{}{r.Options[name]}
+		}
+		for _, v := range values {
+			var value string
+			switch v := v.(type) {
+			case string:
+				value = v
+			case bool:
+				value = strconv.FormatBool(v)
+			case float64:
+				value = strconv.FormatFloat(v, 'f', -1, 64)
+			default:
+				return nil, fmt.Errorf("option %s must be a string, number, boolean or array of them", name)
+			}
+			args = append(args, fmt.Sprintf("--%s=%s", name, value))
+		}
+	}
+	return args, nil
+}
+
+// Job 一次巡检任务
+type Job struct {
+	ID         string     `json:"id"`
+	Command    string     `json:"command"`
+	Args       []string   `json:"args"` // 密码类参数的值已隐藏
+	Status     string     `json:"status"`
+	ExitCode   *int       `json:"exit_code,omitempty"` // 0 未发现问题, 2 发现问题, 3 采集失败, 见退出码说明
+	Error      string     `json:"error,omitempty"`
+	CreatedAt  time.Time  `json:"created_at"`
+	StartedAt  *time.Time `json:"started_at,omitempty"`
+	FinishedAt *time.Time `json:"finished_at,omitempty"`
+	Progress   Progress   `json:"progress"`
+	Reports    []string   `json:"reports"` // 已保存的报告文件
+
+	args []string
+	log  []string
+}
+
+// Progress 任务进度
+type Progress struct {
+	QueuePosition int    `json:"queue_position,omitempty"` // 排队时前面的任务数量加1
+	ReportsSaved  int    `json:"reports_saved"`            // 已保存的报告数量, 主机清单中每台服务器一个报告
+	Message       string `json:"message,omitempty"`        // 最近一行输出
+}
+
+// finished 任务是否已结束
+func (j *Job) finished() bool {
+	switch j.Status {
+	case StatusCompleted, StatusFailed, StatusCanceled:
+		return true
+	}
+	return false
+}
+
+// newJobID 随机生成任务ID
+func newJobID() string {
+	b := make([]byte, 8)
+	if _, err := rand.Read(b); err != nil {
+		return strconv.FormatInt(time.Now().UnixNano(), 36)
+	}
+	return hex.EncodeToString(b)
+}
+
+// maskArgs 隐藏密码类参数的值
+func maskArgs(args []string) []string {
+	masked := make([]string, len(args))
+	hideNext := false
+	for i, arg := range args {
+		switch {
+		case hideNext:
+			masked[i] = "******"
+			hideNext = false
+		case strings.HasPrefix(arg, "--") && secret(arg):
+			if name, _, ok := strings.Cut(arg, "="); ok {
+				masked[i] = name + "=******"
+			} else {
+				masked[i] = arg
+				hideNext = true
+			}
+		default:
+			masked[i] = arg
+		}
+	}
+	return masked
+}
+
+func secret(flag string) bool {
+	name, _, _ := strings.Cut(strings.TrimPrefix(flag, "--"), "=")
+	return strings.Contains(name, "password") || strings.Contains(name, "passphrase") || strings.Contains(name, "token")
+}
+
+// run 执行任务并更新状态
+func (s *Server) run(job *Job) {
+	s.mu.Lock()
+	if job.Status != StatusQueued {
+		s.mu.Unlock()
+		return
+	}
+	now := time.Now()
+	job.Status = StatusRunning
+	job.StartedAt = &now
+	s.mu.Unlock()
+
+	log := func(line string) {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		job.log = append(job.log, line)
+		if len(job.log) > maxLogLines {
+			job.log = job.log[len(job.log)-maxLogLines:]
+		}
+		if line = strings.TrimSpace(line); line != "" && strings.Trim(line, "=") != "" {
+			job.Progress.Message = line
+		}
+	}
+	saved := func(path string) {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		job.Reports = append(job.Reports, path)
+		job.Progress.ReportsSaved = len(job.Reports)
+	}
+	code, err := s.opts.Run(s.ctx, job.Command, job.args, log, saved)
+
+	s.mu.Lock()
+	now = time.Now()
+	job.FinishedAt = &now
+	switch {
+	case err != nil:
+		job.Status = StatusFailed
+		job.Error = err.Error()
+	case code == 1:
+		job.Status = StatusFailed
+		job.ExitCode = &code
+		job.Error = job.Progress.Message
+	default:
+		job.Status = StatusCompleted
+		job.ExitCode = &code
+	}
+	reports := append([]string{}, job.Reports...)
+	s.mu.Unlock()
+
+	// 更新 /metrics 中的巡检结果
+	if s.opts.Exporter != nil {
+		for _, path := range reports {
+			if inspection, err := report.Load(path); err == nil {
+				s.opts.Exporter.Update(inspection)
+			}
+		}
+	}
+}
+
+// submit 创建任务并加入队列, 队列已满或服务停止时返回错误
+func (s *Server) submit(req *Request) (*Job, error) {
+	args, err := req.commandArgs()
+	if err != nil {
+		return nil, &requestError{err}
+	}
+	if s.opts.Validate != nil {
+		if err := s.opts.Validate(req.Command, args); err != nil {
+			return nil, &requestError{err}
+		}
+	}
+
+	job := &Job{
+		ID:        newJobID(),
+		Command:   req.Command,
+		Args:      maskArgs(args),
+		Status:    StatusQueued,
+		CreatedAt: time.Now(),
+		Reports:   []string{},
+		args:      args,
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.closed {
+		return nil, errShuttingDown
+	}
+	select {
+	case s.queue <- job:
+	default:
+		return nil, errQueueFull
+	}
+	s.jobs[job.ID] = job
+	s.order = append(s.order, job.ID)
+	s.pruneJobs()
+	return job, nil
+}
+
+// pruneJobs 任务数量超过maxJobs时删除最早结束的任务, 调用时需持有锁
+func (s *Server) pruneJobs() {
+	for i := 0; len(s.order) > maxJobs && i < len(s.order); {
+		if job := s.jobs[s.order[i]]; job.finished() {
+			delete(s.jobs, job.ID)
+			s.order = append(s.order[:i], s.order[i+1:]...)
+			continue
+		}
+		i++
+	}
+}
+
+// snapshot 返回任务的副本并计算排队位置, 调用时需持有锁
+func (s *Server) snapshot(job *Job) Job {
+	c := *job
+	c.Reports = append([]string{}, job.Reports...)
+	c.args, c.log = nil, nil
+	if job.Status == StatusQueued {
+		c.Progress.QueuePosition = 1
+		for _, id := range s.order {
+			if id == job.ID {
+				break
+			}
+			if s.jobs[id].Status == StatusQueued {
+				c.Progress.QueuePosition++
+			}
+		}
+	}
+	return c
+}
+
+// cancelQueued 取消所有排队中的任务
+func (s *Server) cancelQueued() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	now := time.Now()
+	for _, job := range s.jobs {
+		if job.Status == StatusQueued {
+			job.Status = StatusCanceled
+			job.FinishedAt = &now
+			job.Error = "server shutting down"
+		}
+	}
+}
+
+// loadReports 加载json或yaml报告, 一次巡检保存的多个报告(如清单中每台服务器一个)合并为一个
+func loadReports(command string, paths []string) (*models.InspectionReport, error) {
+	var reports []*models.InspectionReport
+	for _, path := range paths {
+		r, err := report.Load(path)
+		if err != nil {
+			return nil, err
+		}
+		reports = append(reports, r)
+	}
+	if len(reports) == 1 {
+		return reports[0], nil
+	}
+
+	merged := &models.InspectionReport{Type: command, Timestamp: reports[0].Timestamp}
+	for _, r := range reports {
+		merged.ServerReports = append(merged.ServerReports, r.Servers()...)
+		if r.K8sReport != nil {
+			merged.K8sReport = r.K8sReport
+		}
+	}
+	utils.BuildInspectionSummary(merged)
+	return merged, nil
+}
+
+// worker 依次执行队列中的任务, 队列关闭后退出
+func (s *Server) worker() {
+	defer s.wg.Done()
+	for job := range s.queue {
+		s.run(job)
+	}
+}
+
+// Shutdown 停止接受新任务并取消排队中的任务, 等待执行中的任务结束;
+// ctx结束时中止执行中的任务
+func (s *Server) Shutdown(ctx context.Context) error {
+	s.mu.Lock()
+	if !s.closed {
+		s.closed = true
+		close(s.queue)
+	}
+	s.mu.Unlock()
+	s.cancelQueued()
+
+	done := make(chan struct{})
+	go func() {
+		s.wg.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		s.cancel()
+		<-done
+		return ctx.Err()
+	}
+}
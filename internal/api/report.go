@@ -0,0 +1,114 @@
+package api
+
+import (
+	"errors"
+	"fmt"
+	"inspection-tool/pkg/report"
+	"io/fs"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// formats 支持的报告格式及其Content-Type
+var formats = map[string]string{
+	"json":     "application/json",
+	"yaml":     "application/yaml",
+	"html":     "text/html; charset=utf-8",
+	"markdown": "text/markdown; charset=utf-8",
+	"text":     "text/plain; charset=utf-8",
+	"csv":      "text/csv; charset=utf-8",
+	"xlsx":     "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet",
+	"junit":    "application/xml",
+	"sarif":    "application/sarif+json",
+}
+
+// fileFormat 报告文件的格式
+func fileFormat(path string) string {
+	switch ext := strings.TrimPrefix(strings.ToLower(filepath.Ext(path)), "."); ext {
+	case "md":
+		return "markdown"
+	case "txt":
+		return "text"
+	case "xml":
+		return "junit"
+	case "yml":
+		return "yaml"
+	default:
+		return ext
+	}
+}
+
+// serveReport 返回报告, ?format= 与报告文件的格式相同时直接返回文件, 否则转换格式;
+// 只有json和yaml报告可以转换, csv格式用 ?table= 指定表(默认issues)
+func serveReport(w http.ResponseWriter, r *http.Request, command string, paths []string) {
+	stored := fileFormat(paths[0])
+	format := r.URL.Query().Get("format")
+	if format == "" {
+		format = stored
+		if len(paths) > 1 {
+			format = "json"
+		}
+	}
+	contentType, ok := formats[format]
+	if !ok {
+		writeError(w, http.StatusBadRequest, fmt.Errorf("unsupported format %q", format))
+		return
+	}
+	table := r.URL.Query().Get("table")
+	if table == "" {
+		table = "issues"
+	}
+
+	base := strings.TrimSuffix(filepath.Base(paths[0]), filepath.Ext(paths[0]))
+	if len(paths) > 1 {
+		base = "inspection_" + command
+	}
+	if format == "csv" {
+		base = strings.TrimSuffix(base, "_*") + "_" + table
+	}
+	name := base + "." + report.Extension(format)
+
+	var content []byte
+	var err error
+	switch {
+	case len(paths) == 1 && format == stored && stored == "csv":
+		// csv报告每张表一个文件, 记录的是 <base>_*.csv
+		content, err = os.ReadFile(strings.Replace(paths[0], "*", table, 1))
+	case len(paths) == 1 && format == stored:
+		content, err = os.ReadFile(paths[0])
+	case !loadable(stored):
+		writeError(w, http.StatusConflict, fmt.Errorf("report is stored as %s and can only be converted from json or yaml", stored))
+		return
+	default:
+		data, loadErr := loadReports(command, paths)
+		switch {
+		case loadErr != nil:
+			err = loadErr
+		case format == "csv":
+			if content, err = report.RenderCSVTable(data, table); err != nil {
+				writeError(w, http.StatusNotFound, err)
+				return
+			}
+		default:
+			content, err = report.NewGenerator(format, "", true).Render(data)
+		}
+	}
+	if errors.Is(err, fs.ErrNotExist) {
+		writeError(w, http.StatusNotFound, fmt.Errorf("report file no longer exists: %w", err))
+		return
+	}
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", contentType)
+	w.Header().Set("Content-Disposition", fmt.Sprintf("inline; filename=%q", name))
+	w.Write(content)
+}
+
+func loadable(format string) bool {
+	return format == "json" || format == "yaml"
+}
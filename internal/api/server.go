@@ -0,0 +1,322 @@
+package api
+
+import (
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"inspection-tool/internal/history"
+	"inspection-tool/internal/metrics"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// maxRequestBody 请求体大小上限
+const maxRequestBody = 1 << 20
+
+var (
+	errQueueFull    = errors.New("too many inspections waiting, try again later")
+	errShuttingDown = errors.New("server is shutting down")
+)
+
+// requestError 请求参数错误, 返回400
+type requestError struct {
+	err error
+}
+
+func (e *requestError) Error() string { return e.err.Error() }
+
+// RunFunc 执行一次巡检并返回命令的退出码, 输出的每一行调用log, 每保存一个报告调用saved
+// ctx结束时应中止巡检
+type RunFunc func(ctx context.Context, command string, args []string, log func(line string), saved func(path string)) (int, error)
+
+// ValidateFunc 校验命令行参数
+type ValidateFunc func(command string, args []string) error
+
+// Options API服务选项
+type Options struct {
+	Token       string            // 访问令牌, 不能为空
+	OutputDir   string            // 报告输出目录, 历史报告从其中的巡检历史读取
+	Concurrency int               // 同时执行的巡检数量
+	QueueSize   int               // 等待执行的巡检数量上限
+	Run         RunFunc           // 执行巡检
+	Validate    ValidateFunc      // 加入队列前校验命令行参数, 可为nil
+	Exporter    *metrics.Exporter // 不为nil时提供 /metrics, 并在巡检结束后更新
+}
+
+// Server 触发巡检和获取报告的HTTP API
+//
+//	POST /api/v1/inspections              启动巡检, 请求体见Request
+//	GET  /api/v1/inspections              列出任务
+//	GET  /api/v1/inspections/{id}         任务状态和进度
+//	GET  /api/v1/inspections/{id}/log     任务输出
+//	GET  /api/v1/inspections/{id}/report  任务的报告, ?format= 指定格式
+//	GET  /api/v1/reports                  历史报告, ?type= ?target= ?limit= 筛选
+//	GET  /api/v1/reports/{id}             历史报告, ?format= 指定格式
+//	GET  /metrics                         Prometheus指标
+//	GET  /healthz                         健康检查, 无需令牌
+type Server struct {
+	opts Options
+
+	mu     sync.Mutex
+	jobs   map[string]*Job
+	order  []string // 按创建顺序的任务ID
+	queue  chan *Job
+	closed bool
+
+	ctx    context.Context
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+}
+
+// New 创建API服务并启动执行巡检的worker
+func New(opts Options) (*Server, error) {
+	if opts.Token == "" {
+		return nil, fmt.Errorf("api token is required")
+	}
+	if opts.Run == nil {
+		return nil, fmt.Errorf("run function is required")
+	}
+	if opts.Concurrency < 1 {
+		opts.Concurrency = 1
+	}
+	if opts.QueueSize < 1 {
+		opts.QueueSize = 1
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	s := &Server{
+		opts:   opts,
+		jobs:   make(map[string]*Job),
+		queue:  make(chan *Job, opts.QueueSize),
+		ctx:    ctx,
+		cancel: cancel,
+	}
+	for i := 0; i < opts.Concurrency; i++ {
+		s.wg.Add(1)
+		go s.worker()
+	}
+	return s, nil
+}
+
+// Handler 返回API的http.Handler
+func (s *Server) Handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/api/v1/inspections", s.authorized(s.handleInspections))
+	mux.HandleFunc("/api/v1/inspections/", s.authorized(s.handleInspection))
+	mux.HandleFunc("/api/v1/reports", s.authorized(s.handleReports))
+	mux.HandleFunc("/api/v1/reports/", s.authorized(s.handleReport))
+	if s.opts.Exporter != nil {
+		mux.HandleFunc("/metrics", s.authorized(s.opts.Exporter.ServeHTTP))
+	}
+	mux.HandleFunc("/healthz", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("ok\n"))
+	})
+	return mux
+}
+
+// authorized 校验请求头 Authorization: Bearer <token>
+func (s *Server) authorized(next http.HandlerFunc) http.HandlerFunc {
+	expected := []byte("Bearer " + s.opts.Token)
+	return func(w http.ResponseWriter, r *http.Request) {
+		if subtle.ConstantTimeCompare([]byte(r.Header.Get("Authorization")), expected) != 1 {
+			w.Header().Set("WWW-Authenticate", `Bearer realm="inspection-tool"`)
+			writeError(w, http.StatusUnauthorized, errors.New("missing or invalid token"))
+			return
+		}
+		next(w, r)
+	}
+}
+
+// handleInspections 启动巡检或列出任务
+func (s *Server) handleInspections(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodPost:
+		var req Request
+		dec := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxRequestBody))
+		dec.DisallowUnknownFields()
+		if err := dec.Decode(&req); err != nil {
+			writeError(w, http.StatusBadRequest, fmt.Errorf("invalid request body: %w", err))
+			return
+		}
+		job, err := s.submit(&req)
+		if err != nil {
+			var reqErr *requestError
+			switch {
+			case errors.As(err, &reqErr):
+				writeError(w, http.StatusBadRequest, err)
+			case errors.Is(err, errQueueFull):
+				writeError(w, http.StatusTooManyRequests, err)
+			default:
+				writeError(w, http.StatusServiceUnavailable, err)
+			}
+			return
+		}
+		s.mu.Lock()
+		snapshot := s.snapshot(job)
+		s.mu.Unlock()
+		w.Header().Set("Location", "/api/v1/inspections/"+job.ID)
+		writeJSON(w, http.StatusAccepted, snapshot)
+
+	case http.MethodGet:
+		s.mu.Lock()
+		jobs := make([]Job, 0, len(s.order))
+		for i := len(s.order) - 1; i >= 0; i-- {
+			jobs = append(jobs, s.snapshot(s.jobs[s.order[i]]))
+		}
+		s.mu.Unlock()
+		writeJSON(w, http.StatusOK, jobs)
+
+	default:
+		methodNotAllowed(w, http.MethodGet, http.MethodPost)
+	}
+}
+
+// handleInspection 任务状态、输出和报告
+func (s *Server) handleInspection(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		methodNotAllowed(w, http.MethodGet)
+		return
+	}
+	id, sub, _ := strings.Cut(strings.TrimPrefix(r.URL.Path, "/api/v1/inspections/"), "/")
+
+	s.mu.Lock()
+	job, ok := s.jobs[id]
+	var snapshot Job
+	var log []string
+	if ok {
+		snapshot = s.snapshot(job)
+		log = append(log, job.log...)
+	}
+	s.mu.Unlock()
+	if !ok {
+		writeError(w, http.StatusNotFound, fmt.Errorf("inspection %s not found", id))
+		return
+	}
+
+	switch sub {
+	case "":
+		writeJSON(w, http.StatusOK, snapshot)
+	case "log":
+		w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+		for _, line := range log {
+			fmt.Fprintln(w, line)
+		}
+	case "report":
+		if !snapshot.finished() {
+			writeError(w, http.StatusConflict, fmt.Errorf("inspection %s is %s", id, snapshot.Status))
+			return
+		}
+		if len(snapshot.Reports) == 0 {
+			writeError(w, http.StatusNotFound, fmt.Errorf("inspection %s saved no report", id))
+			return
+		}
+		serveReport(w, r, snapshot.Command, snapshot.Reports)
+	default:
+		writeError(w, http.StatusNotFound, fmt.Errorf("unknown resource %s", sub))
+	}
+}
+
+// handleReports 列出巡检历史中的报告, 最新的在前
+func (s *Server) handleReports(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		methodNotAllowed(w, http.MethodGet)
+		return
+	}
+	query := r.URL.Query()
+	limit := 0
+	if v := query.Get("limit"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 0 {
+			writeError(w, http.StatusBadRequest, fmt.Errorf("invalid limit %q", v))
+			return
+		}
+		limit = n
+	}
+
+	runs, err := s.runs()
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+	result := make([]history.Run, 0, len(runs))
+	for i := len(runs) - 1; i >= 0; i-- {
+		run := runs[i]
+		if t := query.Get("type"); t != "" && run.Type != t {
+			continue
+		}
+		if t := query.Get("target"); t != "" && !contains(run.Targets, t) {
+			continue
+		}
+		result = append(result, run)
+		if limit > 0 && len(result) == limit {
+			break
+		}
+	}
+	writeJSON(w, http.StatusOK, result)
+}
+
+// handleReport 返回巡检历史中的一个报告
+func (s *Server) handleReport(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		methodNotAllowed(w, http.MethodGet)
+		return
+	}
+	id := strings.TrimPrefix(r.URL.Path, "/api/v1/reports/")
+
+	runs, err := s.runs()
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+	for _, run := range runs {
+		if run.ID != id {
+			continue
+		}
+		if run.Report == "" {
+			writeError(w, http.StatusNotFound, fmt.Errorf("report file of %s was not recorded", id))
+			return
+		}
+		serveReport(w, r, run.Type, []string{run.Report})
+		return
+	}
+	writeError(w, http.StatusNotFound, fmt.Errorf("report %s not found", id))
+}
+
+// runs 报告输出目录中的巡检历史
+func (s *Server) runs() ([]history.Run, error) {
+	store, err := history.Open(s.opts.OutputDir)
+	if err != nil {
+		return nil, err
+	}
+	return store.Runs()
+}
+
+func contains(values []string, v string) bool {
+	for _, value := range values {
+		if value == v {
+			return true
+		}
+	}
+	return false
+}
+
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	enc.Encode(v)
+}
+
+func writeError(w http.ResponseWriter, status int, err error) {
+	writeJSON(w, status, map[string]string{"error": err.Error()})
+}
+
+func methodNotAllowed(w http.ResponseWriter, methods ...string) {
+	w.Header().Set("Allow", strings.Join(methods, ", "))
+	writeError(w, http.StatusMethodNotAllowed, errors.New("method not allowed"))
+}
@@ -0,0 +1,311 @@
+package api
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"inspection-tool/internal/history"
+	"inspection-tool/pkg/models"
+	"inspection-tool/pkg/report"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+const testToken = "secret"
+
+// newTestServer 创建API服务, run为nil时保存一个json服务器报告并以退出码2结束
+func newTestServer(t *testing.T, run RunFunc, queueSize int) (*Server, string) {
+	t.Helper()
+	dir := t.TempDir()
+	if run == nil {
+		run = func(ctx context.Context, command string, args []string, log func(string), saved func(string)) (int, error) {
+			log("开始巡检")
+			path, err := report.NewGenerator("json", dir, true).GenerateServerReport(&models.ServerReport{
+				Host:      "10.0.0.1",
+				Timestamp: time.Date(2024, 1, 1, 8, 0, 0, 0, time.UTC),
+				Issues:    []models.Issue{{Level: models.SeverityWarning, Category: "disk", Message: "磁盘使用率过高"}},
+			})
+			if err != nil {
+				return 0, err
+			}
+			saved(path)
+			return 2, nil
+		}
+	}
+	s, err := New(Options{Token: testToken, OutputDir: dir, Concurrency: 1, QueueSize: queueSize, Run: run})
+	if err != nil {
+		t.Fatalf("New failed: %v", err)
+	}
+	t.Cleanup(func() { s.Shutdown(context.Background()) })
+	return s, dir
+}
+
+func do(h http.Handler, method, path, body string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, path, strings.NewReader(body))
+	req.Header.Set("Authorization", "Bearer "+testToken)
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	return rec
+}
+
+// waitJob 等待任务结束
+func waitJob(t *testing.T, h http.Handler, id string) Job {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		var job Job
+		rec := do(h, http.MethodGet, "/api/v1/inspections/"+id, "")
+		if err := json.Unmarshal(rec.Body.Bytes(), &job); err != nil {
+			t.Fatalf("invalid job response %q: %v", rec.Body.String(), err)
+		}
+		if job.finished() {
+			return job
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("job %s did not finish: %+v", id, job)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestNew(t *testing.T) {
+	if _, err := New(Options{Run: func(context.Context, string, []string, func(string), func(string)) (int, error) { return 0, nil }}); err == nil {
+		t.Error("expected error without token")
+	}
+	if _, err := New(Options{Token: testToken}); err == nil {
+		t.Error("expected error without run function")
+	}
+}
+
+func TestAuthorization(t *testing.T) {
+	s, _ := newTestServer(t, nil, 1)
+	h := s.Handler()
+
+	for _, header := range []string{"", "Bearer wrong", testToken} {
+		req := httptest.NewRequest(http.MethodGet, "/api/v1/inspections", nil)
+		if header != "" {
+			req.Header.Set("Authorization", header)
+		}
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, req)
+		if rec.Code != http.StatusUnauthorized {
+			t.Errorf("Authorization %q: expected 401, got %d", header, rec.Code)
+		}
+	}
+
+	// 健康检查无需令牌
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/healthz", nil))
+	if rec.Code != http.StatusOK {
+		t.Errorf("expected 200 for /healthz, got %d", rec.Code)
+	}
+}
+
+func TestInspectionLifecycle(t *testing.T) {
+	s, _ := newTestServer(t, nil, 1)
+	h := s.Handler()
+
+	rec := do(h, http.MethodPost, "/api/v1/inspections", `{"command":"server","options":{"inventory":"hosts.yaml","password":"p"}}`)
+	if rec.Code != http.StatusAccepted {
+		t.Fatalf("expected 202, got %d: %s", rec.Code, rec.Body.String())
+	}
+	var created Job
+	if err := json.Unmarshal(rec.Body.Bytes(), &created); err != nil {
+		t.Fatalf("invalid response: %v", err)
+	}
+	if rec.Header().Get("Location") != "/api/v1/inspections/"+created.ID {
+		t.Errorf("unexpected Location %q", rec.Header().Get("Location"))
+	}
+	if want := []string{"--inventory=hosts.yaml", "--password=******"}; !reflect.DeepEqual(created.Args, want) {
+		t.Errorf("expected args %v, got %v", want, created.Args)
+	}
+
+	job := waitJob(t, h, created.ID)
+	if job.Status != StatusCompleted || job.ExitCode == nil || *job.ExitCode != 2 {
+		t.Fatalf("unexpected job: %+v", job)
+	}
+	if job.Progress.ReportsSaved != 1 || job.Progress.Message != "开始巡检" {
+		t.Errorf("unexpected progress: %+v", job.Progress)
+	}
+
+	if rec := do(h, http.MethodGet, "/api/v1/inspections/"+job.ID+"/log", ""); rec.Body.String() != "开始巡检\n" {
+		t.Errorf("unexpected log %q", rec.Body.String())
+	}
+
+	// 任务列表最新的在前
+	var jobs []Job
+	json.Unmarshal(do(h, http.MethodGet, "/api/v1/inspections", "").Body.Bytes(), &jobs)
+	if len(jobs) != 1 || jobs[0].ID != job.ID {
+		t.Errorf("unexpected jobs: %+v", jobs)
+	}
+
+	if rec := do(h, http.MethodGet, "/api/v1/inspections/unknown", ""); rec.Code != http.StatusNotFound {
+		t.Errorf("expected 404 for unknown job, got %d", rec.Code)
+	}
+}
+
+func TestInspectionReport(t *testing.T) {
+	s, _ := newTestServer(t, nil, 1)
+	h := s.Handler()
+
+	var created Job
+	json.Unmarshal(do(h, http.MethodPost, "/api/v1/inspections", `{"command":"server","args":["--host","10.0.0.1"]}`).Body.Bytes(), &created)
+	waitJob(t, h, created.ID)
+
+	tests := []struct {
+		query       string
+		code        int
+		contentType string
+		contains    string
+	}{
+		{"", http.StatusOK, "application/json", `"host": "10.0.0.1"`},
+		{"?format=html", http.StatusOK, "text/html; charset=utf-8", "<html"},
+		{"?format=markdown", http.StatusOK, "text/markdown; charset=utf-8", "10.0.0.1"},
+		{"?format=csv", http.StatusOK, "text/csv; charset=utf-8", "磁盘使用率过高"},
+		{"?format=csv&table=servers", http.StatusOK, "text/csv; charset=utf-8", "10.0.0.1"},
+		{"?format=csv&table=pods", http.StatusNotFound, "application/json", "no pods table"},
+		{"?format=pdf", http.StatusBadRequest, "application/json", "unsupported format"},
+	}
+	for _, tt := range tests {
+		rec := do(h, http.MethodGet, "/api/v1/inspections/"+created.ID+"/report"+tt.query, "")
+		if rec.Code != tt.code || rec.Header().Get("Content-Type") != tt.contentType {
+			t.Errorf("%q: expected %d %s, got %d %s", tt.query, tt.code, tt.contentType, rec.Code, rec.Header().Get("Content-Type"))
+		}
+		if !strings.Contains(rec.Body.String(), tt.contains) {
+			t.Errorf("%q: expected %q in body:\n%s", tt.query, tt.contains, rec.Body.String())
+		}
+	}
+}
+
+func TestSubmitErrors(t *testing.T) {
+	release := make(chan struct{})
+	started := make(chan struct{}, 1)
+	s, _ := newTestServer(t, func(ctx context.Context, command string, args []string, log func(string), saved func(string)) (int, error) {
+		started <- struct{}{}
+		<-release
+		return 0, nil
+	}, 1)
+	defer close(release)
+	h := s.Handler()
+
+	for _, body := range []string{
+		`{"command":"deploy"}`,
+		`{"command":"server","options":{"host":{"ip":"10.0.0.1"}}}`,
+		`{"command":"server","unknown":true}`,
+		`not json`,
+	} {
+		if rec := do(h, http.MethodPost, "/api/v1/inspections", body); rec.Code != http.StatusBadRequest {
+			t.Errorf("%s: expected 400, got %d", body, rec.Code)
+		}
+	}
+
+	// 第一个任务执行中, 第二个排队, 第三个超出队列
+	if rec := do(h, http.MethodPost, "/api/v1/inspections", `{"command":"server"}`); rec.Code != http.StatusAccepted {
+		t.Fatalf("expected 202, got %d", rec.Code)
+	}
+	<-started
+	rec := do(h, http.MethodPost, "/api/v1/inspections", `{"command":"k8s"}`)
+	var queued Job
+	json.Unmarshal(rec.Body.Bytes(), &queued)
+	if rec.Code != http.StatusAccepted || queued.Status != StatusQueued || queued.Progress.QueuePosition != 1 {
+		t.Fatalf("expected queued job, got %d %s", rec.Code, rec.Body.String())
+	}
+	if rec := do(h, http.MethodPost, "/api/v1/inspections", `{"command":"all"}`); rec.Code != http.StatusTooManyRequests {
+		t.Errorf("expected 429, got %d", rec.Code)
+	}
+	if rec := do(h, http.MethodGet, "/api/v1/inspections/"+queued.ID+"/report", ""); rec.Code != http.StatusConflict {
+		t.Errorf("expected 409 for unfinished job, got %d", rec.Code)
+	}
+}
+
+func TestValidate(t *testing.T) {
+	dir := t.TempDir()
+	s, err := New(Options{
+		Token:     testToken,
+		OutputDir: dir,
+		Run:       func(context.Context, string, []string, func(string), func(string)) (int, error) { return 0, nil },
+		Validate: func(command string, args []string) error {
+			return errors.New("unknown flag: --no-such-flag")
+		},
+	})
+	if err != nil {
+		t.Fatalf("New failed: %v", err)
+	}
+	defer s.Shutdown(context.Background())
+
+	if rec := do(s.Handler(), http.MethodPost, "/api/v1/inspections", `{"command":"server"}`); rec.Code != http.StatusBadRequest {
+		t.Errorf("expected 400 when validation fails, got %d", rec.Code)
+	}
+}
+
+func TestReports(t *testing.T) {
+	s, dir := newTestServer(t, nil, 1)
+	h := s.Handler()
+
+	store, err := history.Open(dir)
+	if err != nil {
+		t.Fatalf("history.Open failed: %v", err)
+	}
+	start := time.Date(2024, 1, 1, 8, 0, 0, 0, time.UTC)
+	gen := report.NewGenerator("json", dir, true)
+	for i, host := range []string{"10.0.0.1", "10.0.0.2", "10.0.0.1"} {
+		sr := &models.ServerReport{Host: host, Timestamp: start.Add(time.Duration(i) * time.Hour)}
+		path, err := gen.GenerateServerReport(sr)
+		if err != nil {
+			t.Fatalf("GenerateServerReport failed: %v", err)
+		}
+		if _, err := store.Add(&models.InspectionReport{Type: "server", Timestamp: sr.Timestamp, ServerReport: sr}, path); err != nil {
+			t.Fatalf("store.Add failed: %v", err)
+		}
+	}
+
+	var runs []history.Run
+	json.Unmarshal(do(h, http.MethodGet, "/api/v1/reports?target=10.0.0.1", "").Body.Bytes(), &runs)
+	if len(runs) != 2 || !runs[0].Timestamp.Equal(start.Add(2*time.Hour)) {
+		t.Fatalf("unexpected runs: %+v", runs)
+	}
+	json.Unmarshal(do(h, http.MethodGet, "/api/v1/reports?limit=1", "").Body.Bytes(), &runs)
+	if len(runs) != 1 {
+		t.Errorf("expected 1 run with limit, got %d", len(runs))
+	}
+	if rec := do(h, http.MethodGet, "/api/v1/reports?limit=x", ""); rec.Code != http.StatusBadRequest {
+		t.Errorf("expected 400 for invalid limit, got %d", rec.Code)
+	}
+
+	rec := do(h, http.MethodGet, "/api/v1/reports/"+runs[0].ID+"?format=text", "")
+	if rec.Code != http.StatusOK || !bytes.Contains(rec.Body.Bytes(), []byte("10.0.0.1")) {
+		t.Errorf("unexpected report response %d:\n%s", rec.Code, rec.Body.String())
+	}
+	if rec := do(h, http.MethodGet, "/api/v1/reports/unknown", ""); rec.Code != http.StatusNotFound {
+		t.Errorf("expected 404 for unknown report, got %d", rec.Code)
+	}
+}
+
+func TestCommandArgs(t *testing.T) {
+	req := &Request{
+		Command: "k8s",
+		Args:    []string{"--kubeconfig", "/root/.kube/config"},
+		Options: map[string]interface{}{"detailed": true, "namespaces": []interface{}{"default", "kube-system"}, "timeout": float64(30)},
+	}
+	args, err := req.commandArgs()
+	if err != nil {
+		t.Fatalf("commandArgs failed: %v", err)
+	}
+	want := []string{"--kubeconfig", "/root/.kube/config", "--detailed=true", "--namespaces=default", "--namespaces=kube-system", "--timeout=30"}
+	if !reflect.DeepEqual(args, want) {
+		t.Errorf("expected %v, got %v", want, args)
+	}
+}
+
+func TestMaskArgs(t *testing.T) {
+	got := maskArgs([]string{"--host", "10.0.0.1", "--password", "p", "--key-passphrase=k", "--token-file", "t"})
+	want := []string{"--host", "10.0.0.1", "--password", "******", "--key-passphrase=******", "--token-file", "******"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("expected %v, got %v", want, got)
+	}
+}
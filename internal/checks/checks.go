@@ -0,0 +1,250 @@
+package checks
+
+import (
+	"fmt"
+	"inspection-tool/internal/config"
+	"inspection-tool/pkg/models"
+	"path"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Target 检查项适用的巡检对象
+type Target string
+
+const (
+	TargetServer Target = "server"
+	TargetK8s    Target = "k8s"
+)
+
+// Input 检查项的输入, 只设置与Target对应的报告和阈值
+type Input struct {
+	Server           *models.ServerReport
+	ServerThresholds config.ServerThresholds
+	K8s              *models.K8sReport
+	K8sThresholds    config.K8sThresholds
+
+	// 过去的巡检记录, 供趋势类检查项使用, 未加载历史记录时为nil
+	History History
+}
+
+// History 趋势类检查项读取的历史指标和预测参数, 由调用方按预测窗口加载
+type History interface {
+	// Trend 预测参数
+	Trend() config.TrendConfig
+	// Points 巡检对象上一个资源的指标在[since, until)内的历史值, 按时间顺序
+	Points(target, resource, metric string, since, until time.Time) []Point
+}
+
+// Point 历史记录中的一个指标值
+type Point struct {
+	Time  time.Time
+	Value float64
+}
+
+// target 问题所属巡检对象的标识: 服务器地址或集群API地址
+func (in *Input) target(t Target) string {
+	switch {
+	case t == TargetServer && in.Server != nil:
+		return in.Server.Host
+	case t == TargetK8s && in.K8s != nil:
+		return in.K8s.Target()
+	}
+	return ""
+}
+
+// Check 巡检检查项
+type Check interface {
+	// ID 稳定的检查项ID, 如 server.disk.usage, 写入生成的问题中
+	ID() string
+	Category() string
+	// Severity 默认问题级别
+	Severity() models.Severity
+	Target() Target
+	// Evaluate 根据报告生成问题, 未设置的级别、类别和时间由检查集补全
+	Evaluate(in *Input) []models.Issue
+}
+
+// funcCheck 由函数实现的检查项
+type funcCheck struct {
+	id       string
+	category string
+	severity models.Severity
+	target   Target
+	evaluate func(in *Input) []models.Issue
+}
+
+// New 由函数创建检查项
+func New(id, category string, severity models.Severity, target Target, evaluate func(in *Input) []models.Issue) Check {
+	return &funcCheck{
+		id:       id,
+		category: category,
+		severity: severity,
+		target:   target,
+		evaluate: evaluate,
+	}
+}
+
+func (c *funcCheck) ID() string                        { return c.id }
+func (c *funcCheck) Category() string                  { return c.category }
+func (c *funcCheck) Severity() models.Severity         { return c.severity }
+func (c *funcCheck) Target() Target                    { return c.target }
+func (c *funcCheck) Evaluate(in *Input) []models.Issue { return c.evaluate(in) }
+
+// Registry 检查项注册表
+type Registry struct {
+	mu     sync.RWMutex
+	checks []Check
+}
+
+// NewRegistry 创建空的注册表
+func NewRegistry() *Registry {
+	return &Registry{}
+}
+
+// Default 默认注册表, 内置检查项在各巡检器包的init中注册
+var Default = NewRegistry()
+
+// Register 向默认注册表注册检查项, ID重复时panic
+func Register(c Check) {
+	if err := Default.Register(c); err != nil {
+		panic(err)
+	}
+}
+
+// Register 注册检查项
+func (r *Registry) Register(c Check) error {
+	if c.ID() == "" {
+		return fmt.Errorf("check id is required")
+	}
+	switch c.Target() {
+	case TargetServer, TargetK8s:
+	default:
+		return fmt.Errorf("check %s: unknown target %q", c.ID(), c.Target())
+	}
+	if !c.Severity().Valid() {
+		return fmt.Errorf("check %s: invalid severity %q", c.ID(), c.Severity())
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, existing := range r.checks {
+		if existing.ID() == c.ID() {
+			return fmt.Errorf("check %s already registered", c.ID())
+		}
+	}
+	r.checks = append(r.checks, c)
+	return nil
+}
+
+// Clone 复制注册表, 用于在内置检查项之外加入本次运行的自定义规则
+func (r *Registry) Clone() *Registry {
+	return &Registry{checks: r.Checks()}
+}
+
+// Checks 按注册顺序返回所有检查项
+func (r *Registry) Checks() []Check {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return append([]Check(nil), r.checks...)
+}
+
+// Select 按 --checks 和 --skip-checks 选择检查项
+// 模式匹配检查项ID, 支持通配符, 也可以是ID前缀(如 server.disk); enable为空时选择全部
+// 没有匹配任何检查项的模式视为错误, 以免拼写错误导致检查被悄悄忽略
+func (r *Registry) Select(enable, skip []string) (*Set, error) {
+	all := r.Checks()
+	for _, p := range append(append([]string(nil), enable...), skip...) {
+		if _, err := path.Match(p, ""); err != nil {
+			return nil, fmt.Errorf("invalid check pattern %q: %w", p, err)
+		}
+		if !matchesAny(all, p) {
+			return nil, fmt.Errorf("no check matches %q", p)
+		}
+	}
+
+	set := &Set{}
+	for _, c := range all {
+		if len(enable) > 0 && !match(enable, c.ID()) {
+			continue
+		}
+		if match(skip, c.ID()) {
+			continue
+		}
+		set.checks = append(set.checks, c)
+	}
+	return set, nil
+}
+
+// match 判断ID是否匹配任一模式
+func match(patterns []string, id string) bool {
+	for _, p := range patterns {
+		if p == id || strings.HasPrefix(id, p+".") {
+			return true
+		}
+		if ok, _ := path.Match(p, id); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// matchesAny 判断模式是否匹配任一检查项
+func matchesAny(all []Check, pattern string) bool {
+	for _, c := range all {
+		if match([]string{pattern}, c.ID()) {
+			return true
+		}
+	}
+	return false
+}
+
+// Set 本次巡检启用的检查项, nil表示默认注册表中的全部检查项
+type Set struct {
+	checks []Check
+}
+
+// Checks 返回启用的检查项
+func (s *Set) Checks() []Check {
+	if s == nil {
+		return Default.Checks()
+	}
+	return s.checks
+}
+
+// Enabled 判断检查项是否启用
+func (s *Set) Enabled(id string) bool {
+	for _, c := range s.Checks() {
+		if c.ID() == id {
+			return true
+		}
+	}
+	return false
+}
+
+// Evaluate 执行适用于target的检查项, 并为问题补全检查项ID、默认级别、类别和时间, 计算指纹
+func (s *Set) Evaluate(target Target, in *Input) []models.Issue {
+	issues := []models.Issue{}
+	object := in.target(target)
+	for _, c := range s.Checks() {
+		if c.Target() != target {
+			continue
+		}
+		for _, issue := range c.Evaluate(in) {
+			issue.CheckID = c.ID()
+			if issue.Level == "" {
+				issue.Level = c.Severity()
+			}
+			if issue.Category == "" {
+				issue.Category = c.Category()
+			}
+			if issue.Timestamp.IsZero() {
+				issue.Timestamp = time.Now()
+			}
+			issue.Identify(object)
+			issues = append(issues, issue)
+		}
+	}
+	return issues
+}
@@ -0,0 +1,119 @@
+package checks
+
+import (
+	"inspection-tool/pkg/models"
+	"testing"
+)
+
+func newTestRegistry(t *testing.T) *Registry {
+	t.Helper()
+	r := NewRegistry()
+	noop := func(in *Input) []models.Issue { return nil }
+	for _, c := range []Check{
+		New("server.disk.usage", "disk", "critical", TargetServer, noop),
+		New("server.disk.inodes", "disk", "warning", TargetServer, noop),
+		New("server.memory.usage", "memory", "critical", TargetServer, noop),
+		New("k8s.pod.restarts", "pod", "warning", TargetK8s, noop),
+	} {
+		if err := r.Register(c); err != nil {
+			t.Fatalf("Register failed: %v", err)
+		}
+	}
+	return r
+}
+
+func TestRegister(t *testing.T) {
+	r := newTestRegistry(t)
+
+	if err := r.Register(New("server.disk.usage", "disk", "info", TargetServer, nil)); err == nil {
+		t.Error("Expected error for duplicate check id")
+	}
+	if err := r.Register(New("db.replication", "db", "warning", "db", nil)); err == nil {
+		t.Error("Expected error for unknown target")
+	}
+	if err := r.Register(New("server.disk.quota", "disk", "major", TargetServer, nil)); err == nil {
+		t.Error("Expected error for invalid severity")
+	}
+	if len(r.Checks()) != 4 {
+		t.Errorf("Expected 4 checks, got %d", len(r.Checks()))
+	}
+}
+
+func TestSelect(t *testing.T) {
+	r := newTestRegistry(t)
+
+	tests := []struct {
+		enable, skip []string
+		want         []string
+	}{
+		{nil, nil, []string{"server.disk.usage", "server.disk.inodes", "server.memory.usage", "k8s.pod.restarts"}},
+		{[]string{"server.disk"}, nil, []string{"server.disk.usage", "server.disk.inodes"}},
+		{[]string{"server.*.usage"}, nil, []string{"server.disk.usage", "server.memory.usage"}},
+		{nil, []string{"server.disk.inodes", "k8s"}, []string{"server.disk.usage", "server.memory.usage"}},
+		{[]string{"server"}, []string{"server.disk"}, []string{"server.memory.usage"}},
+	}
+
+	for _, tt := range tests {
+		set, err := r.Select(tt.enable, tt.skip)
+		if err != nil {
+			t.Fatalf("Select(%v, %v) failed: %v", tt.enable, tt.skip, err)
+		}
+		var got []string
+		for _, c := range set.Checks() {
+			got = append(got, c.ID())
+		}
+		if len(got) != len(tt.want) {
+			t.Errorf("Select(%v, %v) = %v, want %v", tt.enable, tt.skip, got, tt.want)
+			continue
+		}
+		for i := range got {
+			if got[i] != tt.want[i] {
+				t.Errorf("Select(%v, %v) = %v, want %v", tt.enable, tt.skip, got, tt.want)
+				break
+			}
+		}
+	}
+
+	if _, err := r.Select([]string{"server.dsik"}, nil); err == nil {
+		t.Error("Expected error for pattern matching no check")
+	}
+	if _, err := r.Select(nil, []string{"[bad"}); err == nil {
+		t.Error("Expected error for invalid pattern")
+	}
+}
+
+func TestEvaluate(t *testing.T) {
+	r := NewRegistry()
+	r.Register(New("server.test.default", "test", "warning", TargetServer, func(in *Input) []models.Issue {
+		return []models.Issue{{Message: in.Server.Host}}
+	}))
+	r.Register(New("server.test.override", "test", "warning", TargetServer, func(in *Input) []models.Issue {
+		return []models.Issue{{Level: "critical", Message: "override"}}
+	}))
+	r.Register(New("k8s.test", "test", "warning", TargetK8s, func(in *Input) []models.Issue {
+		return []models.Issue{{Message: "k8s"}}
+	}))
+
+	set, err := r.Select(nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	issues := set.Evaluate(TargetServer, &Input{Server: &models.ServerReport{Host: "web01"}})
+	if len(issues) != 2 {
+		t.Fatalf("Expected 2 server issues, got %+v", issues)
+	}
+
+	first := issues[0]
+	if first.CheckID != "server.test.default" || first.Level != "warning" || first.Category != "test" || first.Timestamp.IsZero() {
+		t.Errorf("Expected defaults to be filled, got %+v", first)
+	}
+	if first.Message != "web01" {
+		t.Errorf("Expected check to read the report, got %q", first.Message)
+	}
+	if issues[1].Level != "critical" || issues[1].CheckID != "server.test.override" {
+		t.Errorf("Expected explicit level to be kept, got %+v", issues[1])
+	}
+	if first.Target != "web01" || first.Fingerprint == "" || first.Fingerprint == issues[1].Fingerprint {
+		t.Errorf("Expected target and distinct fingerprints to be set, got %+v / %+v", first, issues[1])
+	}
+}
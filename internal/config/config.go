@@ -0,0 +1,629 @@
+package config
+
+import (
+	"fmt"
+	"inspection-tool/internal/schedule"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/spf13/viper"
+)
+
+// EnvPrefix 环境变量前缀, 例如 INSPECTION_SERVER_THRESHOLDS_DISK_USAGE_PERCENT=90
+const EnvPrefix = "INSPECTION"
+
+// Config 巡检工具配置
+type Config struct {
+	Server ServerConfig `mapstructure:"server" yaml:"server"`
+	K8s    K8sConfig    `mapstructure:"k8s" yaml:"k8s"`
+	Report ReportConfig `mapstructure:"report" yaml:"report"`
+	Alert  AlertConfig  `mapstructure:"alert" yaml:"alert"`
+	Checks ChecksConfig `mapstructure:"checks" yaml:"checks"`
+	Trend  TrendConfig  `mapstructure:"trend" yaml:"trend"`
+	Daemon DaemonConfig `mapstructure:"daemon" yaml:"daemon"`
+	API    APIConfig    `mapstructure:"api" yaml:"api"`
+
+	// 实际加载的配置文件路径, 未找到配置文件时为空
+	File string `mapstructure:"-" yaml:"-"`
+}
+
+// ServerConfig 服务器巡检配置
+type ServerConfig struct {
+	Timeout     int              `mapstructure:"timeout" yaml:"timeout"`         // SSH连接超时(秒)
+	Interval    int              `mapstructure:"interval" yaml:"interval"`       // 巡检间隔(秒)
+	Concurrency int              `mapstructure:"concurrency" yaml:"concurrency"` // 并发巡检数量
+	Thresholds  ServerThresholds `mapstructure:"thresholds" yaml:"thresholds"`
+
+	// 主机密钥校验策略: strict, tofu, insecure
+	HostKeyPolicy  string `mapstructure:"host_key_policy" yaml:"host_key_policy"`
+	KnownHostsFile string `mapstructure:"known_hosts_file" yaml:"known_hosts_file"` // 为空时使用~/.ssh/known_hosts
+
+	// OpenSSH客户端配置文件, 为空时使用~/.ssh/config
+	SSHConfigFile string `mapstructure:"ssh_config_file" yaml:"ssh_config_file"`
+
+	// 跳板机, 按顺序逐跳连接(ProxyJump), 所有目标主机共享同一条跳板连接
+	JumpHosts []JumpHost `mapstructure:"jump_hosts" yaml:"jump_hosts"`
+}
+
+// JumpHost 跳板机配置, 每一跳使用各自的认证信息
+type JumpHost struct {
+	Host          string   `mapstructure:"host" yaml:"host"`
+	Port          int      `mapstructure:"port" yaml:"port"` // 默认22
+	User          string   `mapstructure:"user" yaml:"user"`
+	Password      string   `mapstructure:"password" yaml:"password"`
+	KeyFiles      []string `mapstructure:"key_files" yaml:"key_files"`
+	KeyPassphrase string   `mapstructure:"key_passphrase" yaml:"key_passphrase"`
+}
+
+// ServerThresholds 服务器巡检阈值
+type ServerThresholds struct {
+	CPU     CPUThresholds     `mapstructure:"cpu" yaml:"cpu"`
+	Memory  MemoryThresholds  `mapstructure:"memory" yaml:"memory"`
+	Disk    DiskThresholds    `mapstructure:"disk" yaml:"disk"`
+	Network NetworkThresholds `mapstructure:"network" yaml:"network"`
+	System  SystemThresholds  `mapstructure:"system" yaml:"system"`
+}
+
+// CPUThresholds CPU阈值
+type CPUThresholds struct {
+	Load1Min          float64 `mapstructure:"load_1min" yaml:"load_1min"`
+	Load5Min          float64 `mapstructure:"load_5min" yaml:"load_5min"`
+	Load15Min         float64 `mapstructure:"load_15min" yaml:"load_15min"`
+	LoadPerCore       float64 `mapstructure:"load_per_core" yaml:"load_per_core"` // 1分钟负载超过核心数的倍数视为严重
+	UsagePercent      float64 `mapstructure:"usage_percent" yaml:"usage_percent"`
+	ContextSwitchRate float64 `mapstructure:"context_switch_rate" yaml:"context_switch_rate"` // 每秒平均上下文切换次数
+}
+
+// MemoryThresholds 内存阈值
+type MemoryThresholds struct {
+	UsagePercent float64 `mapstructure:"usage_percent" yaml:"usage_percent"`
+	AvailableMB  int64   `mapstructure:"available_mb" yaml:"available_mb"`
+	SwapPercent  float64 `mapstructure:"swap_percent" yaml:"swap_percent"`
+}
+
+// DiskThresholds 磁盘阈值
+type DiskThresholds struct {
+	UsagePercent      float64 `mapstructure:"usage_percent" yaml:"usage_percent"`
+	InodeUsagePercent float64 `mapstructure:"inode_usage_percent" yaml:"inode_usage_percent"`
+	IowaitPercent     float64 `mapstructure:"iowait_percent" yaml:"iowait_percent"`
+	IOUtilPercent     float64 `mapstructure:"io_util_percent" yaml:"io_util_percent"`
+}
+
+// NetworkThresholds 网络阈值
+type NetworkThresholds struct {
+	PacketErrorRate float64 `mapstructure:"packet_error_rate" yaml:"packet_error_rate"`
+	RetransmitRate  float64 `mapstructure:"retransmit_rate" yaml:"retransmit_rate"`
+	TimeWait        int     `mapstructure:"time_wait" yaml:"time_wait"`
+}
+
+// SystemThresholds 系统阈值
+type SystemThresholds struct {
+	FileHandleUsagePercent float64 `mapstructure:"file_handle_usage_percent" yaml:"file_handle_usage_percent"`
+	BlockedTasks           int     `mapstructure:"blocked_tasks" yaml:"blocked_tasks"`
+	TimeOffsetSeconds      float64 `mapstructure:"time_offset_seconds" yaml:"time_offset_seconds"`
+}
+
+// K8sConfig Kubernetes巡检配置
+type K8sConfig struct {
+	Kubeconfig string        `mapstructure:"kubeconfig" yaml:"kubeconfig"`
+	Namespaces []string      `mapstructure:"namespaces" yaml:"namespaces"`
+	Interval   int           `mapstructure:"interval" yaml:"interval"` // 检查间隔(秒)
+	Thresholds K8sThresholds `mapstructure:"thresholds" yaml:"thresholds"`
+
+	// SSH巡检节点时的地址优先顺序: InternalIP, ExternalIP, Hostname
+	NodeAddressTypes []string `mapstructure:"node_address_types" yaml:"node_address_types"`
+}
+
+// K8sThresholds Kubernetes巡检阈值
+type K8sThresholds struct {
+	Node NodeThresholds `mapstructure:"node" yaml:"node"`
+	Pod  PodThresholds  `mapstructure:"pod" yaml:"pod"`
+	Etcd EtcdThresholds `mapstructure:"etcd" yaml:"etcd"`
+}
+
+// NodeThresholds 节点阈值
+type NodeThresholds struct {
+	CPUUsagePercent    float64 `mapstructure:"cpu_usage_percent" yaml:"cpu_usage_percent"`
+	MemoryUsagePercent float64 `mapstructure:"memory_usage_percent" yaml:"memory_usage_percent"`
+	DiskUsagePercent   float64 `mapstructure:"disk_usage_percent" yaml:"disk_usage_percent"`
+	PodCountPercent    float64 `mapstructure:"pod_count_percent" yaml:"pod_count_percent"`
+}
+
+// PodThresholds Pod阈值
+type PodThresholds struct {
+	CPUUsagePercent    float64 `mapstructure:"cpu_usage_percent" yaml:"cpu_usage_percent"`       // 相对limit
+	MemoryUsagePercent float64 `mapstructure:"memory_usage_percent" yaml:"memory_usage_percent"` // 相对limit
+	RestartCount       int     `mapstructure:"restart_count" yaml:"restart_count"`
+	PendingSeconds     int64   `mapstructure:"pending_seconds" yaml:"pending_seconds"`
+}
+
+// EtcdThresholds etcd阈值
+type EtcdThresholds struct {
+	DBSizeMB      int64 `mapstructure:"db_size_mb" yaml:"db_size_mb"`
+	LeaderChanges int   `mapstructure:"leader_changes" yaml:"leader_changes"`
+}
+
+// ReportConfig 报告配置
+type ReportConfig struct {
+	Format        string `mapstructure:"format" yaml:"format"`
+	OutputDir     string `mapstructure:"output_dir" yaml:"output_dir"`
+	Detailed      bool   `mapstructure:"detailed" yaml:"detailed"`
+	RetentionDays int    `mapstructure:"retention_days" yaml:"retention_days"`
+}
+
+// ChecksConfig 检查项配置
+type ChecksConfig struct {
+	RulesFile   string `mapstructure:"rules_file" yaml:"rules_file"`     // 自定义规则文件, 为空时不加载
+	WaiversFile string `mapstructure:"waivers_file" yaml:"waivers_file"` // 问题豁免文件, 为空时不加载
+}
+
+// TrendConfig 趋势预测配置, 根据历史记录预测磁盘、inode、内存和节点Pod容量的用满时间
+type TrendConfig struct {
+	WindowDays   int `mapstructure:"window_days" yaml:"window_days"`     // 参与拟合的历史天数
+	MinSamples   int `mapstructure:"min_samples" yaml:"min_samples"`     // 至少需要的巡检次数(含本次)
+	WarningDays  int `mapstructure:"warning_days" yaml:"warning_days"`   // 预计在该天数内用满时生成warning问题
+	CriticalDays int `mapstructure:"critical_days" yaml:"critical_days"` // 预计在该天数内用满时生成critical问题
+}
+
+// DaemonConfig 守护进程配置
+type DaemonConfig struct {
+	MetricsAddr string      `mapstructure:"metrics_addr" yaml:"metrics_addr"` // Prometheus指标接口的监听地址, 为空时不启用
+	Jobs        []DaemonJob `mapstructure:"jobs" yaml:"jobs"`
+}
+
+// DaemonJob 定时执行的巡检任务
+type DaemonJob struct {
+	Name     string   `mapstructure:"name" yaml:"name"`         // 为空时使用命令名和序号
+	Command  string   `mapstructure:"command" yaml:"command"`   // server, k8s, all
+	Schedule string   `mapstructure:"schedule" yaml:"schedule"` // cron表达式或 @every 间隔, 为空时按巡检间隔执行
+	Args     []string `mapstructure:"args" yaml:"args"`         // 命令行参数, 与直接执行命令时相同
+}
+
+// JobSchedule 任务的定时规则, 未配置schedule时按 server.interval 或 k8s.interval 的间隔执行,
+// all 命令取两者中较小的非零值
+func (c *Config) JobSchedule(job DaemonJob) (schedule.Schedule, error) {
+	if job.Schedule != "" {
+		return schedule.Parse(job.Schedule)
+	}
+
+	var interval int
+	switch job.Command {
+	case "server":
+		interval = c.Server.Interval
+	case "k8s":
+		interval = c.K8s.Interval
+	case "all":
+		interval = c.Server.Interval
+		if interval == 0 || (c.K8s.Interval > 0 && c.K8s.Interval < interval) {
+			interval = c.K8s.Interval
+		}
+	}
+	if interval <= 0 {
+		return nil, fmt.Errorf("schedule is required when %s interval is 0", job.Command)
+	}
+	return schedule.Every(time.Duration(interval) * time.Second), nil
+}
+
+// APIConfig HTTP API(inspection-tool serve)配置
+type APIConfig struct {
+	Addr        string `mapstructure:"addr" yaml:"addr"`               // 监听地址
+	Token       string `mapstructure:"token" yaml:"token"`             // 访问令牌, 请求头 Authorization: Bearer <token>
+	Concurrency int    `mapstructure:"concurrency" yaml:"concurrency"` // 同时执行的巡检数量
+	QueueSize   int    `mapstructure:"queue_size" yaml:"queue_size"`   // 等待执行的巡检数量上限, 超过时拒绝新请求
+}
+
+// AlertConfig 告警配置
+type AlertConfig struct {
+	Enabled   bool           `mapstructure:"enabled" yaml:"enabled"`
+	Receivers AlertReceivers `mapstructure:"receivers" yaml:"receivers"`
+}
+
+// AlertReceivers 告警接收者
+type AlertReceivers struct {
+	Webhook WebhookReceiver `mapstructure:"webhook" yaml:"webhook"`
+	Email   EmailReceiver   `mapstructure:"email" yaml:"email"`
+}
+
+// WebhookReceiver Webhook告警
+type WebhookReceiver struct {
+	Enabled bool   `mapstructure:"enabled" yaml:"enabled"`
+	URL     string `mapstructure:"url" yaml:"url"`
+}
+
+// EmailReceiver 邮件告警
+type EmailReceiver struct {
+	Enabled  bool     `mapstructure:"enabled" yaml:"enabled"`
+	SMTPHost string   `mapstructure:"smtp_host" yaml:"smtp_host"`
+	SMTPPort int      `mapstructure:"smtp_port" yaml:"smtp_port"`
+	From     string   `mapstructure:"from" yaml:"from"`
+	To       []string `mapstructure:"to" yaml:"to"`
+}
+
+// Default 返回默认配置(与configs/config.yaml保持一致)
+func Default() *Config {
+	return &Config{
+		Server: ServerConfig{
+			Timeout:       30,
+			Interval:      60,
+			Concurrency:   5,
+			Thresholds:    DefaultServerThresholds(),
+			HostKeyPolicy: "tofu",
+		},
+		K8s: K8sConfig{
+			Kubeconfig:       "~/.kube/config",
+			Namespaces:       []string{},
+			Interval:         60,
+			Thresholds:       DefaultK8sThresholds(),
+			NodeAddressTypes: []string{"InternalIP", "ExternalIP", "Hostname"},
+		},
+		Report: ReportConfig{
+			Format:        "json",
+			OutputDir:     "./reports",
+			Detailed:      true,
+			RetentionDays: 30,
+		},
+		Trend: TrendConfig{
+			WindowDays:   14,
+			MinSamples:   3,
+			WarningDays:  14,
+			CriticalDays: 3,
+		},
+		API: APIConfig{
+			Addr:        ":8080",
+			Concurrency: 2,
+			QueueSize:   100,
+		},
+		Alert: AlertConfig{
+			Receivers: AlertReceivers{
+				Email: EmailReceiver{SMTPPort: 587, To: []string{}},
+			},
+		},
+	}
+}
+
+// DefaultServerThresholds 默认服务器阈值
+func DefaultServerThresholds() ServerThresholds {
+	return ServerThresholds{
+		CPU: CPUThresholds{
+			Load1Min:          8.0,
+			Load5Min:          6.0,
+			Load15Min:         4.0,
+			LoadPerCore:       2.0,
+			UsagePercent:      80.0,
+			ContextSwitchRate: 10000,
+		},
+		Memory: MemoryThresholds{
+			UsagePercent: 85.0,
+			AvailableMB:  1024,
+			SwapPercent:  50.0,
+		},
+		Disk: DiskThresholds{
+			UsagePercent:      85.0,
+			InodeUsagePercent: 80.0,
+			IowaitPercent:     30.0,
+			IOUtilPercent:     80.0,
+		},
+		Network: NetworkThresholds{
+			PacketErrorRate: 0.01,
+			RetransmitRate:  0.05,
+			TimeWait:        10000,
+		},
+		System: SystemThresholds{
+			FileHandleUsagePercent: 80.0,
+			BlockedTasks:           10,
+			TimeOffsetSeconds:      5,
+		},
+	}
+}
+
+// DefaultK8sThresholds 默认Kubernetes阈值
+func DefaultK8sThresholds() K8sThresholds {
+	return K8sThresholds{
+		Node: NodeThresholds{
+			CPUUsagePercent:    80.0,
+			MemoryUsagePercent: 85.0,
+			DiskUsagePercent:   85.0,
+			PodCountPercent:    90.0,
+		},
+		Pod: PodThresholds{
+			CPUUsagePercent:    80.0,
+			MemoryUsagePercent: 80.0,
+			RestartCount:       5,
+			PendingSeconds:     300,
+		},
+		Etcd: EtcdThresholds{
+			DBSizeMB:      8192,
+			LeaderChanges: 3,
+		},
+	}
+}
+
+// Load 加载配置
+// path为空时依次查找 ./configs/config.yaml、./config.yaml、~/.inspection-tool/config.yaml,
+// 都不存在则使用默认配置。环境变量(INSPECTION_前缀)优先于配置文件。
+func Load(path string) (*Config, error) {
+	v := viper.New()
+	setDefaults(v, Default())
+
+	v.SetEnvPrefix(EnvPrefix)
+	v.SetEnvKeyReplacer(strings.NewReplacer(".", "_"))
+	v.AutomaticEnv()
+
+	file := path
+	if file == "" {
+		file = findConfigFile()
+	}
+
+	if file != "" {
+		v.SetConfigFile(file)
+		if err := v.ReadInConfig(); err != nil {
+			return nil, fmt.Errorf("failed to read config %s: %w", file, err)
+		}
+	}
+
+	cfg := &Config{}
+	if err := v.Unmarshal(cfg); err != nil {
+		return nil, fmt.Errorf("failed to parse config: %w", err)
+	}
+	cfg.File = file
+	cfg.K8s.Kubeconfig = ExpandHome(cfg.K8s.Kubeconfig)
+
+	if err := cfg.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid config: %w", err)
+	}
+
+	return cfg, nil
+}
+
+// findConfigFile 查找默认位置的配置文件
+func findConfigFile() string {
+	candidates := []string{
+		filepath.Join("configs", "config.yaml"),
+		"config.yaml",
+	}
+	if homeDir, err := os.UserHomeDir(); err == nil {
+		candidates = append(candidates, filepath.Join(homeDir, ".inspection-tool", "config.yaml"))
+	}
+
+	for _, candidate := range candidates {
+		if info, err := os.Stat(candidate); err == nil && !info.IsDir() {
+			return candidate
+		}
+	}
+	return ""
+}
+
+// setDefaults 注册所有配置项的默认值, 使AutomaticEnv能够覆盖文件中不存在的键
+func setDefaults(v *viper.Viper, cfg *Config) {
+	s := cfg.Server
+	v.SetDefault("server.timeout", s.Timeout)
+	v.SetDefault("server.interval", s.Interval)
+	v.SetDefault("server.concurrency", s.Concurrency)
+	v.SetDefault("server.host_key_policy", s.HostKeyPolicy)
+	v.SetDefault("server.known_hosts_file", s.KnownHostsFile)
+	v.SetDefault("server.ssh_config_file", s.SSHConfigFile)
+	v.SetDefault("server.thresholds.cpu.load_1min", s.Thresholds.CPU.Load1Min)
+	v.SetDefault("server.thresholds.cpu.load_5min", s.Thresholds.CPU.Load5Min)
+	v.SetDefault("server.thresholds.cpu.load_15min", s.Thresholds.CPU.Load15Min)
+	v.SetDefault("server.thresholds.cpu.load_per_core", s.Thresholds.CPU.LoadPerCore)
+	v.SetDefault("server.thresholds.cpu.usage_percent", s.Thresholds.CPU.UsagePercent)
+	v.SetDefault("server.thresholds.cpu.context_switch_rate", s.Thresholds.CPU.ContextSwitchRate)
+	v.SetDefault("server.thresholds.memory.usage_percent", s.Thresholds.Memory.UsagePercent)
+	v.SetDefault("server.thresholds.memory.available_mb", s.Thresholds.Memory.AvailableMB)
+	v.SetDefault("server.thresholds.memory.swap_percent", s.Thresholds.Memory.SwapPercent)
+	v.SetDefault("server.thresholds.disk.usage_percent", s.Thresholds.Disk.UsagePercent)
+	v.SetDefault("server.thresholds.disk.inode_usage_percent", s.Thresholds.Disk.InodeUsagePercent)
+	v.SetDefault("server.thresholds.disk.iowait_percent", s.Thresholds.Disk.IowaitPercent)
+	v.SetDefault("server.thresholds.disk.io_util_percent", s.Thresholds.Disk.IOUtilPercent)
+	v.SetDefault("server.thresholds.network.packet_error_rate", s.Thresholds.Network.PacketErrorRate)
+	v.SetDefault("server.thresholds.network.retransmit_rate", s.Thresholds.Network.RetransmitRate)
+	v.SetDefault("server.thresholds.network.time_wait", s.Thresholds.Network.TimeWait)
+	v.SetDefault("server.thresholds.system.file_handle_usage_percent", s.Thresholds.System.FileHandleUsagePercent)
+	v.SetDefault("server.thresholds.system.blocked_tasks", s.Thresholds.System.BlockedTasks)
+	v.SetDefault("server.thresholds.system.time_offset_seconds", s.Thresholds.System.TimeOffsetSeconds)
+
+	k := cfg.K8s
+	v.SetDefault("k8s.kubeconfig", k.Kubeconfig)
+	v.SetDefault("k8s.namespaces", k.Namespaces)
+	v.SetDefault("k8s.node_address_types", k.NodeAddressTypes)
+	v.SetDefault("k8s.interval", k.Interval)
+	v.SetDefault("k8s.thresholds.node.cpu_usage_percent", k.Thresholds.Node.CPUUsagePercent)
+	v.SetDefault("k8s.thresholds.node.memory_usage_percent", k.Thresholds.Node.MemoryUsagePercent)
+	v.SetDefault("k8s.thresholds.node.disk_usage_percent", k.Thresholds.Node.DiskUsagePercent)
+	v.SetDefault("k8s.thresholds.node.pod_count_percent", k.Thresholds.Node.PodCountPercent)
+	v.SetDefault("k8s.thresholds.pod.cpu_usage_percent", k.Thresholds.Pod.CPUUsagePercent)
+	v.SetDefault("k8s.thresholds.pod.memory_usage_percent", k.Thresholds.Pod.MemoryUsagePercent)
+	v.SetDefault("k8s.thresholds.pod.restart_count", k.Thresholds.Pod.RestartCount)
+	v.SetDefault("k8s.thresholds.pod.pending_seconds", k.Thresholds.Pod.PendingSeconds)
+	v.SetDefault("k8s.thresholds.etcd.db_size_mb", k.Thresholds.Etcd.DBSizeMB)
+	v.SetDefault("k8s.thresholds.etcd.leader_changes", k.Thresholds.Etcd.LeaderChanges)
+
+	r := cfg.Report
+	v.SetDefault("report.format", r.Format)
+	v.SetDefault("report.output_dir", r.OutputDir)
+	v.SetDefault("report.detailed", r.Detailed)
+	v.SetDefault("report.retention_days", r.RetentionDays)
+
+	v.SetDefault("checks.rules_file", cfg.Checks.RulesFile)
+	v.SetDefault("checks.waivers_file", cfg.Checks.WaiversFile)
+
+	t := cfg.Trend
+	v.SetDefault("trend.window_days", t.WindowDays)
+	v.SetDefault("trend.min_samples", t.MinSamples)
+	v.SetDefault("trend.warning_days", t.WarningDays)
+	v.SetDefault("trend.critical_days", t.CriticalDays)
+
+	v.SetDefault("daemon.metrics_addr", cfg.Daemon.MetricsAddr)
+
+	api := cfg.API
+	v.SetDefault("api.addr", api.Addr)
+	v.SetDefault("api.token", api.Token)
+	v.SetDefault("api.concurrency", api.Concurrency)
+	v.SetDefault("api.queue_size", api.QueueSize)
+
+	a := cfg.Alert
+	v.SetDefault("alert.enabled", a.Enabled)
+	v.SetDefault("alert.receivers.webhook.enabled", a.Receivers.Webhook.Enabled)
+	v.SetDefault("alert.receivers.webhook.url", a.Receivers.Webhook.URL)
+	v.SetDefault("alert.receivers.email.enabled", a.Receivers.Email.Enabled)
+	v.SetDefault("alert.receivers.email.smtp_host", a.Receivers.Email.SMTPHost)
+	v.SetDefault("alert.receivers.email.smtp_port", a.Receivers.Email.SMTPPort)
+	v.SetDefault("alert.receivers.email.from", a.Receivers.Email.From)
+	v.SetDefault("alert.receivers.email.to", a.Receivers.Email.To)
+}
+
+// Validate 校验配置
+func (c *Config) Validate() error {
+	var errs []string
+
+	if c.Server.Timeout <= 0 {
+		errs = append(errs, fmt.Sprintf("server.timeout must be positive, got %d", c.Server.Timeout))
+	}
+	if c.Server.Interval < 0 {
+		errs = append(errs, fmt.Sprintf("server.interval must not be negative, got %d", c.Server.Interval))
+	}
+	if c.Server.Concurrency <= 0 {
+		errs = append(errs, fmt.Sprintf("server.concurrency must be positive, got %d", c.Server.Concurrency))
+	}
+	switch c.Server.HostKeyPolicy {
+	case "strict", "tofu", "insecure":
+	default:
+		errs = append(errs, fmt.Sprintf("server.host_key_policy must be strict, tofu or insecure, got %q", c.Server.HostKeyPolicy))
+	}
+	for i, hop := range c.Server.JumpHosts {
+		if hop.Host == "" {
+			errs = append(errs, fmt.Sprintf("server.jump_hosts[%d].host is required", i))
+		}
+		if hop.Port < 0 || hop.Port > 65535 {
+			errs = append(errs, fmt.Sprintf("server.jump_hosts[%d].port must be between 0 and 65535, got %d", i, hop.Port))
+		}
+	}
+	if c.K8s.Interval < 0 {
+		errs = append(errs, fmt.Sprintf("k8s.interval must not be negative, got %d", c.K8s.Interval))
+	}
+	if err := ValidateNodeAddressTypes(c.K8s.NodeAddressTypes); err != nil {
+		errs = append(errs, fmt.Sprintf("k8s.node_address_types: %v", err))
+	}
+
+	st := c.Server.Thresholds
+	percents := map[string]float64{
+		"server.thresholds.cpu.usage_percent":                st.CPU.UsagePercent,
+		"server.thresholds.memory.usage_percent":             st.Memory.UsagePercent,
+		"server.thresholds.memory.swap_percent":              st.Memory.SwapPercent,
+		"server.thresholds.disk.usage_percent":               st.Disk.UsagePercent,
+		"server.thresholds.disk.inode_usage_percent":         st.Disk.InodeUsagePercent,
+		"server.thresholds.disk.iowait_percent":              st.Disk.IowaitPercent,
+		"server.thresholds.disk.io_util_percent":             st.Disk.IOUtilPercent,
+		"server.thresholds.system.file_handle_usage_percent": st.System.FileHandleUsagePercent,
+		"k8s.thresholds.node.cpu_usage_percent":              c.K8s.Thresholds.Node.CPUUsagePercent,
+		"k8s.thresholds.node.memory_usage_percent":           c.K8s.Thresholds.Node.MemoryUsagePercent,
+		"k8s.thresholds.node.disk_usage_percent":             c.K8s.Thresholds.Node.DiskUsagePercent,
+		"k8s.thresholds.node.pod_count_percent":              c.K8s.Thresholds.Node.PodCountPercent,
+		"k8s.thresholds.pod.cpu_usage_percent":               c.K8s.Thresholds.Pod.CPUUsagePercent,
+		"k8s.thresholds.pod.memory_usage_percent":            c.K8s.Thresholds.Pod.MemoryUsagePercent,
+	}
+	for key, val := range percents {
+		if val < 0 || val > 100 {
+			errs = append(errs, fmt.Sprintf("%s must be between 0 and 100, got %.2f", key, val))
+		}
+	}
+
+	nonNegative := map[string]float64{
+		"server.thresholds.cpu.load_1min":              st.CPU.Load1Min,
+		"server.thresholds.cpu.load_5min":              st.CPU.Load5Min,
+		"server.thresholds.cpu.load_15min":             st.CPU.Load15Min,
+		"server.thresholds.cpu.load_per_core":          st.CPU.LoadPerCore,
+		"server.thresholds.cpu.context_switch_rate":    st.CPU.ContextSwitchRate,
+		"server.thresholds.memory.available_mb":        float64(st.Memory.AvailableMB),
+		"server.thresholds.network.packet_error_rate":  st.Network.PacketErrorRate,
+		"server.thresholds.network.retransmit_rate":    st.Network.RetransmitRate,
+		"server.thresholds.network.time_wait":          float64(st.Network.TimeWait),
+		"server.thresholds.system.blocked_tasks":       float64(st.System.BlockedTasks),
+		"server.thresholds.system.time_offset_seconds": st.System.TimeOffsetSeconds,
+		"k8s.thresholds.pod.restart_count":             float64(c.K8s.Thresholds.Pod.RestartCount),
+		"k8s.thresholds.pod.pending_seconds":           float64(c.K8s.Thresholds.Pod.PendingSeconds),
+		"k8s.thresholds.etcd.db_size_mb":               float64(c.K8s.Thresholds.Etcd.DBSizeMB),
+		"k8s.thresholds.etcd.leader_changes":           float64(c.K8s.Thresholds.Etcd.LeaderChanges),
+		"report.retention_days":                        float64(c.Report.RetentionDays),
+		"trend.window_days":                            float64(c.Trend.WindowDays),
+		"trend.warning_days":                           float64(c.Trend.WarningDays),
+		"trend.critical_days":                          float64(c.Trend.CriticalDays),
+		"alert.receivers.email.smtp_port":              float64(c.Alert.Receivers.Email.SMTPPort),
+	}
+	for key, val := range nonNegative {
+		if val < 0 {
+			errs = append(errs, fmt.Sprintf("%s must not be negative, got %v", key, val))
+		}
+	}
+
+	if c.Trend.MinSamples < 2 {
+		errs = append(errs, fmt.Sprintf("trend.min_samples must be at least 2, got %d", c.Trend.MinSamples))
+	}
+	if c.Trend.CriticalDays > c.Trend.WarningDays {
+		errs = append(errs, fmt.Sprintf("trend.critical_days (%d) must not exceed trend.warning_days (%d)", c.Trend.CriticalDays, c.Trend.WarningDays))
+	}
+
+	if c.API.Concurrency < 1 {
+		errs = append(errs, fmt.Sprintf("api.concurrency must be at least 1, got %d", c.API.Concurrency))
+	}
+	if c.API.QueueSize < 1 {
+		errs = append(errs, fmt.Sprintf("api.queue_size must be at least 1, got %d", c.API.QueueSize))
+	}
+
+	names := make(map[string]bool)
+	for i, job := range c.Daemon.Jobs {
+		switch job.Command {
+		case "server", "k8s", "all":
+		default:
+			errs = append(errs, fmt.Sprintf("daemon.jobs[%d].command must be server, k8s or all, got %q", i, job.Command))
+			continue
+		}
+		if _, err := c.JobSchedule(job); err != nil {
+			errs = append(errs, fmt.Sprintf("daemon.jobs[%d]: %v", i, err))
+		}
+		if job.Name != "" {
+			if names[job.Name] {
+				errs = append(errs, fmt.Sprintf("daemon.jobs[%d].name %q is duplicated", i, job.Name))
+			}
+			names[job.Name] = true
+		}
+	}
+
+	switch c.Report.Format {
+	case "json", "yaml", "html", "markdown", "text", "csv", "xlsx", "junit", "sarif":
+	default:
+		errs = append(errs, fmt.Sprintf("report.format %q is not supported", c.Report.Format))
+	}
+
+	if len(errs) > 0 {
+		// 排序保证错误信息稳定
+		sort.Strings(errs)
+		return fmt.Errorf("%s", strings.Join(errs, "; "))
+	}
+	return nil
+}
+
+// ValidateNodeAddressTypes 校验节点地址类型列表
+func ValidateNodeAddressTypes(types []string) error {
+	for _, t := range types {
+		switch t {
+		case "InternalIP", "ExternalIP", "Hostname":
+		default:
+			return fmt.Errorf("unknown node address type %q, must be InternalIP, ExternalIP or Hostname", t)
+		}
+	}
+	return nil
+}
+
+// ExpandHome 展开路径中的 ~ 前缀
+func ExpandHome(path string) string {
+	if path == "~" || strings.HasPrefix(path, "~/") {
+		if homeDir, err := os.UserHomeDir(); err == nil {
+			return filepath.Join(homeDir, strings.TrimPrefix(path, "~"))
+		}
+	}
+	return path
+}
@@ -0,0 +1,178 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestDefaultValid(t *testing.T) {
+	if err := Default().Validate(); err != nil {
+		t.Errorf("Expected default config to be valid, got %v", err)
+	}
+}
+
+func TestLoadFile(t *testing.T) {
+	tmpDir := t.TempDir()
+	path := filepath.Join(tmpDir, "config.yaml")
+	content := `server:
+  timeout: 10
+  thresholds:
+    disk:
+      usage_percent: 92.5
+  jump_hosts:
+    - host: bastion.example.com
+      user: jump
+      key_files: ["~/.ssh/bastion"]
+k8s:
+  thresholds:
+    pod:
+      restart_count: 20
+daemon:
+  jobs:
+    - name: nightly
+      command: all
+      schedule: "0 2 * * *"
+      args: ["--inventory", "hosts.yaml", "--format", "html"]
+`
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	cfg, err := Load(path)
+	if err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+
+	if cfg.File != path {
+		t.Errorf("Expected file '%s', got '%s'", path, cfg.File)
+	}
+
+	if cfg.Server.Timeout != 10 {
+		t.Errorf("Expected timeout 10, got %d", cfg.Server.Timeout)
+	}
+
+	if cfg.Server.Thresholds.Disk.UsagePercent != 92.5 {
+		t.Errorf("Expected disk usage 92.5, got %.2f", cfg.Server.Thresholds.Disk.UsagePercent)
+	}
+
+	if cfg.K8s.Thresholds.Pod.RestartCount != 20 {
+		t.Errorf("Expected restart count 20, got %d", cfg.K8s.Thresholds.Pod.RestartCount)
+	}
+
+	if len(cfg.Server.JumpHosts) != 1 || cfg.Server.JumpHosts[0].Host != "bastion.example.com" ||
+		cfg.Server.JumpHosts[0].User != "jump" || len(cfg.Server.JumpHosts[0].KeyFiles) != 1 {
+		t.Errorf("Unexpected jump hosts: %+v", cfg.Server.JumpHosts)
+	}
+
+	if len(cfg.Daemon.Jobs) != 1 || cfg.Daemon.Jobs[0].Command != "all" || len(cfg.Daemon.Jobs[0].Args) != 4 {
+		t.Errorf("Unexpected daemon jobs: %+v", cfg.Daemon.Jobs)
+	}
+
+	// 未配置的键应保留默认值
+	if cfg.Server.Thresholds.Memory.UsagePercent != 85.0 {
+		t.Errorf("Expected default memory usage 85, got %.2f", cfg.Server.Thresholds.Memory.UsagePercent)
+	}
+}
+
+func TestLoadEnvOverride(t *testing.T) {
+	tmpDir := t.TempDir()
+	path := filepath.Join(tmpDir, "config.yaml")
+	if err := os.WriteFile(path, []byte("server:\n  concurrency: 3\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	t.Setenv("INSPECTION_SERVER_CONCURRENCY", "8")
+	t.Setenv("INSPECTION_K8S_THRESHOLDS_NODE_CPU_USAGE_PERCENT", "70")
+
+	cfg, err := Load(path)
+	if err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+
+	if cfg.Server.Concurrency != 8 {
+		t.Errorf("Expected concurrency 8 from env, got %d", cfg.Server.Concurrency)
+	}
+
+	if cfg.K8s.Thresholds.Node.CPUUsagePercent != 70 {
+		t.Errorf("Expected node cpu threshold 70 from env, got %.2f", cfg.K8s.Thresholds.Node.CPUUsagePercent)
+	}
+}
+
+func TestLoadMissingFile(t *testing.T) {
+	_, err := Load(filepath.Join(t.TempDir(), "missing.yaml"))
+	if err == nil {
+		t.Error("Expected error for missing config file")
+	}
+}
+
+func TestValidate(t *testing.T) {
+	cfg := Default()
+	cfg.Server.Thresholds.Disk.UsagePercent = 120
+	cfg.Server.Concurrency = 0
+	cfg.Report.Format = "xml"
+	cfg.Server.JumpHosts = []JumpHost{{Port: 22}}
+	cfg.Trend.CriticalDays = 30
+	cfg.Daemon.Jobs = []DaemonJob{{Command: "server", Schedule: "61 * * * *"}}
+
+	err := cfg.Validate()
+	if err == nil {
+		t.Fatal("Expected validation error")
+	}
+
+	for _, key := range []string{"server.thresholds.disk.usage_percent", "server.concurrency", "report.format", "server.jump_hosts[0].host", "trend.critical_days", "daemon.jobs[0]"} {
+		if !strings.Contains(err.Error(), key) {
+			t.Errorf("Expected error to mention '%s', got: %v", key, err)
+		}
+	}
+}
+
+func TestJobSchedule(t *testing.T) {
+	cfg := Default()
+	cfg.Server.Interval = 300
+	cfg.K8s.Interval = 600
+	now := time.Date(2024, 1, 1, 10, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		job  DaemonJob
+		next time.Time
+	}{
+		{DaemonJob{Command: "server"}, now.Add(5 * time.Minute)},
+		{DaemonJob{Command: "k8s"}, now.Add(10 * time.Minute)},
+		{DaemonJob{Command: "all"}, now.Add(5 * time.Minute)},
+		{DaemonJob{Command: "all", Schedule: "@every 1h"}, now.Add(time.Hour)},
+	}
+	for _, tt := range tests {
+		s, err := cfg.JobSchedule(tt.job)
+		if err != nil {
+			t.Errorf("%+v: %v", tt.job, err)
+			continue
+		}
+		if next := s.Next(now); !next.Equal(tt.next) {
+			t.Errorf("%+v: next = %v, want %v", tt.job, next, tt.next)
+		}
+	}
+
+	// 未配置schedule且间隔为0时无法调度
+	cfg.Server.Interval = 0
+	if _, err := cfg.JobSchedule(DaemonJob{Command: "server"}); err == nil {
+		t.Error("Expected error without schedule and interval")
+	}
+}
+
+func TestExpandHome(t *testing.T) {
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		t.Skip("no home directory")
+	}
+
+	if got := ExpandHome("~/.kube/config"); got != filepath.Join(homeDir, ".kube", "config") {
+		t.Errorf("ExpandHome(~/.kube/config) = %s", got)
+	}
+
+	if got := ExpandHome("/etc/kubeconfig"); got != "/etc/kubeconfig" {
+		t.Errorf("ExpandHome(/etc/kubeconfig) = %s", got)
+	}
+}
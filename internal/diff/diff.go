@@ -0,0 +1,351 @@
+package diff
+
+import (
+	"fmt"
+	"inspection-tool/pkg/models"
+	"math"
+	"sort"
+	"time"
+)
+
+// DefaultMinChange 百分比指标默认的显著变化阈值(百分点)
+const DefaultMinChange = 5.0
+
+// Result 两次巡检的差异
+type Result struct {
+	Old RunInfo `json:"old"`
+	New RunInfo `json:"new"`
+
+	NewIssues        []IssueChange `json:"new_issues"`        // 只在新报告中出现的问题
+	ResolvedIssues   []IssueChange `json:"resolved_issues"`   // 只在旧报告中出现的问题
+	PersistingIssues []IssueChange `json:"persisting_issues"` // 两次都出现的问题
+
+	Metrics []MetricDelta `json:"metrics"` // 显著变化的指标
+	Added   []Resource    `json:"added"`   // 新增的服务器、挂载点、节点和Pod
+	Removed []Resource    `json:"removed"` // 消失的服务器、挂载点、节点和Pod
+	States  []StateChange `json:"states"`  // 服务器状态和节点就绪状态的变化
+}
+
+// RunInfo 参与比较的报告
+type RunInfo struct {
+	Timestamp time.Time `json:"timestamp"`
+	Type      string    `json:"type"`
+}
+
+// IssueChange 按指纹比较的问题, 持续存在的问题记录旧的级别
+type IssueChange struct {
+	models.Issue
+	OldLevel models.Severity `json:"old_level,omitempty"`
+}
+
+// MetricDelta 指标变化
+type MetricDelta struct {
+	Target   string  `json:"target"`             // 服务器地址或集群API地址
+	Resource string  `json:"resource,omitempty"` // 挂载点、节点或Pod, 为空时为整个巡检对象
+	Metric   string  `json:"metric"`
+	Old      float64 `json:"old"`
+	New      float64 `json:"new"`
+	Delta    float64 `json:"delta"`
+	Unit     string  `json:"unit,omitempty"`
+}
+
+// Resource 新增或消失的资源
+type Resource struct {
+	Kind   string `json:"kind"` // server, mount, node, pod
+	Target string `json:"target"`
+	Name   string `json:"name"`
+}
+
+// StateChange 状态变化
+type StateChange struct {
+	Kind   string `json:"kind"` // server, node
+	Target string `json:"target"`
+	Name   string `json:"name"`
+	Old    string `json:"old"`
+	New    string `json:"new"`
+}
+
+// 资源类型
+const (
+	KindServer = "server"
+	KindMount  = "mount"
+	KindNode   = "node"
+	KindPod    = "pod"
+)
+
+// Options 比较选项
+type Options struct {
+	// MinChange 百分比指标的显著变化阈值(百分点), 计数类指标有变化即输出
+	MinChange float64
+}
+
+// Compare 比较两次巡检的报告
+func Compare(oldReport, newReport *models.InspectionReport, opts Options) *Result {
+	if opts.MinChange <= 0 {
+		opts.MinChange = DefaultMinChange
+	}
+	c := &comparer{
+		opts: opts,
+		result: &Result{
+			Old: RunInfo{Timestamp: oldReport.Timestamp, Type: oldReport.Type},
+			New: RunInfo{Timestamp: newReport.Timestamp, Type: newReport.Type},
+		},
+	}
+
+	oldKeys, oldIssues := reportIssues(oldReport)
+	newKeys, newIssues := reportIssues(newReport)
+	c.compareIssues(oldKeys, oldIssues, newKeys, newIssues)
+	c.compareServers(oldReport.Servers(), newReport.Servers())
+	c.compareK8s(oldReport.K8sReport, newReport.K8sReport)
+	return c.result
+}
+
+// comparer 比较过程的状态
+type comparer struct {
+	opts   Options
+	result *Result
+}
+
+// reportIssues 报告中的全部问题, 以指纹为键; 旧版本报告中没有指纹的问题在此计算
+func reportIssues(report *models.InspectionReport) ([]string, map[string]models.Issue) {
+	var keys []string
+	issues := make(map[string]models.Issue)
+	add := func(target string, list []models.Issue) {
+		for _, issue := range list {
+			if issue.Fingerprint == "" {
+				issue.Identify(target)
+			}
+			if _, ok := issues[issue.Fingerprint]; ok {
+				continue
+			}
+			keys = append(keys, issue.Fingerprint)
+			issues[issue.Fingerprint] = issue
+		}
+	}
+	for _, sr := range report.Servers() {
+		add(sr.Host, sr.Issues)
+	}
+	if report.K8sReport != nil {
+		add(report.K8sReport.Target(), report.K8sReport.Issues)
+	}
+	return keys, issues
+}
+
+// compareIssues 按指纹比较问题
+func (c *comparer) compareIssues(oldKeys []string, oldIssues map[string]models.Issue, newKeys []string, newIssues map[string]models.Issue) {
+	for _, key := range newKeys {
+		issue := newIssues[key]
+		if old, ok := oldIssues[key]; ok {
+			change := IssueChange{Issue: issue}
+			if old.Level != issue.Level {
+				change.OldLevel = old.Level
+			}
+			c.result.PersistingIssues = append(c.result.PersistingIssues, change)
+			continue
+		}
+		c.result.NewIssues = append(c.result.NewIssues, IssueChange{Issue: issue})
+	}
+	for _, key := range oldKeys {
+		if _, ok := newIssues[key]; !ok {
+			c.result.ResolvedIssues = append(c.result.ResolvedIssues, IssueChange{Issue: oldIssues[key]})
+		}
+	}
+
+	for _, list := range [][]IssueChange{c.result.NewIssues, c.result.ResolvedIssues, c.result.PersistingIssues} {
+		sort.SliceStable(list, func(i, j int) bool {
+			return list[i].Level.Rank() > list[j].Level.Rank()
+		})
+	}
+}
+
+// compareServers 比较服务器: 增减、状态和资源指标
+func (c *comparer) compareServers(oldServers, newServers []*models.ServerReport) {
+	oldByHost := make(map[string]*models.ServerReport)
+	for _, sr := range oldServers {
+		oldByHost[sr.Host] = sr
+	}
+	newByHost := make(map[string]*models.ServerReport)
+	for _, sr := range newServers {
+		newByHost[sr.Host] = sr
+		old, ok := oldByHost[sr.Host]
+		if !ok {
+			c.added(KindServer, sr.Host, sr.Host)
+			continue
+		}
+		if old.Status != sr.Status {
+			c.state(KindServer, sr.Host, sr.Host, old.Status, sr.Status)
+		}
+		// 未采集到指标的一方没有可比较的指标
+		if old.Failed() || sr.Failed() {
+			continue
+		}
+
+		// 部分采集步骤失败时, 只比较两次都采集到的指标
+		if collected(old, sr, "cpu.usage") {
+			c.percent(sr.Host, "", "cpu.usage_percent", old.CPU.UsagePercent, sr.CPU.UsagePercent)
+		}
+		if collected(old, sr, "memory.meminfo") {
+			c.percent(sr.Host, "", "memory.usage_percent", old.Memory.UsagePercent, sr.Memory.UsagePercent)
+			c.percent(sr.Host, "", "memory.swap_percent", old.Memory.SwapPercent, sr.Memory.SwapPercent)
+		}
+		if collected(old, sr, "system.file_handles") {
+			c.percent(sr.Host, "", "system.file_handles_percent", old.System.FileHandlesPercent, sr.System.FileHandlesPercent)
+		}
+		c.compareDisks(sr.Host, old, sr)
+	}
+	for _, sr := range oldServers {
+		if _, ok := newByHost[sr.Host]; !ok {
+			c.removed(KindServer, sr.Host, sr.Host)
+		}
+	}
+}
+
+// collected 两次巡检都采集到了该指标
+func collected(oldServer, newServer *models.ServerReport, metric string) bool {
+	return oldServer.Collected(metric) && newServer.Collected(metric)
+}
+
+// compareDisks 按挂载点比较磁盘
+func (c *comparer) compareDisks(host string, oldServer, newServer *models.ServerReport) {
+	usage := collected(oldServer, newServer, "disk.usage")
+	inodes := collected(oldServer, newServer, "disk.inodes")
+	oldByMount := make(map[string]models.DiskMetrics)
+	for _, d := range oldServer.Disk {
+		oldByMount[d.MountPoint] = d
+	}
+	newByMount := make(map[string]bool)
+	for _, d := range newServer.Disk {
+		newByMount[d.MountPoint] = true
+		old, ok := oldByMount[d.MountPoint]
+		if !ok {
+			c.added(KindMount, host, d.MountPoint)
+			continue
+		}
+		if usage && c.percent(host, d.MountPoint, "disk.usage_percent", old.UsagePercent, d.UsagePercent) {
+			c.metric(host, d.MountPoint, "disk.used_gb", old.UsedGB, d.UsedGB, "GB")
+		}
+		if inodes {
+			c.percent(host, d.MountPoint, "disk.inodes_percent", old.InodesPercent, d.InodesPercent)
+		}
+	}
+	for _, d := range oldServer.Disk {
+		if !newByMount[d.MountPoint] {
+			c.removed(KindMount, host, d.MountPoint)
+		}
+	}
+}
+
+// compareK8s 比较集群: 节点和Pod的增减、节点就绪状态、资源使用率和Pod重启次数
+func (c *comparer) compareK8s(oldReport, newReport *models.K8sReport) {
+	if oldReport == nil || newReport == nil {
+		return
+	}
+	k8sTarget := newReport.Target()
+
+	c.count(k8sTarget, "", "cluster.node_count", oldReport.ClusterInfo.NodeCount, newReport.ClusterInfo.NodeCount)
+	c.count(k8sTarget, "", "cluster.pod_count", oldReport.ClusterInfo.PodCount, newReport.ClusterInfo.PodCount)
+
+	oldNodes := make(map[string]models.NodeMetrics)
+	for _, n := range oldReport.Nodes {
+		oldNodes[n.Name] = n
+	}
+	newNodes := make(map[string]bool)
+	for _, n := range newReport.Nodes {
+		newNodes[n.Name] = true
+		old, ok := oldNodes[n.Name]
+		if !ok {
+			c.added(KindNode, k8sTarget, n.Name)
+			continue
+		}
+		if old.Ready != n.Ready {
+			c.state(KindNode, k8sTarget, n.Name, readiness(old.Ready), readiness(n.Ready))
+		}
+		c.percent(k8sTarget, n.Name, "node.cpu_percent", old.CPUPercent, n.CPUPercent)
+		c.percent(k8sTarget, n.Name, "node.memory_percent", old.MemoryPercent, n.MemoryPercent)
+		c.percent(k8sTarget, n.Name, "node.pod_percent", old.PodPercent, n.PodPercent)
+		c.count(k8sTarget, n.Name, "node.pod_count", old.PodCount, n.PodCount)
+	}
+	for _, n := range oldReport.Nodes {
+		if !newNodes[n.Name] {
+			c.removed(KindNode, k8sTarget, n.Name)
+		}
+	}
+
+	oldPods := make(map[string]models.PodMetrics)
+	for _, p := range oldReport.Pods {
+		oldPods[podName(p)] = p
+	}
+	newPods := make(map[string]bool)
+	for _, p := range newReport.Pods {
+		name := podName(p)
+		newPods[name] = true
+		old, ok := oldPods[name]
+		if !ok {
+			c.added(KindPod, k8sTarget, name)
+			continue
+		}
+		c.count(k8sTarget, name, "pod.restart_count", old.RestartCount, p.RestartCount)
+	}
+	for _, p := range oldReport.Pods {
+		if name := podName(p); !newPods[name] {
+			c.removed(KindPod, k8sTarget, name)
+		}
+	}
+}
+
+// percent 记录变化不小于阈值的百分比指标, 返回是否记录
+func (c *comparer) percent(target, resource, metric string, before, after float64) bool {
+	if math.Abs(after-before) < c.opts.MinChange {
+		return false
+	}
+	c.metric(target, resource, metric, before, after, "%")
+	return true
+}
+
+// count 记录有变化的计数类指标
+func (c *comparer) count(target, resource, metric string, before, after int) {
+	if before != after {
+		c.metric(target, resource, metric, float64(before), float64(after), "")
+	}
+}
+
+func (c *comparer) metric(target, resource, metric string, before, after float64, unit string) {
+	c.result.Metrics = append(c.result.Metrics, MetricDelta{
+		Target:   target,
+		Resource: resource,
+		Metric:   metric,
+		Old:      before,
+		New:      after,
+		Delta:    math.Round((after-before)*100) / 100,
+		Unit:     unit,
+	})
+}
+
+func (c *comparer) added(kind, target, name string) {
+	c.result.Added = append(c.result.Added, Resource{Kind: kind, Target: target, Name: name})
+}
+
+func (c *comparer) removed(kind, target, name string) {
+	c.result.Removed = append(c.result.Removed, Resource{Kind: kind, Target: target, Name: name})
+}
+
+func (c *comparer) state(kind, target, name, before, after string) {
+	c.result.States = append(c.result.States, StateChange{Kind: kind, Target: target, Name: name, Old: before, New: after})
+}
+
+// Empty 判断两次巡检是否没有差异(持续存在的问题不算差异)
+func (r *Result) Empty() bool {
+	return len(r.NewIssues) == 0 && len(r.ResolvedIssues) == 0 && len(r.Metrics) == 0 &&
+		len(r.Added) == 0 && len(r.Removed) == 0 && len(r.States) == 0
+}
+
+func readiness(ready bool) string {
+	if ready {
+		return "Ready"
+	}
+	return "NotReady"
+}
+
+func podName(p models.PodMetrics) string {
+	return fmt.Sprintf("%s/%s", p.Namespace, p.Name)
+}
@@ -0,0 +1,238 @@
+package diff
+
+import (
+	"bytes"
+	"encoding/json"
+	"inspection-tool/pkg/models"
+	"strings"
+	"testing"
+	"time"
+)
+
+// diffTestReports 一天前后的两份完整报告
+func diffTestReports() (*models.InspectionReport, *models.InspectionReport) {
+	day := time.Date(2024, 1, 1, 2, 0, 0, 0, time.UTC)
+	diskIssue := models.Issue{CheckID: "disk.usage", Level: models.SeverityWarning, Category: "disk",
+		Message: "磁盘 /data 使用率 82%", ResourceKind: models.ResourceDisk, ResourceName: "/data"}
+	memIssue := models.Issue{CheckID: "memory.usage", Level: models.SeverityWarning, Category: "memory", Message: "内存使用率过高"}
+
+	oldReport := &models.InspectionReport{
+		Timestamp: day,
+		Type:      "all",
+		ServerReports: []*models.ServerReport{
+			{
+				Host:   "10.0.0.1",
+				Status: models.HostStatusWarning,
+				Memory: models.MemoryMetrics{UsagePercent: 85},
+				Disk: []models.DiskMetrics{
+					{MountPoint: "/", UsagePercent: 40, UsedGB: 40},
+					{MountPoint: "/data", UsagePercent: 82, UsedGB: 820},
+					{MountPoint: "/mnt/old", UsagePercent: 10},
+				},
+				Issues: []models.Issue{diskIssue, memIssue},
+			},
+			{Host: "10.0.0.2", Status: models.HostStatusHealthy},
+		},
+		K8sReport: &models.K8sReport{
+			ClusterInfo: models.ClusterInfo{Server: "https://10.0.0.10:6443", NodeCount: 2, PodCount: 2},
+			Nodes: []models.NodeMetrics{
+				{Name: "node-1", Ready: true, PodCount: 1},
+				{Name: "node-2", Ready: true, PodCount: 1},
+			},
+			Pods: []models.PodMetrics{
+				{Name: "web-0", Namespace: "default", RestartCount: 1},
+				{Name: "job-1", Namespace: "batch"},
+			},
+		},
+	}
+
+	diskIssue.Level = models.SeverityCritical
+	diskIssue.Message = "磁盘 /data 使用率 93%"
+	newReport := &models.InspectionReport{
+		Timestamp: day.Add(24 * time.Hour),
+		Type:      "all",
+		ServerReports: []*models.ServerReport{
+			{
+				Host:   "10.0.0.1",
+				Status: models.HostStatusCritical,
+				Memory: models.MemoryMetrics{UsagePercent: 60},
+				Disk: []models.DiskMetrics{
+					{MountPoint: "/", UsagePercent: 42, UsedGB: 42},
+					{MountPoint: "/data", UsagePercent: 93, UsedGB: 930},
+				},
+				Issues: []models.Issue{diskIssue},
+			},
+			{
+				Host:   "10.0.0.2",
+				Status: models.HostStatusUnreachable,
+				Issues: []models.Issue{{Level: models.SeverityCritical, Category: "connection", Message: "连接失败"}},
+			},
+			{Host: "10.0.0.3", Status: models.HostStatusHealthy},
+		},
+		K8sReport: &models.K8sReport{
+			ClusterInfo: models.ClusterInfo{Server: "https://10.0.0.10:6443", NodeCount: 2, PodCount: 2},
+			Nodes: []models.NodeMetrics{
+				{Name: "node-1", Ready: false, PodCount: 1},
+				{Name: "node-3", Ready: true, PodCount: 1},
+			},
+			Pods: []models.PodMetrics{
+				{Name: "web-0", Namespace: "default", RestartCount: 4},
+				{Name: "api-0", Namespace: "default"},
+			},
+		},
+	}
+	return oldReport, newReport
+}
+
+func TestCompareIssues(t *testing.T) {
+	oldReport, newReport := diffTestReports()
+	r := Compare(oldReport, newReport, Options{})
+
+	if len(r.NewIssues) != 1 || r.NewIssues[0].Category != "connection" || r.NewIssues[0].Target != "10.0.0.2" {
+		t.Errorf("unexpected new issues: %+v", r.NewIssues)
+	}
+	if len(r.ResolvedIssues) != 1 || r.ResolvedIssues[0].CheckID != "memory.usage" {
+		t.Errorf("unexpected resolved issues: %+v", r.ResolvedIssues)
+	}
+	if len(r.PersistingIssues) != 1 {
+		t.Fatalf("unexpected persisting issues: %+v", r.PersistingIssues)
+	}
+	persisting := r.PersistingIssues[0]
+	if persisting.Level != models.SeverityCritical || persisting.OldLevel != models.SeverityWarning {
+		t.Errorf("persisting issue should record level change, got %s→%s", persisting.OldLevel, persisting.Level)
+	}
+	if persisting.Message != "磁盘 /data 使用率 93%" {
+		t.Errorf("persisting issue should use the new message, got %q", persisting.Message)
+	}
+}
+
+func TestCompareMetricsAndResources(t *testing.T) {
+	oldReport, newReport := diffTestReports()
+	r := Compare(oldReport, newReport, Options{})
+
+	metrics := make(map[string]MetricDelta)
+	for _, m := range r.Metrics {
+		metrics[m.Target+" "+m.Resource+" "+m.Metric] = m
+	}
+	if m, ok := metrics["10.0.0.1 /data disk.usage_percent"]; !ok || m.Delta != 11 {
+		t.Errorf("disk growth not reported: %+v", m)
+	}
+	if m, ok := metrics["10.0.0.1 /data disk.used_gb"]; !ok || m.Delta != 110 || m.Unit != "GB" {
+		t.Errorf("disk used_gb not reported with usage change: %+v", m)
+	}
+	if m, ok := metrics["10.0.0.1  memory.usage_percent"]; !ok || m.Delta != -25 {
+		t.Errorf("memory drop not reported: %+v", m)
+	}
+	// 变化小于阈值的指标不输出
+	if _, ok := metrics["10.0.0.1 / disk.usage_percent"]; ok {
+		t.Error("disk change below min change should be ignored")
+	}
+	if m, ok := metrics["https://10.0.0.10:6443 default/web-0 pod.restart_count"]; !ok || m.Delta != 3 {
+		t.Errorf("pod restarts not reported: %+v", m)
+	}
+
+	// 阈值调低后小幅变化也输出
+	r2 := Compare(oldReport, newReport, Options{MinChange: 1})
+	found := false
+	for _, m := range r2.Metrics {
+		if m.Resource == "/" && m.Metric == "disk.usage_percent" {
+			found = true
+		}
+	}
+	if !found {
+		t.Error("disk change above lowered min change should be reported")
+	}
+
+	var added, removed []string
+	for _, res := range r.Added {
+		added = append(added, res.Kind+":"+res.Name)
+	}
+	for _, res := range r.Removed {
+		removed = append(removed, res.Kind+":"+res.Name)
+	}
+	if got := strings.Join(added, ","); got != "server:10.0.0.3,node:node-3,pod:default/api-0" {
+		t.Errorf("unexpected added resources: %s", got)
+	}
+	if got := strings.Join(removed, ","); got != "mount:/mnt/old,node:node-2,pod:batch/job-1" {
+		t.Errorf("unexpected removed resources: %s", got)
+	}
+
+	var states []string
+	for _, s := range r.States {
+		states = append(states, s.Kind+":"+s.Name+":"+s.Old+"→"+s.New)
+	}
+	want := "server:10.0.0.1:warning→critical,server:10.0.0.2:healthy→unreachable,node:node-1:Ready→NotReady"
+	if got := strings.Join(states, ","); got != want {
+		t.Errorf("unexpected state changes: %s", got)
+	}
+}
+
+func TestCompareUncollectedMetrics(t *testing.T) {
+	oldReport, newReport := diffTestReports()
+	// 新报告中内存和磁盘使用率的采集失败, 采集失败的值为0, 不能当作变化
+	sr := newReport.ServerReports[0]
+	sr.Memory = models.MemoryMetrics{}
+	sr.Collectors = []models.CollectorStatus{
+		{Name: "memory", Status: models.CollectorFailed},
+		{Name: "disk", Status: models.CollectorDegraded, Missing: []string{"disk.usage"}},
+	}
+	r := Compare(oldReport, newReport, Options{})
+
+	for _, m := range r.Metrics {
+		if m.Target == "10.0.0.1" && (strings.HasPrefix(m.Metric, "memory.") || m.Metric == "disk.usage_percent" || m.Metric == "disk.used_gb") {
+			t.Errorf("uncollected metric should not be compared: %+v", m)
+		}
+	}
+}
+
+func TestCompareIdentical(t *testing.T) {
+	oldReport, _ := diffTestReports()
+	r := Compare(oldReport, oldReport, Options{})
+	if !r.Empty() {
+		t.Errorf("identical reports should have no difference: %+v", r)
+	}
+	if len(r.PersistingIssues) != 2 {
+		t.Errorf("expected 2 persisting issues, got %d", len(r.PersistingIssues))
+	}
+}
+
+func TestWrite(t *testing.T) {
+	oldReport, newReport := diffTestReports()
+	r := Compare(oldReport, newReport, Options{})
+
+	var text bytes.Buffer
+	if err := Write(&text, r, "text"); err != nil {
+		t.Fatalf("Write text failed: %v", err)
+	}
+	for _, want := range []string{"新增问题: 1, 已解决: 1, 持续存在: 1", "[warning→critical] 10.0.0.1 disk", "10.0.0.1 /data disk.usage_percent: 82.00% → 93.00% (+11.00)", "node https://10.0.0.10:6443 node-1: Ready → NotReady"} {
+		if !strings.Contains(text.String(), want) {
+			t.Errorf("text output missing %q:\n%s", want, text.String())
+		}
+	}
+
+	var md bytes.Buffer
+	if err := Write(&md, r, "markdown"); err != nil {
+		t.Fatalf("Write markdown failed: %v", err)
+	}
+	for _, want := range []string{"# 巡检差异", "## 新增问题", "| 消失 | mount | 10.0.0.1 /mnt/old |", "| https://10.0.0.10:6443 default/web-0 | pod.restart_count | 1 | 4 | +3 |"} {
+		if !strings.Contains(md.String(), want) {
+			t.Errorf("markdown output missing %q:\n%s", want, md.String())
+		}
+	}
+
+	var js bytes.Buffer
+	if err := Write(&js, r, "json"); err != nil {
+		t.Fatalf("Write json failed: %v", err)
+	}
+	var decoded Result
+	if err := json.Unmarshal(js.Bytes(), &decoded); err != nil {
+		t.Fatalf("invalid json output: %v", err)
+	}
+	if len(decoded.NewIssues) != 1 || decoded.PersistingIssues[0].OldLevel != models.SeverityWarning {
+		t.Errorf("json output lost issue changes: %+v", decoded)
+	}
+
+	if err := Write(&js, r, "html"); err == nil {
+		t.Error("expected error for unsupported format")
+	}
+}
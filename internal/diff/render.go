@@ -0,0 +1,197 @@
+package diff
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// Formats 支持的输出格式
+var Formats = []string{"text", "markdown", "json"}
+
+// Write 按格式输出差异
+func Write(w io.Writer, r *Result, format string) error {
+	switch format {
+	case "text":
+		writeText(w, r)
+		return nil
+	case "markdown":
+		writeMarkdown(w, r)
+		return nil
+	case "json":
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(r)
+	default:
+		return fmt.Errorf("unsupported format: %s", format)
+	}
+}
+
+// writeText 输出纯文本差异, 风格与巡检摘要一致
+func writeText(w io.Writer, r *Result) {
+	fmt.Fprintln(w, "========================================")
+	fmt.Fprintln(w, "巡检差异")
+	fmt.Fprintln(w, "========================================")
+	fmt.Fprintf(w, "旧报告: %s (%s)\n", r.Old.Timestamp.Format("2006-01-02 15:04:05"), r.Old.Type)
+	fmt.Fprintf(w, "新报告: %s (%s)\n", r.New.Timestamp.Format("2006-01-02 15:04:05"), r.New.Type)
+	fmt.Fprintf(w, "新增问题: %d, 已解决: %d, 持续存在: %d\n", len(r.NewIssues), len(r.ResolvedIssues), len(r.PersistingIssues))
+	fmt.Fprintln(w, "========================================")
+
+	for _, section := range []struct {
+		title  string
+		issues []IssueChange
+	}{
+		{"新增问题", r.NewIssues},
+		{"已解决问题", r.ResolvedIssues},
+		{"持续存在的问题", r.PersistingIssues},
+	} {
+		if len(section.issues) == 0 {
+			continue
+		}
+		fmt.Fprintf(w, "\n%s:\n", section.title)
+		for _, issue := range section.issues {
+			fmt.Fprintf(w, "  [%s] %s %s: %s\n", issueLevel(issue), issue.Target, issue.Category, issue.Message)
+		}
+	}
+
+	if len(r.Metrics) > 0 {
+		fmt.Fprintln(w, "\n指标变化:")
+		for _, m := range r.Metrics {
+			fmt.Fprintf(w, "  %s %s: %s → %s (%s)\n", location(m.Target, m.Resource), m.Metric,
+				formatValue(m.Old, m.Unit), formatValue(m.New, m.Unit), formatDelta(m.Delta, m.Unit))
+		}
+	}
+
+	if len(r.States) > 0 {
+		fmt.Fprintln(w, "\n状态变化:")
+		for _, s := range r.States {
+			fmt.Fprintf(w, "  %s %s: %s → %s\n", s.Kind, location(s.Target, s.Name), s.Old, s.New)
+		}
+	}
+
+	for _, section := range []struct {
+		title     string
+		resources []Resource
+	}{
+		{"新增资源", r.Added},
+		{"消失资源", r.Removed},
+	} {
+		if len(section.resources) == 0 {
+			continue
+		}
+		fmt.Fprintf(w, "\n%s:\n", section.title)
+		for _, res := range section.resources {
+			fmt.Fprintf(w, "  %s %s\n", res.Kind, location(res.Target, res.Name))
+		}
+	}
+
+	if r.Empty() {
+		fmt.Fprintln(w, "\n没有变化")
+	}
+	fmt.Fprintln(w, "========================================")
+}
+
+// writeMarkdown 输出Markdown差异
+func writeMarkdown(w io.Writer, r *Result) {
+	fmt.Fprintln(w, "# 巡检差异")
+	fmt.Fprintln(w)
+	fmt.Fprintf(w, "- 旧报告: %s (%s)\n", r.Old.Timestamp.Format("2006-01-02 15:04:05"), r.Old.Type)
+	fmt.Fprintf(w, "- 新报告: %s (%s)\n", r.New.Timestamp.Format("2006-01-02 15:04:05"), r.New.Type)
+	fmt.Fprintf(w, "- 新增问题: %d, 已解决: %d, 持续存在: %d\n", len(r.NewIssues), len(r.ResolvedIssues), len(r.PersistingIssues))
+
+	for _, section := range []struct {
+		title  string
+		issues []IssueChange
+	}{
+		{"新增问题", r.NewIssues},
+		{"已解决问题", r.ResolvedIssues},
+		{"持续存在的问题", r.PersistingIssues},
+	} {
+		if len(section.issues) == 0 {
+			continue
+		}
+		fmt.Fprintf(w, "\n## %s\n\n", section.title)
+		fmt.Fprintln(w, "| 级别 | 对象 | 类别 | 问题 |")
+		fmt.Fprintln(w, "| --- | --- | --- | --- |")
+		for _, issue := range section.issues {
+			fmt.Fprintf(w, "| %s | %s | %s | %s |\n", issueLevel(issue), cell(issue.Target), cell(issue.Category), cell(issue.Message))
+		}
+	}
+
+	if len(r.Metrics) > 0 {
+		fmt.Fprintln(w, "\n## 指标变化")
+		fmt.Fprintln(w)
+		fmt.Fprintln(w, "| 对象 | 指标 | 旧值 | 新值 | 变化 |")
+		fmt.Fprintln(w, "| --- | --- | ---: | ---: | ---: |")
+		for _, m := range r.Metrics {
+			fmt.Fprintf(w, "| %s | %s | %s | %s | %s |\n", cell(location(m.Target, m.Resource)), m.Metric,
+				formatValue(m.Old, m.Unit), formatValue(m.New, m.Unit), formatDelta(m.Delta, m.Unit))
+		}
+	}
+
+	if len(r.States) > 0 {
+		fmt.Fprintln(w, "\n## 状态变化")
+		fmt.Fprintln(w)
+		fmt.Fprintln(w, "| 类型 | 对象 | 旧状态 | 新状态 |")
+		fmt.Fprintln(w, "| --- | --- | --- | --- |")
+		for _, s := range r.States {
+			fmt.Fprintf(w, "| %s | %s | %s | %s |\n", s.Kind, cell(location(s.Target, s.Name)), s.Old, s.New)
+		}
+	}
+
+	if len(r.Added) > 0 || len(r.Removed) > 0 {
+		fmt.Fprintln(w, "\n## 资源增减")
+		fmt.Fprintln(w)
+		fmt.Fprintln(w, "| 变化 | 类型 | 对象 |")
+		fmt.Fprintln(w, "| --- | --- | --- |")
+		for _, res := range r.Added {
+			fmt.Fprintf(w, "| 新增 | %s | %s |\n", res.Kind, cell(location(res.Target, res.Name)))
+		}
+		for _, res := range r.Removed {
+			fmt.Fprintf(w, "| 消失 | %s | %s |\n", res.Kind, cell(location(res.Target, res.Name)))
+		}
+	}
+
+	if r.Empty() {
+		fmt.Fprintln(w, "\n没有变化")
+	}
+}
+
+// issueLevel 问题级别, 级别有变化时显示旧级别
+func issueLevel(issue IssueChange) string {
+	if issue.OldLevel != "" {
+		return fmt.Sprintf("%s→%s", issue.OldLevel, issue.Level)
+	}
+	return string(issue.Level)
+}
+
+// location 巡检对象和其中的资源, 服务器本身的变化只显示地址
+func location(target, name string) string {
+	if name == "" || name == target {
+		return target
+	}
+	return target + " " + name
+}
+
+// formatValue 百分比和容量保留两位小数, 计数类指标显示整数
+func formatValue(v float64, unit string) string {
+	if unit == "" {
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	}
+	return fmt.Sprintf("%.2f", v) + unit
+}
+
+func formatDelta(d float64, unit string) string {
+	if unit == "" {
+		return fmt.Sprintf("%+g", d)
+	}
+	return fmt.Sprintf("%+.2f", d)
+}
+
+// cell 转义Markdown表格单元格
+func cell(s string) string {
+	s = strings.ReplaceAll(s, "|", "\\|")
+	return strings.ReplaceAll(s, "\n", "<br>")
+}
@@ -0,0 +1,291 @@
+package history
+
+import (
+	"encoding/json"
+	"fmt"
+	"inspection-tool/pkg/models"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+)
+
+// DirName 历史记录在报告输出目录下的子目录, CleanupOldReports只清理文件, 不会删除该目录
+const DirName = "history"
+
+// Run 一次巡检(一个报告文件)的索引信息
+type Run struct {
+	ID        string    `json:"id"`
+	Timestamp time.Time `json:"timestamp"`
+	Type      string    `json:"type"`             // server, k8s, all
+	Report    string    `json:"report,omitempty"` // 对应的报告文件
+	Targets   []string  `json:"targets"`          // 服务器地址和集群API地址
+	Critical  int       `json:"critical"`
+	Warning   int       `json:"warning"`
+	Info      int       `json:"info"`
+	Waived    int       `json:"waived"`
+	Failed    []string  `json:"failed,omitempty"` // 未采集到指标的服务器
+}
+
+// Sample 一次巡检中的一个指标值
+type Sample struct {
+	Target   string  `json:"target"`
+	Resource string  `json:"resource,omitempty"` // 挂载点、节点, 为空时为整个巡检对象
+	Metric   string  `json:"metric"`
+	Value    float64 `json:"value"`
+}
+
+// IssueRecord 一次巡检中的一个问题
+type IssueRecord struct {
+	Fingerprint string          `json:"fingerprint"`
+	CheckID     string          `json:"check_id,omitempty"`
+	Level       models.Severity `json:"level"`
+	Category    string          `json:"category"`
+	Target      string          `json:"target"`
+	Resource    string          `json:"resource,omitempty"` // 挂载点、节点、namespace/pod等
+	Message     string          `json:"message"`
+	Suppressed  bool            `json:"suppressed,omitempty"`
+}
+
+// Record 历史记录文件的内容
+type Record struct {
+	Run
+	Samples []Sample      `json:"samples"`
+	Issues  []IssueRecord `json:"issues"`
+}
+
+// Store 报告输出目录下的历史记录, 按巡检日期分目录保存, 每次巡检一个json文件,
+// 每个日期目录中的 runs.jsonl 逐行追加巡检的索引信息, 列出巡检时只读取索引
+// 文件只新增和按保留期整目录删除, 多个进程同时写入不会互相覆盖
+type Store struct {
+	dir string
+}
+
+// indexFile 日期目录中的巡检索引, 每行一个Run
+const indexFile = "runs.jsonl"
+
+// dayLayout 日期目录名的格式, 与记录ID开头的日期一致
+const dayLayout = "20060102"
+
+// Open 打开报告输出目录下的历史记录, 目录不存在时创建
+func Open(outputDir string) (*Store, error) {
+	dir := filepath.Join(outputDir, DirName)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, fmt.Errorf("failed to create history directory: %w", err)
+	}
+	return &Store{dir: dir}, nil
+}
+
+// Dir 历史记录目录
+func (s *Store) Dir() string {
+	return s.dir
+}
+
+// Add 索引一次巡检的报告, reportFile为保存的报告文件
+func (s *Store) Add(report *models.InspectionReport, reportFile string) (*Run, error) {
+	record := NewRecord(report)
+	record.Report = reportFile
+
+	dayDir := filepath.Join(s.dir, record.Timestamp.Format(dayLayout))
+	if err := os.MkdirAll(dayDir, 0755); err != nil {
+		return nil, fmt.Errorf("failed to create history directory: %w", err)
+	}
+
+	base := runID(record)
+	for i := 1; ; i++ {
+		record.ID = base
+		if i > 1 {
+			record.ID = fmt.Sprintf("%s_%d", base, i)
+		}
+		f, err := os.OpenFile(s.path(record.ID), os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0644)
+		if os.IsExist(err) {
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to create history record: %w", err)
+		}
+		data, err := json.Marshal(record)
+		if err == nil {
+			_, err = f.Write(data)
+		}
+		if cerr := f.Close(); err == nil {
+			err = cerr
+		}
+		if err == nil {
+			err = appendIndex(dayDir, &record.Run)
+		}
+		if err != nil {
+			os.Remove(f.Name())
+			return nil, fmt.Errorf("failed to write history record: %w", err)
+		}
+		return &record.Run, nil
+	}
+}
+
+// appendIndex 在日期目录的索引末尾追加一行, 一次写入整行, 并发追加的行不会交错
+func appendIndex(dayDir string, run *Run) error {
+	line, err := json.Marshal(run)
+	if err != nil {
+		return err
+	}
+	f, err := os.OpenFile(filepath.Join(dayDir, indexFile), os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0644)
+	if err != nil {
+		return err
+	}
+	_, err = f.Write(append(line, '\n'))
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	return err
+}
+
+// days 按时间顺序返回不早于since的日期目录名, since为零值时返回全部
+func (s *Store) days(since time.Time) ([]string, error) {
+	entries, err := os.ReadDir(s.dir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read history directory: %w", err)
+	}
+	first := ""
+	if !since.IsZero() {
+		first = since.Format(dayLayout)
+	}
+	var days []string
+	for _, entry := range entries {
+		if !entry.IsDir() || !isDay(entry.Name()) || entry.Name() < first {
+			continue
+		}
+		days = append(days, entry.Name())
+	}
+	return days, nil
+}
+
+func isDay(name string) bool {
+	_, err := time.Parse(dayLayout, name)
+	return err == nil
+}
+
+// Records 按时间顺序返回全部历史记录, 无法解析的文件跳过
+func (s *Store) Records() ([]*Record, error) {
+	return s.RecordsSince(time.Time{})
+}
+
+// RecordsSince 按时间顺序返回不早于since的历史记录, 只读取对应日期目录中的文件
+func (s *Store) RecordsSince(since time.Time) ([]*Record, error) {
+	days, err := s.days(since)
+	if err != nil {
+		return nil, err
+	}
+
+	var records []*Record
+	for _, day := range days {
+		entries, err := os.ReadDir(filepath.Join(s.dir, day))
+		if err != nil {
+			continue
+		}
+		for _, entry := range entries {
+			if entry.IsDir() || filepath.Ext(entry.Name()) != ".json" {
+				continue
+			}
+			data, err := os.ReadFile(filepath.Join(s.dir, day, entry.Name()))
+			if err != nil {
+				continue
+			}
+			record := &Record{}
+			if err := json.Unmarshal(data, record); err != nil || record.Timestamp.Before(since) {
+				continue
+			}
+			// 以文件名为准
+			record.ID = strings.TrimSuffix(entry.Name(), ".json")
+			records = append(records, record)
+		}
+	}
+
+	sort.SliceStable(records, func(i, j int) bool {
+		return before(&records[i].Run, &records[j].Run)
+	})
+	return records, nil
+}
+
+// Runs 按时间顺序返回全部巡检, 只读取各日期目录中的索引
+func (s *Store) Runs() ([]Run, error) {
+	days, err := s.days(time.Time{})
+	if err != nil {
+		return nil, err
+	}
+
+	var runs []Run
+	for _, day := range days {
+		data, err := os.ReadFile(filepath.Join(s.dir, day, indexFile))
+		if err != nil {
+			continue
+		}
+		for _, line := range strings.Split(string(data), "\n") {
+			var run Run
+			if line == "" || json.Unmarshal([]byte(line), &run) != nil {
+				continue
+			}
+			runs = append(runs, run)
+		}
+	}
+
+	sort.SliceStable(runs, func(i, j int) bool {
+		return before(&runs[i], &runs[j])
+	})
+	return runs, nil
+}
+
+func before(a, b *Run) bool {
+	if !a.Timestamp.Equal(b.Timestamp) {
+		return a.Timestamp.Before(b.Timestamp)
+	}
+	return a.ID < b.ID
+}
+
+// Prune 按目录名删除早于保留天数的日期目录, 不读取其中的记录, 返回删除的记录数量;
+// 按天删除, 保留期开始的那一天整天保留; retentionDays不大于0时保留全部记录
+func (s *Store) Prune(retentionDays int, now time.Time) (int, error) {
+	if retentionDays <= 0 {
+		return 0, nil
+	}
+	days, err := s.days(time.Time{})
+	if err != nil {
+		return 0, err
+	}
+
+	cutoff := now.AddDate(0, 0, -retentionDays).Format(dayLayout)
+	removed := 0
+	for _, day := range days {
+		if day >= cutoff {
+			break
+		}
+		dayDir := filepath.Join(s.dir, day)
+		entries, _ := os.ReadDir(dayDir)
+		if err := os.RemoveAll(dayDir); err != nil {
+			return removed, fmt.Errorf("failed to remove history records: %w", err)
+		}
+		for _, entry := range entries {
+			if filepath.Ext(entry.Name()) == ".json" {
+				removed++
+			}
+		}
+	}
+	return removed, nil
+}
+
+// path 记录文件位于记录ID开头的日期对应的目录中
+func (s *Store) path(id string) string {
+	return filepath.Join(s.dir, id[:len(dayLayout)], id+".json")
+}
+
+var unsafeChars = regexp.MustCompile(`[^A-Za-z0-9._-]+`)
+
+// runID 记录ID: 时间和类型, 单台服务器的巡检再加上服务器地址
+func runID(record *Record) string {
+	id := record.Timestamp.Format("20060102_150405") + "_" + record.Type
+	if record.Type == "server" && len(record.Targets) == 1 {
+		id += "_" + unsafeChars.ReplaceAllString(record.Targets[0], "-")
+	}
+	return id
+}
@@ -0,0 +1,177 @@
+package history
+
+import (
+	"inspection-tool/pkg/models"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// historyTestReport 一台服务器在某天的巡检报告, dataUsage为/data的使用率
+func historyTestReport(day int, dataUsage float64, issues ...models.Issue) *models.InspectionReport {
+	return &models.InspectionReport{
+		Timestamp: time.Date(2024, 1, day, 2, 0, 0, 0, time.UTC),
+		Type:      "server",
+		ServerReport: &models.ServerReport{
+			Host:   "10.0.0.1",
+			Status: models.HostStatusHealthy,
+			Memory: models.MemoryMetrics{UsagePercent: 50},
+			Disk: []models.DiskMetrics{
+				{MountPoint: "/", UsagePercent: 40, TotalGB: 100, UsedGB: 40},
+				{MountPoint: "/data", UsagePercent: dataUsage, TotalGB: 1000, UsedGB: dataUsage * 10},
+			},
+			Issues: issues,
+		},
+	}
+}
+
+var diskIssue = models.Issue{CheckID: "server.disk.usage", Level: models.SeverityWarning, Category: "disk",
+	Message: "磁盘使用率过高", ResourceKind: models.ResourceDisk, ResourceName: "/data"}
+
+func TestNewRecord(t *testing.T) {
+	report := historyTestReport(1, 80, diskIssue, models.Issue{Level: models.SeverityInfo, Category: "waiver", Suppressed: true})
+	report.ServerReport.Collectors = []models.CollectorStatus{{Name: "memory", Status: models.CollectorFailed}}
+	report.ServerReports = []*models.ServerReport{{Host: "10.0.0.2", Status: models.HostStatusUnreachable,
+		Issues: []models.Issue{{Level: models.SeverityCritical, Category: "connection"}}}}
+
+	record := NewRecord(report)
+	if record.Warning != 1 || record.Critical != 1 || record.Waived != 1 {
+		t.Errorf("unexpected issue counts: critical=%d warning=%d waived=%d", record.Critical, record.Warning, record.Waived)
+	}
+	if len(record.Failed) != 1 || record.Failed[0] != "10.0.0.2" {
+		t.Errorf("unexpected failed hosts: %v", record.Failed)
+	}
+	for _, s := range record.Samples {
+		if s.Metric == MetricMemoryUsage {
+			t.Error("metrics of a failed collector should not be recorded")
+		}
+		if s.Target == "10.0.0.2" {
+			t.Error("failed host should have no samples")
+		}
+	}
+	if record.Issues[0].Fingerprint == "" || record.Issues[0].Resource != "/data" {
+		t.Errorf("issue not identified: %+v", record.Issues[0])
+	}
+}
+
+func TestStore(t *testing.T) {
+	dir := t.TempDir()
+	store, err := Open(dir)
+	if err != nil {
+		t.Fatalf("Open failed: %v", err)
+	}
+
+	// 乱序写入, 同一时间的两次巡检不会互相覆盖
+	for _, report := range []*models.InspectionReport{
+		historyTestReport(3, 90, diskIssue),
+		historyTestReport(1, 80),
+		historyTestReport(2, 85, diskIssue),
+		historyTestReport(2, 85, diskIssue),
+	} {
+		if _, err := store.Add(report, "report.json"); err != nil {
+			t.Fatalf("Add failed: %v", err)
+		}
+	}
+
+	runs, err := store.Runs()
+	if err != nil {
+		t.Fatalf("Runs failed: %v", err)
+	}
+	if len(runs) != 4 {
+		t.Fatalf("expected 4 runs, got %d", len(runs))
+	}
+	if runs[0].ID != "20240101_020000_server_10.0.0.1" || runs[2].ID != "20240102_020000_server_10.0.0.1_2" {
+		t.Errorf("unexpected run ids: %s, %s", runs[0].ID, runs[2].ID)
+	}
+
+	if _, err := os.Stat(filepath.Join(store.Dir(), "20240102", runs[2].ID+".json")); err != nil {
+		t.Errorf("expected record in its day directory: %v", err)
+	}
+
+	// 列出巡检只读取索引, 无法解析的记录文件不影响; 读取记录时跳过
+	os.WriteFile(filepath.Join(store.Dir(), "20240103", "broken.json"), []byte("{"), 0644)
+	if runs, _ := store.Runs(); len(runs) != 4 {
+		t.Errorf("expected 4 runs from the index, got %d", len(runs))
+	}
+	records, err := store.RecordsSince(time.Date(2024, 1, 2, 0, 0, 0, 0, time.UTC))
+	if err != nil {
+		t.Fatalf("RecordsSince failed: %v", err)
+	}
+	if len(records) != 3 || records[0].ID != "20240102_020000_server_10.0.0.1" || len(records[2].Samples) == 0 {
+		t.Errorf("unexpected records since day 2: %d", len(records))
+	}
+	if records, _ := store.RecordsSince(time.Date(2024, 1, 2, 3, 0, 0, 0, time.UTC)); len(records) != 1 {
+		t.Errorf("expected records before since to be skipped, got %d", len(records))
+	}
+
+	// 按天删除, 保留期开始的那一天整天保留
+	removed, err := store.Prune(30, time.Date(2024, 2, 2, 12, 0, 0, 0, time.UTC))
+	if err != nil {
+		t.Fatalf("Prune failed: %v", err)
+	}
+	if removed != 3 {
+		t.Errorf("expected 3 records removed, got %d", removed)
+	}
+	runs, _ = store.Runs()
+	if len(runs) != 1 || runs[0].Timestamp.Day() != 3 {
+		t.Errorf("unexpected runs after prune: %+v", runs)
+	}
+
+	if removed, _ := store.Prune(0, time.Now()); removed != 0 {
+		t.Error("retention 0 should keep all records")
+	}
+}
+
+func TestMetricSeries(t *testing.T) {
+	var records []*Record
+	for day, usage := range []float64{80, 85, 90} {
+		record := NewRecord(historyTestReport(day+1, usage))
+		record.ID = record.Timestamp.Format("20060102")
+		records = append(records, record)
+	}
+
+	series := MetricSeries(records, "10.0.0.1", MetricDiskUsage)
+	if len(series) != 2 {
+		t.Fatalf("expected series for 2 mounts, got %d", len(series))
+	}
+	data := series[1]
+	if data.Resource != "/data" || len(data.Points) != 3 || data.Latest().Value != 90 || data.Points[0].RunID != "20240101" {
+		t.Errorf("unexpected /data series: %+v", data)
+	}
+
+	// 前缀匹配
+	if got := len(MetricSeries(records, "10.0.0.1", "disk")); got != 6 {
+		t.Errorf("expected 6 disk series, got %d", got)
+	}
+	if got := len(MetricSeries(records, "10.0.0.9", "")); got != 0 {
+		t.Errorf("unknown host should have no series, got %d", got)
+	}
+}
+
+func TestIssueHistories(t *testing.T) {
+	memIssue := models.Issue{CheckID: "server.memory.usage", Level: models.SeverityCritical, Category: "memory", Message: "内存使用率过高"}
+	escalated := diskIssue
+	escalated.Level = models.SeverityCritical
+	records := []*Record{
+		NewRecord(historyTestReport(1, 80, memIssue)),
+		NewRecord(historyTestReport(2, 85, diskIssue)),
+		NewRecord(historyTestReport(3, 90, escalated)),
+	}
+
+	histories := IssueHistories(records)
+	if len(histories) != 2 {
+		t.Fatalf("expected 2 issues, got %d", len(histories))
+	}
+
+	mem, disk := histories[0], histories[1]
+	if mem.CheckID != "server.memory.usage" || mem.Active || mem.Runs != 1 {
+		t.Errorf("memory issue should be resolved: %+v", mem)
+	}
+	if disk.FirstSeen.Day() != 2 || disk.LastSeen.Day() != 3 || disk.Runs != 2 || !disk.Active {
+		t.Errorf("unexpected disk issue history: %+v", disk)
+	}
+	if disk.Level != models.SeverityCritical {
+		t.Errorf("issue history should keep the latest level, got %s", disk.Level)
+	}
+}
@@ -0,0 +1,108 @@
+package history
+
+import (
+	"sort"
+	"strings"
+	"time"
+)
+
+// Point 指标序列中的一个值
+type Point struct {
+	Timestamp time.Time `json:"timestamp"`
+	RunID     string    `json:"run_id"`
+	Value     float64   `json:"value"`
+}
+
+// Series 巡检对象上一个资源的指标序列
+type Series struct {
+	Target   string  `json:"target"`
+	Resource string  `json:"resource,omitempty"`
+	Metric   string  `json:"metric"`
+	Points   []Point `json:"points"`
+}
+
+// Latest 序列中最新的值
+func (s *Series) Latest() Point {
+	return s.Points[len(s.Points)-1]
+}
+
+// MetricSeries 巡检对象的指标序列, 按指标和资源排序
+// metric为空时返回全部指标, 否则按指标名或前缀匹配(如 disk 匹配 disk.usage_percent)
+func MetricSeries(records []*Record, target, metric string) []*Series {
+	index := make(map[string]*Series)
+	var all []*Series
+	for _, record := range records {
+		for _, s := range record.Samples {
+			if s.Target != target || !matchMetric(s.Metric, metric) {
+				continue
+			}
+			key := s.Metric + "\x00" + s.Resource
+			series, ok := index[key]
+			if !ok {
+				series = &Series{Target: s.Target, Resource: s.Resource, Metric: s.Metric}
+				index[key] = series
+				all = append(all, series)
+			}
+			series.Points = append(series.Points, Point{Timestamp: record.Timestamp, RunID: record.ID, Value: s.Value})
+		}
+	}
+
+	sort.SliceStable(all, func(i, j int) bool {
+		if all[i].Metric != all[j].Metric {
+			return all[i].Metric < all[j].Metric
+		}
+		return all[i].Resource < all[j].Resource
+	})
+	return all
+}
+
+func matchMetric(name, pattern string) bool {
+	return pattern == "" || name == pattern || strings.HasPrefix(name, pattern+".")
+}
+
+// IssueHistory 同一指纹的问题在历次巡检中的出现情况
+type IssueHistory struct {
+	IssueRecord           // 最近一次出现时的内容
+	FirstSeen   time.Time `json:"first_seen"`
+	LastSeen    time.Time `json:"last_seen"`
+	Runs        int       `json:"runs"`   // 出现的巡检次数
+	Active      bool      `json:"active"` // 是否出现在该巡检对象最近一次的巡检中
+}
+
+// IssueHistories 按指纹汇总问题, 按首次出现时间排序
+func IssueHistories(records []*Record) []*IssueHistory {
+	// 每个巡检对象最近一次巡检的时间, 用于判断问题是否仍然存在
+	lastRun := make(map[string]time.Time)
+	index := make(map[string]*IssueHistory)
+	var all []*IssueHistory
+	for _, record := range records {
+		for _, target := range record.Targets {
+			lastRun[target] = record.Timestamp
+		}
+		seen := make(map[string]bool)
+		for _, issue := range record.Issues {
+			if seen[issue.Fingerprint] {
+				continue
+			}
+			seen[issue.Fingerprint] = true
+
+			h, ok := index[issue.Fingerprint]
+			if !ok {
+				h = &IssueHistory{FirstSeen: record.Timestamp}
+				index[issue.Fingerprint] = h
+				all = append(all, h)
+			}
+			h.IssueRecord = issue
+			h.LastSeen = record.Timestamp
+			h.Runs++
+		}
+	}
+
+	for _, h := range all {
+		h.Active = !h.LastSeen.Before(lastRun[h.Target])
+	}
+	sort.SliceStable(all, func(i, j int) bool {
+		return all[i].FirstSeen.Before(all[j].FirstSeen)
+	})
+	return all
+}
@@ -0,0 +1,136 @@
+package history
+
+import (
+	"inspection-tool/pkg/models"
+)
+
+// 记录的指标, 名称为 "部分.字段" 形式, 与报告中的JSON字段一致
+const (
+	MetricCPUUsage        = "cpu.usage_percent"
+	MetricLoad1           = "cpu.load_1min"
+	MetricMemoryUsage     = "memory.usage_percent"
+	MetricSwapUsage       = "memory.swap_percent"
+	MetricFileHandles     = "system.file_handles_percent"
+	MetricDiskUsage       = "disk.usage_percent" // 资源为挂载点
+	MetricDiskUsedGB      = "disk.used_gb"
+	MetricDiskTotalGB     = "disk.total_gb"
+	MetricInodesUsage     = "disk.inodes_percent"
+	MetricNodeCount       = "cluster.node_count"
+	MetricPodCount        = "cluster.pod_count"
+	MetricNodeReady       = "node.ready" // 资源为节点, 1为Ready
+	MetricNodeCPU         = "node.cpu_percent"
+	MetricNodeMemory      = "node.memory_percent"
+	MetricNodePods        = "node.pod_count"
+	MetricNodePodsPercent = "node.pod_percent"
+)
+
+// NewRecord 从报告中提取问题统计、指标和问题
+func NewRecord(report *models.InspectionReport) *Record {
+	record := &Record{Run: Run{Timestamp: report.Timestamp, Type: report.Type, Targets: []string{}}}
+
+	for _, sr := range report.Servers() {
+		record.Targets = append(record.Targets, sr.Host)
+		record.addIssues(sr.Host, sr.Issues)
+		if sr.Failed() {
+			record.Failed = append(record.Failed, sr.Host)
+			continue
+		}
+		record.addServerSamples(sr)
+	}
+	if kr := report.K8sReport; kr != nil {
+		record.Targets = append(record.Targets, kr.Target())
+		record.addIssues(kr.Target(), kr.Issues)
+		record.addK8sSamples(kr)
+	}
+	return record
+}
+
+// addServerSamples 记录服务器指标, 未采集的指标不记录, 以免零值被当作实际值
+func (r *Record) addServerSamples(sr *models.ServerReport) {
+	host := sr.Host
+	if sr.Collected("cpu.usage") {
+		r.sample(host, "", MetricCPUUsage, sr.CPU.UsagePercent)
+	}
+	if sr.Collected("cpu.load") {
+		r.sample(host, "", MetricLoad1, sr.CPU.Load1)
+	}
+	if sr.Collected("memory.meminfo") {
+		r.sample(host, "", MetricMemoryUsage, sr.Memory.UsagePercent)
+		r.sample(host, "", MetricSwapUsage, sr.Memory.SwapPercent)
+	}
+	if sr.Collected("system.file_handles") {
+		r.sample(host, "", MetricFileHandles, sr.System.FileHandlesPercent)
+	}
+	for _, d := range sr.Disk {
+		if sr.Collected("disk.usage") {
+			r.sample(host, d.MountPoint, MetricDiskUsage, d.UsagePercent)
+			r.sample(host, d.MountPoint, MetricDiskUsedGB, d.UsedGB)
+			r.sample(host, d.MountPoint, MetricDiskTotalGB, d.TotalGB)
+		}
+		if sr.Collected("disk.inodes") && d.InodesTotal > 0 {
+			r.sample(host, d.MountPoint, MetricInodesUsage, d.InodesPercent)
+		}
+	}
+}
+
+// addK8sSamples 记录集群和节点指标
+func (r *Record) addK8sSamples(kr *models.K8sReport) {
+	target := kr.Target()
+	r.sample(target, "", MetricNodeCount, float64(kr.ClusterInfo.NodeCount))
+	r.sample(target, "", MetricPodCount, float64(kr.ClusterInfo.PodCount))
+	for _, n := range kr.Nodes {
+		ready := 0.0
+		if n.Ready {
+			ready = 1
+		}
+		r.sample(target, n.Name, MetricNodeReady, ready)
+		r.sample(target, n.Name, MetricNodeCPU, n.CPUPercent)
+		r.sample(target, n.Name, MetricNodeMemory, n.MemoryPercent)
+		r.sample(target, n.Name, MetricNodePods, float64(n.PodCount))
+		if n.PodsCapacity > 0 {
+			r.sample(target, n.Name, MetricNodePodsPercent, n.PodPercent)
+		}
+	}
+}
+
+func (r *Record) sample(target, resource, metric string, value float64) {
+	r.Samples = append(r.Samples, Sample{Target: target, Resource: resource, Metric: metric, Value: value})
+}
+
+// addIssues 记录问题并统计数量, 被豁免的问题单独计数
+func (r *Record) addIssues(target string, issues []models.Issue) {
+	for _, issue := range issues {
+		if issue.Fingerprint == "" {
+			issue.Identify(target)
+		}
+		r.Issues = append(r.Issues, IssueRecord{
+			Fingerprint: issue.Fingerprint,
+			CheckID:     issue.CheckID,
+			Level:       issue.Level,
+			Category:    issue.Category,
+			Target:      issue.Target,
+			Resource:    issueResource(issue),
+			Message:     issue.Message,
+			Suppressed:  issue.Suppressed,
+		})
+
+		switch {
+		case issue.Suppressed:
+			r.Waived++
+		case issue.Level == models.SeverityCritical:
+			r.Critical++
+		case issue.Level == models.SeverityWarning:
+			r.Warning++
+		case issue.Level == models.SeverityInfo:
+			r.Info++
+		}
+	}
+}
+
+// issueResource 问题涉及的资源名称, K8s资源带命名空间
+func issueResource(issue models.Issue) string {
+	if issue.Namespace != "" {
+		return issue.Namespace + "/" + issue.ResourceName
+	}
+	return issue.ResourceName
+}
@@ -0,0 +1,234 @@
+package inventory
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// iniGroup Ansible INI清单中的分组
+type iniGroup struct {
+	hosts    []string
+	vars     map[string]string
+	children []string
+}
+
+// parseINI 导入Ansible INI格式清单
+// 支持 [group]、[group:vars]、[group:children]、主机范围 web[01:03] 以及变量
+// ansible_host、ansible_port、ansible_user、ansible_password(ansible_ssh_pass)、ansible_ssh_private_key_file,
+// 标签通过主机或分组变量 tags=a,b 指定; INI清单不支持阈值覆盖
+func parseINI(data []byte) (*Inventory, error) {
+	groups := make(map[string]*iniGroup)
+	var groupOrder []string
+	hostVars := make(map[string]map[string]string)
+	var hostOrder []string
+
+	getGroup := func(name string) *iniGroup {
+		g, ok := groups[name]
+		if !ok {
+			g = &iniGroup{vars: make(map[string]string)}
+			groups[name] = g
+			groupOrder = append(groupOrder, name)
+		}
+		return g
+	}
+
+	section, kind := "ungrouped", ""
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	lineNo := 0
+	for scanner.Scan() {
+		lineNo++
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") || strings.HasPrefix(line, ";") {
+			continue
+		}
+
+		if strings.HasPrefix(line, "[") && strings.HasSuffix(line, "]") {
+			section, kind = strings.TrimSpace(line[1:len(line)-1]), ""
+			if idx := strings.Index(section, ":"); idx >= 0 {
+				section, kind = section[:idx], section[idx+1:]
+			}
+			switch kind {
+			case "", "vars", "children":
+			default:
+				return nil, fmt.Errorf("line %d: unknown section type %q", lineNo, kind)
+			}
+			getGroup(section)
+			continue
+		}
+
+		g := getGroup(section)
+		switch kind {
+		case "vars":
+			key, value, ok := strings.Cut(line, "=")
+			if !ok {
+				return nil, fmt.Errorf("line %d: expected key=value", lineNo)
+			}
+			g.vars[strings.TrimSpace(key)] = unquote(strings.TrimSpace(value))
+		case "children":
+			g.children = append(g.children, strings.Fields(line)[0])
+		default:
+			fields := strings.Fields(line)
+			names, err := expandRange(fields[0])
+			if err != nil {
+				return nil, fmt.Errorf("line %d: %w", lineNo, err)
+			}
+			for _, name := range names {
+				vars, ok := hostVars[name]
+				if !ok {
+					vars = make(map[string]string)
+					hostVars[name] = vars
+					hostOrder = append(hostOrder, name)
+				}
+				for _, field := range fields[1:] {
+					key, value, ok := strings.Cut(field, "=")
+					if !ok {
+						return nil, fmt.Errorf("line %d: expected key=value, got %q", lineNo, field)
+					}
+					vars[key] = unquote(value)
+				}
+				g.hosts = append(g.hosts, name)
+			}
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read inventory: %w", err)
+	}
+
+	// 子分组的主机同时属于父分组
+	parents := make(map[string][]string)
+	for _, name := range groupOrder {
+		for _, child := range groups[name].children {
+			getGroup(child)
+			parents[child] = append(parents[child], name)
+		}
+	}
+
+	inv := &Inventory{}
+	for _, name := range hostOrder {
+		// 直接所属分组在前, 父分组在后, 变量按此顺序取第一个设置的值
+		var direct []string
+		for _, g := range groupOrder {
+			if g != "ungrouped" && g != "all" && contains(groups[g].hosts, name) {
+				direct = append(direct, g)
+			}
+		}
+		memberOf := appendUnique(nil, direct...)
+		for i := 0; i < len(memberOf); i++ {
+			for _, p := range parents[memberOf[i]] {
+				if p != "all" {
+					memberOf = appendUnique(memberOf, p)
+				}
+			}
+		}
+
+		layers := []map[string]string{hostVars[name]}
+		for _, g := range memberOf {
+			layers = append(layers, groups[g].vars)
+		}
+		if all, ok := groups["all"]; ok {
+			layers = append(layers, all.vars)
+		}
+		lookup := func(keys ...string) string {
+			for _, layer := range layers {
+				for _, key := range keys {
+					if v, ok := layer[key]; ok && v != "" {
+						return v
+					}
+				}
+			}
+			return ""
+		}
+
+		host := &Host{
+			Name:     name,
+			Address:  lookup("ansible_host", "ansible_ssh_host"),
+			User:     lookup("ansible_user", "ansible_ssh_user"),
+			Password: lookup("ansible_password", "ansible_ssh_pass"),
+			Groups:   memberOf,
+		}
+		if host.Address == host.Name {
+			host.Address = ""
+		}
+		if port := lookup("ansible_port", "ansible_ssh_port"); port != "" {
+			p, err := strconv.Atoi(port)
+			if err != nil {
+				return nil, fmt.Errorf("host %s: invalid ansible_port %q", name, port)
+			}
+			host.Port = p
+		}
+		if key := lookup("ansible_ssh_private_key_file"); key != "" {
+			host.KeyFiles = []string{key}
+		}
+		for _, layer := range layers {
+			if tags, ok := layer["tags"]; ok {
+				host.Tags = appendUnique(host.Tags, splitList(tags)...)
+			}
+		}
+
+		inv.add(host)
+	}
+
+	return inv, nil
+}
+
+// expandRange 展开Ansible主机范围, 如 web[01:03].example.com
+func expandRange(pattern string) ([]string, error) {
+	start := strings.Index(pattern, "[")
+	end := strings.Index(pattern, "]")
+	if start < 0 || end < start {
+		return []string{pattern}, nil
+	}
+
+	from, to, ok := strings.Cut(pattern[start+1:end], ":")
+	if !ok {
+		return nil, fmt.Errorf("invalid host range %q", pattern)
+	}
+	lo, err1 := strconv.Atoi(from)
+	hi, err2 := strconv.Atoi(to)
+	if err1 != nil || err2 != nil || lo > hi {
+		return nil, fmt.Errorf("invalid host range %q", pattern)
+	}
+
+	prefix, suffix := pattern[:start], pattern[end+1:]
+	width := 0
+	if strings.HasPrefix(from, "0") {
+		width = len(from)
+	}
+
+	var names []string
+	for i := lo; i <= hi; i++ {
+		names = append(names, fmt.Sprintf("%s%0*d%s", prefix, width, i, suffix))
+	}
+	return names, nil
+}
+
+// unquote 去掉值两端的引号
+func unquote(s string) string {
+	if len(s) >= 2 && (s[0] == '"' || s[0] == '\'') && s[len(s)-1] == s[0] {
+		return s[1 : len(s)-1]
+	}
+	return s
+}
+
+// splitList 拆分逗号分隔的列表
+func splitList(s string) []string {
+	var items []string
+	for _, item := range strings.Split(s, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+func contains(list []string, item string) bool {
+	for _, v := range list {
+		if v == item {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,271 @@
+package inventory
+
+import (
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"inspection-tool/internal/config"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Host 清单中的一台主机, SSH设置为空时使用命令行参数
+type Host struct {
+	Name     string   // 清单中的主机名(别名), 用于 --limit 和报告
+	Address  string   // 连接地址, 为空时使用Name
+	Port     int      // 为0时使用命令行或ssh_config
+	User     string   // 为空时使用命令行或ssh_config
+	Password string   // 为空时使用命令行
+	KeyFiles []string // 为空时使用命令行
+	Groups   []string // 所属分组, 第一个为主分组
+	Tags     []string
+	Node     string // 对应的K8s节点名称, 由K8s节点生成的主机才有
+
+	// 应用分组阈值覆盖后的阈值, 为nil时使用配置文件中的全局阈值
+	Thresholds *config.ServerThresholds
+}
+
+// Target 返回连接地址
+func (h *Host) Target() string {
+	if h.Address != "" {
+		return h.Address
+	}
+	return h.Name
+}
+
+// Group 返回主分组
+func (h *Host) Group() string {
+	if len(h.Groups) == 0 {
+		return ""
+	}
+	return h.Groups[0]
+}
+
+// Inventory 主机清单
+type Inventory struct {
+	Hosts []*Host
+}
+
+// fileHost YAML清单中的主机
+type fileHost struct {
+	Name     string   `yaml:"name"`
+	Host     string   `yaml:"host"`
+	Port     int      `yaml:"port"`
+	User     string   `yaml:"user"`
+	Password string   `yaml:"password"`
+	KeyFiles []string `yaml:"key_files"`
+	Tags     []string `yaml:"tags"`
+}
+
+// fileGroup YAML清单中的分组, Vars为组内主机的默认SSH设置
+type fileGroup struct {
+	Vars       fileHost   `yaml:"vars"`
+	Tags       []string   `yaml:"tags"`
+	Thresholds yaml.Node  `yaml:"thresholds"` // 只需列出需要覆盖的阈值
+	Hosts      []fileHost `yaml:"hosts"`
+}
+
+// fileInventory YAML清单文件
+type fileInventory struct {
+	Vars   fileHost              `yaml:"vars"`
+	Groups map[string]*fileGroup `yaml:"groups"`
+	Hosts  []fileHost            `yaml:"hosts"` // 不属于任何分组的主机
+}
+
+// Load 加载主机清单, .yaml/.yml按YAML格式解析, 其他文件按Ansible INI格式导入
+// base为全局阈值, 分组中的阈值覆盖在其基础上生效
+func Load(file string, base config.ServerThresholds) (*Inventory, error) {
+	data, err := os.ReadFile(config.ExpandHome(file))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read inventory: %w", err)
+	}
+
+	switch strings.ToLower(filepath.Ext(file)) {
+	case ".yaml", ".yml":
+		return parseYAML(data, base)
+	default:
+		return parseINI(data)
+	}
+}
+
+// parseYAML 解析YAML清单
+func parseYAML(data []byte, base config.ServerThresholds) (*Inventory, error) {
+	var f fileInventory
+	if err := yaml.Unmarshal(data, &f); err != nil {
+		return nil, fmt.Errorf("failed to parse inventory: %w", err)
+	}
+
+	inv := &Inventory{}
+	for _, h := range f.Hosts {
+		host, err := newHost(h, f.Vars)
+		if err != nil {
+			return nil, err
+		}
+		inv.add(host)
+	}
+
+	// 按分组名排序, 保证主机顺序稳定
+	names := make([]string, 0, len(f.Groups))
+	for name := range f.Groups {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		g := f.Groups[name]
+		if g == nil {
+			continue
+		}
+
+		var thresholds *config.ServerThresholds
+		if !g.Thresholds.IsZero() {
+			t := base
+			if err := g.Thresholds.Decode(&t); err != nil {
+				return nil, fmt.Errorf("invalid thresholds for group %s: %w", name, err)
+			}
+			thresholds = &t
+		}
+
+		for _, h := range g.Hosts {
+			host, err := newHost(h, mergeVars(g.Vars, f.Vars))
+			if err != nil {
+				return nil, fmt.Errorf("group %s: %w", name, err)
+			}
+			host.Groups = []string{name}
+			host.Tags = appendUnique(host.Tags, g.Tags...)
+			host.Thresholds = thresholds
+			inv.add(host)
+		}
+	}
+
+	return inv, nil
+}
+
+// newHost 使用vars补全主机未指定的SSH设置
+func newHost(h fileHost, vars fileHost) (*Host, error) {
+	if h.Name == "" && h.Host == "" {
+		return nil, fmt.Errorf("inventory host requires name or host")
+	}
+	h = mergeVars(h, vars)
+
+	host := &Host{
+		Name:     h.Name,
+		Address:  h.Host,
+		Port:     h.Port,
+		User:     h.User,
+		Password: h.Password,
+		KeyFiles: h.KeyFiles,
+		Tags:     appendUnique(nil, h.Tags...),
+	}
+	if host.Name == "" {
+		host.Name = host.Address
+	}
+	if host.Address == host.Name {
+		host.Address = ""
+	}
+	return host, nil
+}
+
+// mergeVars 用vars补全h中未设置的SSH字段
+func mergeVars(h, vars fileHost) fileHost {
+	if h.Port == 0 {
+		h.Port = vars.Port
+	}
+	if h.User == "" {
+		h.User = vars.User
+	}
+	if h.Password == "" {
+		h.Password = vars.Password
+	}
+	if len(h.KeyFiles) == 0 {
+		h.KeyFiles = vars.KeyFiles
+	}
+	return h
+}
+
+// add 添加主机, 同名主机合并分组和标签
+func (inv *Inventory) add(host *Host) {
+	for _, existing := range inv.Hosts {
+		if existing.Name == host.Name {
+			existing.Groups = appendUnique(existing.Groups, host.Groups...)
+			existing.Tags = appendUnique(existing.Tags, host.Tags...)
+			if existing.Thresholds == nil {
+				existing.Thresholds = host.Thresholds
+			}
+			return
+		}
+	}
+	inv.Hosts = append(inv.Hosts, host)
+}
+
+// Select 按 --limit 表达式筛选主机
+// 表达式为逗号分隔的分组名、标签或主机名, 支持通配符; 以!开头表示排除; 为空时返回全部主机
+func (inv *Inventory) Select(limit string) ([]*Host, error) {
+	var include, exclude []string
+	for _, term := range strings.Split(limit, ",") {
+		term = strings.TrimSpace(term)
+		if term == "" {
+			continue
+		}
+		if strings.HasPrefix(term, "!") {
+			exclude = append(exclude, strings.TrimPrefix(term, "!"))
+		} else {
+			include = append(include, term)
+		}
+	}
+	for _, p := range append(include, exclude...) {
+		if _, err := path.Match(p, ""); err != nil {
+			return nil, fmt.Errorf("invalid limit pattern %q: %w", p, err)
+		}
+	}
+
+	var selected []*Host
+	for _, host := range inv.Hosts {
+		if len(include) > 0 && !host.matchAny(include) {
+			continue
+		}
+		if host.matchAny(exclude) {
+			continue
+		}
+		selected = append(selected, host)
+	}
+	return selected, nil
+}
+
+// matchAny 判断主机的名称、地址、分组或标签是否匹配任一模式
+func (h *Host) matchAny(patterns []string) bool {
+	names := append([]string{h.Name, h.Address}, h.Groups...)
+	names = append(names, h.Tags...)
+	for _, p := range patterns {
+		for _, name := range names {
+			if name == "" {
+				continue
+			}
+			if ok, _ := path.Match(p, name); ok {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// appendUnique 追加不重复的元素
+func appendUnique(list []string, items ...string) []string {
+	for _, item := range items {
+		found := false
+		for _, existing := range list {
+			if existing == item {
+				found = true
+				break
+			}
+		}
+		if !found && item != "" {
+			list = append(list, item)
+		}
+	}
+	return list
+}
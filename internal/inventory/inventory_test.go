@@ -0,0 +1,180 @@
+package inventory
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"inspection-tool/internal/config"
+)
+
+func writeInventory(t *testing.T, name, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(path, []byte(content), 0600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestLoadYAML(t *testing.T) {
+	path := writeInventory(t, "inventory.yaml", `
+vars:
+  user: ops
+hosts:
+  - host: 192.168.1.5
+groups:
+  db:
+    vars:
+      user: dba
+      port: 2222
+    tags: [prod]
+    thresholds:
+      disk:
+        usage_percent: 95
+    hosts:
+      - name: db01
+        host: 10.0.0.1
+        tags: [primary]
+      - name: db02
+        host: 10.0.0.2
+        user: root
+        password: secret
+`)
+
+	base := config.DefaultServerThresholds()
+	inv, err := Load(path, base)
+	if err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+
+	if len(inv.Hosts) != 3 {
+		t.Fatalf("Expected 3 hosts, got %d", len(inv.Hosts))
+	}
+
+	plain := inv.Hosts[0]
+	if plain.Target() != "192.168.1.5" || plain.User != "ops" || plain.Group() != "" || plain.Thresholds != nil {
+		t.Errorf("Unexpected ungrouped host: %+v", plain)
+	}
+
+	db01 := inv.Hosts[1]
+	if db01.Name != "db01" || db01.Target() != "10.0.0.1" || db01.User != "dba" || db01.Port != 2222 {
+		t.Errorf("Unexpected db01: %+v", db01)
+	}
+	if db01.Group() != "db" || len(db01.Tags) != 2 {
+		t.Errorf("Expected group db and tags [primary prod], got %s %v", db01.Group(), db01.Tags)
+	}
+	if db01.Thresholds == nil || db01.Thresholds.Disk.UsagePercent != 95 {
+		t.Fatalf("Expected disk threshold override, got %+v", db01.Thresholds)
+	}
+	if db01.Thresholds.Memory.UsagePercent != base.Memory.UsagePercent {
+		t.Errorf("Expected other thresholds to keep base values, got %.2f", db01.Thresholds.Memory.UsagePercent)
+	}
+
+	db02 := inv.Hosts[2]
+	if db02.User != "root" || db02.Password != "secret" || db02.Port != 2222 {
+		t.Errorf("Expected host settings to override group vars, got %+v", db02)
+	}
+}
+
+func TestLoadINI(t *testing.T) {
+	path := writeInventory(t, "hosts", `
+bastion ansible_host=1.2.3.4
+
+[web]
+web[01:02] tags=frontend
+
+[db]
+db01 ansible_host=10.0.0.1 ansible_port=2222 ansible_ssh_private_key_file=~/.ssh/db
+
+[db:vars]
+ansible_user=dba
+tags=stateful
+
+[prod:children]
+web
+db
+
+[all:vars]
+ansible_user=ops
+`)
+
+	inv, err := Load(path, config.DefaultServerThresholds())
+	if err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+
+	if len(inv.Hosts) != 4 {
+		t.Fatalf("Expected 4 hosts, got %d", len(inv.Hosts))
+	}
+
+	byName := make(map[string]*Host)
+	for _, h := range inv.Hosts {
+		byName[h.Name] = h
+	}
+
+	if h := byName["bastion"]; h == nil || h.Target() != "1.2.3.4" || h.User != "ops" {
+		t.Errorf("Unexpected bastion: %+v", h)
+	}
+
+	web := byName["web02"]
+	if web == nil || web.Group() != "web" || len(web.Groups) != 2 || web.Groups[1] != "prod" {
+		t.Fatalf("Expected web02 in groups [web prod], got %+v", web)
+	}
+	if len(web.Tags) != 1 || web.Tags[0] != "frontend" {
+		t.Errorf("Expected web02 tags [frontend], got %v", web.Tags)
+	}
+
+	db := byName["db01"]
+	if db.Target() != "10.0.0.1" || db.Port != 2222 || db.User != "dba" || len(db.KeyFiles) != 1 {
+		t.Errorf("Unexpected db01: %+v", db)
+	}
+	if len(db.Tags) != 1 || db.Tags[0] != "stateful" {
+		t.Errorf("Expected group tags to apply, got %v", db.Tags)
+	}
+}
+
+func TestSelect(t *testing.T) {
+	inv := &Inventory{Hosts: []*Host{
+		{Name: "web01", Groups: []string{"web", "prod"}, Tags: []string{"frontend"}},
+		{Name: "web02", Groups: []string{"web", "staging"}},
+		{Name: "db01", Groups: []string{"db", "prod"}, Tags: []string{"primary"}},
+	}}
+
+	tests := []struct {
+		limit string
+		want  []string
+	}{
+		{"", []string{"web01", "web02", "db01"}},
+		{"web", []string{"web01", "web02"}},
+		{"primary", []string{"db01"}},
+		{"prod,!db", []string{"web01"}},
+		{"web0*", []string{"web01", "web02"}},
+		{"!staging", []string{"web01", "db01"}},
+	}
+
+	for _, tt := range tests {
+		hosts, err := inv.Select(tt.limit)
+		if err != nil {
+			t.Fatalf("Select(%q) failed: %v", tt.limit, err)
+		}
+		var got []string
+		for _, h := range hosts {
+			got = append(got, h.Name)
+		}
+		if len(got) != len(tt.want) {
+			t.Errorf("Select(%q) = %v, want %v", tt.limit, got, tt.want)
+			continue
+		}
+		for i := range got {
+			if got[i] != tt.want[i] {
+				t.Errorf("Select(%q) = %v, want %v", tt.limit, got, tt.want)
+				break
+			}
+		}
+	}
+
+	if _, err := inv.Select("[bad"); err == nil {
+		t.Error("Expected error for invalid pattern")
+	}
+}
@@ -0,0 +1,271 @@
+package k8s
+
+import (
+	"fmt"
+	"inspection-tool/internal/checks"
+	"inspection-tool/pkg/models"
+	"strings"
+)
+
+// 内置Kubernetes检查项
+func init() {
+	for _, c := range k8sChecks() {
+		checks.Register(c)
+	}
+}
+
+// k8sCheck 创建Kubernetes检查项
+func k8sCheck(id, category string, severity models.Severity, evaluate func(in *checks.Input) []models.Issue) checks.Check {
+	return checks.New(id, category, severity, checks.TargetK8s, evaluate)
+}
+
+// k8sChecks 返回内置Kubernetes检查项
+func k8sChecks() []checks.Check {
+	return []checks.Check{
+		k8sCheck("k8s.node.ready", "node", "critical", func(in *checks.Input) []models.Issue {
+			var issues []models.Issue
+			for _, node := range in.K8s.Nodes {
+				if !node.Ready {
+					issues = append(issues, models.Issue{
+						Message:      fmt.Sprintf("Node not ready: %s", node.Name),
+						Details:      getNodeConditionDetails(node.Conditions),
+						Suggestion:   "Check node status and kubelet logs",
+						ResourceKind: models.ResourceNode,
+						ResourceName: node.Name,
+					})
+				}
+			}
+			return issues
+		}),
+
+		k8sCheck("k8s.node.cpu", "node", "warning", func(in *checks.Input) []models.Issue {
+			var issues []models.Issue
+			for _, node := range in.K8s.Nodes {
+				if node.CPUPercent > in.K8sThresholds.Node.CPUUsagePercent {
+					issues = append(issues, models.Issue{
+						Message:      fmt.Sprintf("High CPU usage on node %s: %.2f%%", node.Name, node.CPUPercent),
+						Details:      fmt.Sprintf("CPU: %s / %s", node.CPUUsage, node.CPUCapacity),
+						Suggestion:   "Consider scaling or optimizing workloads",
+						ResourceKind: models.ResourceNode,
+						ResourceName: node.Name,
+					}.Observe(node.CPUPercent, in.K8sThresholds.Node.CPUUsagePercent, "%"))
+				}
+			}
+			return issues
+		}),
+
+		k8sCheck("k8s.node.memory", "node", "warning", func(in *checks.Input) []models.Issue {
+			var issues []models.Issue
+			for _, node := range in.K8s.Nodes {
+				if node.MemoryPercent > in.K8sThresholds.Node.MemoryUsagePercent {
+					issues = append(issues, models.Issue{
+						Message:      fmt.Sprintf("High memory usage on node %s: %.2f%%", node.Name, node.MemoryPercent),
+						Details:      fmt.Sprintf("Memory: %s / %s", node.MemoryUsage, node.MemoryCapacity),
+						Suggestion:   "Check memory-intensive pods or add more nodes",
+						ResourceKind: models.ResourceNode,
+						ResourceName: node.Name,
+					}.Observe(node.MemoryPercent, in.K8sThresholds.Node.MemoryUsagePercent, "%"))
+				}
+			}
+			return issues
+		}),
+
+		k8sCheck("k8s.node.pods", "node", "warning", func(in *checks.Input) []models.Issue {
+			var issues []models.Issue
+			for _, node := range in.K8s.Nodes {
+				if node.PodPercent > in.K8sThresholds.Node.PodCountPercent {
+					issues = append(issues, models.Issue{
+						Message:      fmt.Sprintf("Pod capacity near limit on node %s: %.2f%%", node.Name, node.PodPercent),
+						Details:      fmt.Sprintf("Pods: %d / %d", node.PodCount, node.PodsCapacity),
+						Suggestion:   "Increase max pods or add more nodes",
+						ResourceKind: models.ResourceNode,
+						ResourceName: node.Name,
+					}.Observe(node.PodPercent, in.K8sThresholds.Node.PodCountPercent, "%"))
+				}
+			}
+			return issues
+		}),
+
+		k8sCheck("k8s.apiserver.health", "apiserver", "critical", func(in *checks.Input) []models.Issue {
+			if in.K8s.APIServerStatus.Healthy {
+				return nil
+			}
+			return []models.Issue{{
+				Message:      "API Server unhealthy",
+				Suggestion:   "Check API Server logs and status",
+				ResourceKind: models.ResourceComponent,
+				ResourceName: "apiserver",
+			}}
+		}),
+
+		k8sCheck("k8s.etcd.health", "etcd", "critical", func(in *checks.Input) []models.Issue {
+			etcd := in.K8s.EtcdStatus
+			if etcd.Healthy {
+				return nil
+			}
+			return []models.Issue{{
+				Message:      "etcd cluster unhealthy",
+				Details:      fmt.Sprintf("Healthy members: %d / %d", countHealthyEtcdMembers(etcd.Members), etcd.ClusterSize),
+				Suggestion:   "Check etcd cluster status and logs",
+				ResourceKind: models.ResourceComponent,
+				ResourceName: "etcd",
+			}}
+		}),
+
+		k8sCheck("k8s.etcd.db_size", "etcd", "warning", func(in *checks.Input) []models.Issue {
+			limit := in.K8sThresholds.Etcd.DBSizeMB
+			if limit <= 0 || in.K8s.EtcdStatus.DBSize <= limit {
+				return nil
+			}
+			issue := models.Issue{
+				Message:      fmt.Sprintf("etcd database too large: %d MB", in.K8s.EtcdStatus.DBSize),
+				Details:      fmt.Sprintf("Threshold: %d MB", limit),
+				Suggestion:   "Compact and defragment etcd",
+				ResourceKind: models.ResourceComponent,
+				ResourceName: "etcd",
+			}.Observe(float64(in.K8s.EtcdStatus.DBSize), float64(limit), "MB")
+			return []models.Issue{issue}
+		}),
+
+		k8sCheck("k8s.etcd.leader_changes", "etcd", "warning", func(in *checks.Input) []models.Issue {
+			limit := in.K8sThresholds.Etcd.LeaderChanges
+			if limit <= 0 || in.K8s.EtcdStatus.LeaderChanges <= limit {
+				return nil
+			}
+			issue := models.Issue{
+				Message:      fmt.Sprintf("Frequent etcd leader changes: %d", in.K8s.EtcdStatus.LeaderChanges),
+				Details:      fmt.Sprintf("Threshold: %d", limit),
+				Suggestion:   "Check etcd disk latency and network stability",
+				ResourceKind: models.ResourceComponent,
+				ResourceName: "etcd",
+			}.Observe(float64(in.K8s.EtcdStatus.LeaderChanges), float64(limit), "")
+			return []models.Issue{issue}
+		}),
+
+		k8sCheck("k8s.controller.health", "controller", "critical", func(in *checks.Input) []models.Issue {
+			if in.K8s.ControllerStatus.Healthy {
+				return nil
+			}
+			return []models.Issue{{
+				Message:      "Controller Manager unhealthy",
+				Suggestion:   "Check Controller Manager logs",
+				ResourceKind: models.ResourceComponent,
+				ResourceName: "controller",
+			}}
+		}),
+
+		k8sCheck("k8s.scheduler.health", "scheduler", "critical", func(in *checks.Input) []models.Issue {
+			if in.K8s.SchedulerStatus.Healthy {
+				return nil
+			}
+			return []models.Issue{{
+				Message:      "Scheduler unhealthy",
+				Suggestion:   "Check Scheduler logs",
+				ResourceKind: models.ResourceComponent,
+				ResourceName: "scheduler",
+			}}
+		}),
+
+		k8sCheck("k8s.pod.pending", "pod", "warning", func(in *checks.Input) []models.Issue {
+			var issues []models.Issue
+			for _, pod := range in.K8s.Pods {
+				if pod.Phase == "Pending" && pod.Age > in.K8sThresholds.Pod.PendingSeconds {
+					issues = append(issues, models.Issue{
+						Message:      fmt.Sprintf("Pod stuck in Pending: %s/%s", pod.Namespace, pod.Name),
+						Details:      getPodConditionDetails(pod.Conditions),
+						Suggestion:   "Check resource availability and scheduling constraints",
+						ResourceKind: models.ResourcePod,
+						ResourceName: pod.Name,
+						Namespace:    pod.Namespace,
+					}.Observe(float64(pod.Age), float64(in.K8sThresholds.Pod.PendingSeconds), "s"))
+				}
+			}
+			return issues
+		}),
+
+		k8sCheck("k8s.pod.restarts", "pod", "warning", func(in *checks.Input) []models.Issue {
+			var issues []models.Issue
+			for _, pod := range in.K8s.Pods {
+				if pod.RestartCount > in.K8sThresholds.Pod.RestartCount {
+					issues = append(issues, models.Issue{
+						Message:      fmt.Sprintf("High restart count: %s/%s (%d restarts)", pod.Namespace, pod.Name, pod.RestartCount),
+						Suggestion:   "Check pod logs for errors",
+						ResourceKind: models.ResourcePod,
+						ResourceName: pod.Name,
+						Namespace:    pod.Namespace,
+					}.Observe(float64(pod.RestartCount), float64(in.K8sThresholds.Pod.RestartCount), ""))
+				}
+			}
+			return issues
+		}),
+
+		// 资源使用相对limit检查
+		k8sCheck("k8s.pod.cpu", "pod", "warning", func(in *checks.Input) []models.Issue {
+			var issues []models.Issue
+			for _, pod := range in.K8s.Pods {
+				if percent, ok := quantityPercent(pod.CPUUsage, pod.CPULimit); ok && percent > in.K8sThresholds.Pod.CPUUsagePercent {
+					issues = append(issues, models.Issue{
+						Message:      fmt.Sprintf("High CPU usage: %s/%s (%.2f%% of limit)", pod.Namespace, pod.Name, percent),
+						Details:      fmt.Sprintf("CPU: %s / %s", pod.CPUUsage, pod.CPULimit),
+						Suggestion:   "Check for throttling or raise the CPU limit",
+						ResourceKind: models.ResourcePod,
+						ResourceName: pod.Name,
+						Namespace:    pod.Namespace,
+					}.Observe(percent, in.K8sThresholds.Pod.CPUUsagePercent, "%"))
+				}
+			}
+			return issues
+		}),
+
+		k8sCheck("k8s.pod.memory", "pod", "warning", func(in *checks.Input) []models.Issue {
+			var issues []models.Issue
+			for _, pod := range in.K8s.Pods {
+				if percent, ok := quantityPercent(pod.MemoryUsage, pod.MemoryLimit); ok && percent > in.K8sThresholds.Pod.MemoryUsagePercent {
+					issues = append(issues, models.Issue{
+						Message:      fmt.Sprintf("High memory usage: %s/%s (%.2f%% of limit)", pod.Namespace, pod.Name, percent),
+						Details:      fmt.Sprintf("Memory: %s / %s", pod.MemoryUsage, pod.MemoryLimit),
+						Suggestion:   "Pod may be OOMKilled soon, check for leaks or raise the memory limit",
+						ResourceKind: models.ResourcePod,
+						ResourceName: pod.Name,
+						Namespace:    pod.Namespace,
+					}.Observe(percent, in.K8sThresholds.Pod.MemoryUsagePercent, "%"))
+				}
+			}
+			return issues
+		}),
+
+		k8sCheck("k8s.pod.not_ready", "pod", "warning", func(in *checks.Input) []models.Issue {
+			var issues []models.Issue
+			for _, pod := range in.K8s.Pods {
+				if !pod.Ready && pod.Phase == "Running" {
+					issues = append(issues, models.Issue{
+						Message:      fmt.Sprintf("Pod not ready: %s/%s", pod.Namespace, pod.Name),
+						Details:      getPodConditionDetails(pod.Conditions),
+						Suggestion:   "Check readiness probe and application status",
+						ResourceKind: models.ResourcePod,
+						ResourceName: pod.Name,
+						Namespace:    pod.Namespace,
+					})
+				}
+			}
+			return issues
+		}),
+
+		k8sCheck("k8s.pod.crashloop", "pod", "critical", func(in *checks.Input) []models.Issue {
+			crashLoopPods := 0
+			for _, pod := range in.K8s.Pods {
+				if strings.Contains(pod.Phase, "CrashLoopBackOff") {
+					crashLoopPods++
+				}
+			}
+			if crashLoopPods == 0 {
+				return nil
+			}
+			issue := models.Issue{
+				Message:    fmt.Sprintf("%d pods in CrashLoopBackOff state", crashLoopPods),
+				Suggestion: "Investigate failing pods",
+			}.Observe(float64(crashLoopPods), 0, "")
+			return []models.Issue{issue}
+		}),
+	}
+}
@@ -3,11 +3,15 @@ package k8s
 import (
 	"context"
 	"fmt"
+	"inspection-tool/internal/checks"
+	cfgpkg "inspection-tool/internal/config"
+	"inspection-tool/internal/waivers"
 	"inspection-tool/pkg/models"
 	"strings"
 	"time"
 
 	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/client-go/kubernetes"
 	"k8s.io/client-go/rest"
@@ -21,6 +25,7 @@ type Inspector struct {
 	clientset        *kubernetes.Clientset
 	metricsClientset *metricsv.Clientset
 	config           *InspectorConfig
+	server           string // API Server地址
 }
 
 // InspectorConfig 巡检配置
@@ -28,6 +33,10 @@ type InspectorConfig struct {
 	Kubeconfig string
 	Namespaces []string
 	Timeout    time.Duration
+	Thresholds cfgpkg.K8sThresholds // 为空时使用默认阈值
+	Checks     *checks.Set          // 启用的检查项, 为nil时执行全部检查项
+	Waivers    *waivers.List        // 本次巡检的豁免
+	History    checks.History       // 趋势类检查项读取的历史记录, 为nil时不预测
 }
 
 // NewInspector 创建Kubernetes巡检器
@@ -64,10 +73,15 @@ func NewInspector(config *InspectorConfig) (*Inspector, error) {
 		config.Timeout = 30 * time.Second
 	}
 
+	if config.Thresholds == (cfgpkg.K8sThresholds{}) {
+		config.Thresholds = cfgpkg.DefaultK8sThresholds()
+	}
+
 	return &Inspector{
 		clientset:        clientset,
 		metricsClientset: metricsClientset,
 		config:           config,
+		server:           restConfig.Host,
 	}, nil
 }
 
@@ -95,44 +109,52 @@ func (i *Inspector) Inspect() (*models.K8sReport, error) {
 	if err := i.collectAPIServerMetrics(ctx, report); err != nil {
 		// API Server检查失败不中断巡检
 		report.Issues = append(report.Issues, models.Issue{
-			Level:     "warning",
-			Category:  "apiserver",
-			Message:   "Failed to collect API Server metrics",
-			Details:   err.Error(),
-			Timestamp: time.Now(),
+			Level:        "warning",
+			Category:     "apiserver",
+			Message:      "Failed to collect API Server metrics",
+			Details:      err.Error(),
+			Timestamp:    time.Now(),
+			ResourceKind: models.ResourceComponent,
+			ResourceName: "apiserver",
 		})
 	}
 
 	// 收集etcd状态
 	if err := i.collectEtcdMetrics(ctx, report); err != nil {
 		report.Issues = append(report.Issues, models.Issue{
-			Level:     "warning",
-			Category:  "etcd",
-			Message:   "Failed to collect etcd metrics",
-			Details:   err.Error(),
-			Timestamp: time.Now(),
+			Level:        "warning",
+			Category:     "etcd",
+			Message:      "Failed to collect etcd metrics",
+			Details:      err.Error(),
+			Timestamp:    time.Now(),
+			ResourceKind: models.ResourceComponent,
+			ResourceName: "etcd",
 		})
 	}
 
 	// 收集Controller Manager状态
 	if err := i.collectControllerMetrics(ctx, report); err != nil {
 		report.Issues = append(report.Issues, models.Issue{
-			Level:     "info",
-			Category:  "controller",
-			Message:   "Failed to collect Controller Manager metrics",
-			Details:   err.Error(),
-			Timestamp: time.Now(),
+			Level:        "info",
+			Category:     "controller",
+			Message:      "Failed to collect Controller Manager metrics",
+			Details:      err.Error(),
+			Timestamp:    time.Now(),
+			ResourceKind: models.ResourceComponent,
+			ResourceName: "controller",
 		})
 	}
 
 	// 收集Scheduler状态
 	if err := i.collectSchedulerMetrics(ctx, report); err != nil {
 		report.Issues = append(report.Issues, models.Issue{
-			Level:     "info",
-			Category:  "scheduler",
-			Message:   "Failed to collect Scheduler metrics",
-			Details:   err.Error(),
-			Timestamp: time.Now(),
+			Level:        "info",
+			Category:     "scheduler",
+			Message:      "Failed to collect Scheduler metrics",
+			Details:      err.Error(),
+			Timestamp:    time.Now(),
+			ResourceKind: models.ResourceComponent,
+			ResourceName: "scheduler",
 		})
 	}
 
@@ -174,6 +196,7 @@ func (i *Inspector) collectClusterInfo(ctx context.Context, report *models.K8sRe
 	}
 
 	report.ClusterInfo = models.ClusterInfo{
+		Server:         i.server,
 		Version:        version.GitVersion,
 		NodeCount:      len(nodes.Items),
 		NamespaceCount: len(namespaces.Items),
@@ -242,6 +265,24 @@ func (i *Inspector) parseNodeMetrics(node corev1.Node, metrics *metricsv1beta1.N
 		KubeletVersion:   node.Status.NodeInfo.KubeletVersion,
 	}
 
+	// 解析节点地址, 每种类型取第一个
+	for _, addr := range node.Status.Addresses {
+		switch addr.Type {
+		case corev1.NodeInternalIP:
+			if nm.InternalIP == "" {
+				nm.InternalIP = addr.Address
+			}
+		case corev1.NodeExternalIP:
+			if nm.ExternalIP == "" {
+				nm.ExternalIP = addr.Address
+			}
+		case corev1.NodeHostName:
+			if nm.Hostname == "" {
+				nm.Hostname = addr.Address
+			}
+		}
+	}
+
 	// 解析状态条件
 	for _, condition := range node.Status.Conditions {
 		nm.Conditions = append(nm.Conditions, models.NodeCondition{
@@ -526,163 +567,15 @@ func (i *Inspector) parsePodMetrics(pod corev1.Pod, metrics *metricsv1beta1.PodM
 	return pm
 }
 
-// analyzeIssues 分析问题
+// analyzeIssues 使用启用的检查项分析问题, 并对全部问题应用豁免、计算指纹
 func (i *Inspector) analyzeIssues(report *models.K8sReport) {
-	// 节点问题分析
-	notReadyNodes := 0
-	for _, node := range report.Nodes {
-		if !node.Ready {
-			notReadyNodes++
-			report.Issues = append(report.Issues, models.Issue{
-				Level:     "critical",
-				Category:  "node",
-				Message:   fmt.Sprintf("Node not ready: %s", node.Name),
-				Details:   getNodeConditionDetails(node.Conditions),
-				Timestamp: time.Now(),
-				Suggestion: "Check node status and kubelet logs",
-			})
-		}
-
-		// CPU使用率检查
-		if node.CPUPercent > 80 {
-			report.Issues = append(report.Issues, models.Issue{
-				Level:     "warning",
-				Category:  "node",
-				Message:   fmt.Sprintf("High CPU usage on node %s: %.2f%%", node.Name, node.CPUPercent),
-				Details:   fmt.Sprintf("CPU: %s / %s", node.CPUUsage, node.CPUCapacity),
-				Timestamp: time.Now(),
-				Suggestion: "Consider scaling or optimizing workloads",
-			})
-		}
-
-		// 内存使用率检查
-		if node.MemoryPercent > 85 {
-			report.Issues = append(report.Issues, models.Issue{
-				Level:     "warning",
-				Category:  "node",
-				Message:   fmt.Sprintf("High memory usage on node %s: %.2f%%", node.Name, node.MemoryPercent),
-				Details:   fmt.Sprintf("Memory: %s / %s", node.MemoryUsage, node.MemoryCapacity),
-				Timestamp: time.Now(),
-				Suggestion: "Check memory-intensive pods or add more nodes",
-			})
-		}
-
-		// Pod容量检查
-		if node.PodPercent > 90 {
-			report.Issues = append(report.Issues, models.Issue{
-				Level:     "warning",
-				Category:  "node",
-				Message:   fmt.Sprintf("Pod capacity near limit on node %s: %.2f%%", node.Name, node.PodPercent),
-				Details:   fmt.Sprintf("Pods: %d / %d", node.PodCount, node.PodsCapacity),
-				Timestamp: time.Now(),
-				Suggestion: "Increase max pods or add more nodes",
-			})
-		}
-	}
-
-	// API Server检查
-	if !report.APIServerStatus.Healthy {
-		report.Issues = append(report.Issues, models.Issue{
-			Level:     "critical",
-			Category:  "apiserver",
-			Message:   "API Server unhealthy",
-			Timestamp: time.Now(),
-			Suggestion: "Check API Server logs and status",
-		})
-	}
-
-	// etcd检查
-	if !report.EtcdStatus.Healthy {
-		report.Issues = append(report.Issues, models.Issue{
-			Level:     "critical",
-			Category:  "etcd",
-			Message:   "etcd cluster unhealthy",
-			Details:   fmt.Sprintf("Healthy members: %d / %d", countHealthyEtcdMembers(report.EtcdStatus.Members), report.EtcdStatus.ClusterSize),
-			Timestamp: time.Now(),
-			Suggestion: "Check etcd cluster status and logs",
-		})
-	}
-
-	// Controller Manager检查
-	if !report.ControllerStatus.Healthy {
-		report.Issues = append(report.Issues, models.Issue{
-			Level:     "critical",
-			Category:  "controller",
-			Message:   "Controller Manager unhealthy",
-			Timestamp: time.Now(),
-			Suggestion: "Check Controller Manager logs",
-		})
-	}
-
-	// Scheduler检查
-	if !report.SchedulerStatus.Healthy {
-		report.Issues = append(report.Issues, models.Issue{
-			Level:     "critical",
-			Category:  "scheduler",
-			Message:   "Scheduler unhealthy",
-			Timestamp: time.Now(),
-			Suggestion: "Check Scheduler logs",
-		})
-	}
-
-	// Pod问题分析
-	crashLoopPods := 0
-	pendingPods := 0
-	highRestartPods := 0
-
-	for _, pod := range report.Pods {
-		// CrashLoopBackOff检查
-		if strings.Contains(pod.Phase, "CrashLoopBackOff") {
-			crashLoopPods++
-		}
-
-		// Pending状态检查
-		if pod.Phase == "Pending" && pod.Age > 300 { // 5分钟以上
-			pendingPods++
-			report.Issues = append(report.Issues, models.Issue{
-				Level:     "warning",
-				Category:  "pod",
-				Message:   fmt.Sprintf("Pod stuck in Pending: %s/%s", pod.Namespace, pod.Name),
-				Details:   getPodConditionDetails(pod.Conditions),
-				Timestamp: time.Now(),
-				Suggestion: "Check resource availability and scheduling constraints",
-			})
-		}
-
-		// 高重启次数检查
-		if pod.RestartCount > 5 {
-			highRestartPods++
-			report.Issues = append(report.Issues, models.Issue{
-				Level:     "warning",
-				Category:  "pod",
-				Message:   fmt.Sprintf("High restart count: %s/%s (%d restarts)", pod.Namespace, pod.Name, pod.RestartCount),
-				Timestamp: time.Now(),
-				Suggestion: "Check pod logs for errors",
-			})
-		}
-
-		// Not Ready检查
-		if !pod.Ready && pod.Phase == "Running" {
-			report.Issues = append(report.Issues, models.Issue{
-				Level:     "warning",
-				Category:  "pod",
-				Message:   fmt.Sprintf("Pod not ready: %s/%s", pod.Namespace, pod.Name),
-				Details:   getPodConditionDetails(pod.Conditions),
-				Timestamp: time.Now(),
-				Suggestion: "Check readiness probe and application status",
-			})
-		}
-	}
-
-	if crashLoopPods > 0 {
-		report.Issues = append(report.Issues, models.Issue{
-			Level:     "critical",
-			Category:  "pod",
-			Message:   fmt.Sprintf("%d pods in CrashLoopBackOff state", crashLoopPods),
-			Timestamp: time.Now(),
-			Suggestion: "Investigate failing pods",
-		})
-	}
+	report.Issues = append(report.Issues, i.config.Checks.Evaluate(checks.TargetK8s, &checks.Input{
+		K8s:           report,
+		K8sThresholds: i.config.Thresholds,
+		History:       i.config.History,
+	})...)
+	report.Issues = i.config.Waivers.Apply(report.Issues, "")
+	models.IdentifyIssues(report.Issues, report.Target())
 }
 
 // getNodeConditionDetails 获取节点条件详情
@@ -707,6 +600,22 @@ func getPodConditionDetails(conditions []models.PodCondition) string {
 	return strings.Join(details, "; ")
 }
 
+// quantityPercent 计算资源使用量占limit的百分比, 任一值缺失或无法解析时返回false
+func quantityPercent(usage, limit string) (float64, bool) {
+	if usage == "" || limit == "" {
+		return 0, false
+	}
+	u, err := resource.ParseQuantity(usage)
+	if err != nil {
+		return 0, false
+	}
+	l, err := resource.ParseQuantity(limit)
+	if err != nil || l.IsZero() {
+		return 0, false
+	}
+	return float64(u.MilliValue()) / float64(l.MilliValue()) * 100, true
+}
+
 // countHealthyEtcdMembers 统计健康的etcd成员
 func countHealthyEtcdMembers(members []models.EtcdMember) int {
 	count := 0
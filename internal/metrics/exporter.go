@@ -0,0 +1,253 @@
+package metrics
+
+import (
+	"bufio"
+	"fmt"
+	"inspection-tool/pkg/models"
+	"io"
+	"math"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// ContentType Prometheus文本格式
+const ContentType = "text/plain; version=0.0.4; charset=utf-8"
+
+// Exporter 保存每台服务器和每个集群最近一次的巡检报告, 以Prometheus文本格式输出
+// 可以同时被巡检任务更新和被HTTP请求读取
+type Exporter struct {
+	mu       sync.RWMutex
+	servers  map[string]*models.ServerReport
+	clusters map[string]*models.K8sReport
+}
+
+// NewExporter 创建Exporter
+func NewExporter() *Exporter {
+	return &Exporter{
+		servers:  make(map[string]*models.ServerReport),
+		clusters: make(map[string]*models.K8sReport),
+	}
+}
+
+// Update 用巡检报告中的服务器和集群替换之前的数据, 早于已有数据的报告被忽略
+func (e *Exporter) Update(report *models.InspectionReport) {
+	if e == nil || report == nil {
+		return
+	}
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	for _, sr := range report.Servers() {
+		if old, ok := e.servers[sr.Host]; !ok || !sr.Timestamp.Before(old.Timestamp) {
+			e.servers[sr.Host] = sr
+		}
+	}
+	if kr := report.K8sReport; kr != nil {
+		if old, ok := e.clusters[kr.Target()]; !ok || !kr.Timestamp.Before(old.Timestamp) {
+			e.clusters[kr.Target()] = kr
+		}
+	}
+}
+
+// ServeHTTP 实现http.Handler
+func (e *Exporter) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", ContentType)
+	if err := e.Write(w); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
+
+// Write 以Prometheus文本格式输出所有指标
+func (e *Exporter) Write(w io.Writer) error {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+
+	set := &familySet{index: make(map[string]*family)}
+	hosts := make([]string, 0, len(e.servers))
+	for host := range e.servers {
+		hosts = append(hosts, host)
+	}
+	sort.Strings(hosts)
+	for _, host := range hosts {
+		addServer(set, e.servers[host])
+	}
+
+	clusters := make([]string, 0, len(e.clusters))
+	for target := range e.clusters {
+		clusters = append(clusters, target)
+	}
+	sort.Strings(clusters)
+	for _, target := range clusters {
+		addCluster(set, e.clusters[target])
+	}
+
+	bw := bufio.NewWriter(w)
+	for _, f := range set.families {
+		fmt.Fprintf(bw, "# HELP %s %s\n", f.name, f.help)
+		fmt.Fprintf(bw, "# TYPE %s gauge\n", f.name)
+		for _, s := range f.samples {
+			bw.WriteString(f.name)
+			if len(s.labels) > 0 {
+				bw.WriteByte('{')
+				for i := 0; i < len(s.labels); i += 2 {
+					if i > 0 {
+						bw.WriteByte(',')
+					}
+					fmt.Fprintf(bw, "%s=\"%s\"", s.labels[i], escapeLabel(s.labels[i+1]))
+				}
+				bw.WriteByte('}')
+			}
+			fmt.Fprintf(bw, " %s\n", formatValue(s.value))
+		}
+	}
+	return bw.Flush()
+}
+
+// addServer 服务器指标, 未采集到的指标不输出, 以免零值被当作实际值
+func addServer(set *familySet, sr *models.ServerReport) {
+	host := sr.Host
+	set.add("inspection_last_run_timestamp_seconds", "Unix time of the latest inspection of the target",
+		float64(sr.Timestamp.Unix()), "target", host, "type", "server")
+	set.add("inspection_host_up", "Whether the latest inspection of the server collected metrics (1) or failed to connect or inspect (0)",
+		boolValue(!sr.Failed()), "host", host)
+	addIssues(set, host, sr.Issues)
+	if sr.Failed() {
+		return
+	}
+
+	for _, c := range sr.Collectors {
+		set.add("inspection_collector_up", "Whether every command of the collection section succeeded (1) or some failed (0)",
+			boolValue(c.Status == models.CollectorOK), "host", host, "section", c.Name)
+	}
+	if sr.Collected("cpu.usage") {
+		set.add("inspection_cpu_usage_percent", "CPU usage percent", sr.CPU.UsagePercent, "host", host)
+	}
+	if sr.Collected("cpu.load") {
+		set.add("inspection_cpu_load1", "1 minute load average", sr.CPU.Load1, "host", host)
+	}
+	if sr.Collected("memory.meminfo") {
+		set.add("inspection_memory_usage_percent", "Memory usage percent", sr.Memory.UsagePercent, "host", host)
+		set.add("inspection_swap_usage_percent", "Swap usage percent", sr.Memory.SwapPercent, "host", host)
+	}
+	if sr.Collected("system.file_handles") {
+		set.add("inspection_file_handles_usage_percent", "Allocated file handles as percent of the maximum", sr.System.FileHandlesPercent, "host", host)
+	}
+	for _, d := range sr.Disk {
+		if sr.Collected("disk.usage") {
+			set.add("inspection_disk_usage_percent", "Filesystem usage percent", d.UsagePercent, "host", host, "mount", d.MountPoint)
+			set.add("inspection_disk_size_bytes", "Filesystem size in bytes", d.TotalGB*(1<<30), "host", host, "mount", d.MountPoint)
+			set.add("inspection_disk_used_bytes", "Filesystem used bytes", d.UsedGB*(1<<30), "host", host, "mount", d.MountPoint)
+		}
+		if sr.Collected("disk.inodes") && d.InodesTotal > 0 {
+			set.add("inspection_disk_inodes_usage_percent", "Filesystem inode usage percent", d.InodesPercent, "host", host, "mount", d.MountPoint)
+		}
+	}
+}
+
+// addCluster 集群和节点指标
+func addCluster(set *familySet, kr *models.K8sReport) {
+	cluster := kr.Target()
+	set.add("inspection_last_run_timestamp_seconds", "Unix time of the latest inspection of the target",
+		float64(kr.Timestamp.Unix()), "target", cluster, "type", "k8s")
+	addIssues(set, cluster, kr.Issues)
+
+	set.add("inspection_k8s_nodes", "Number of nodes in the cluster", float64(kr.ClusterInfo.NodeCount), "cluster", cluster)
+	set.add("inspection_k8s_pods", "Number of pods in the inspected namespaces", float64(kr.ClusterInfo.PodCount), "cluster", cluster)
+	for _, n := range kr.Nodes {
+		set.add("inspection_k8s_node_ready", "Whether the node is Ready", boolValue(n.Ready), "cluster", cluster, "node", n.Name)
+		if n.UsageCollected() {
+			set.add("inspection_k8s_node_cpu_usage_percent", "Node CPU usage percent from the metrics API", n.CPUPercent, "cluster", cluster, "node", n.Name)
+			set.add("inspection_k8s_node_memory_usage_percent", "Node memory usage percent from the metrics API", n.MemoryPercent, "cluster", cluster, "node", n.Name)
+		}
+		set.add("inspection_k8s_node_pods", "Number of pods on the node", float64(n.PodCount), "cluster", cluster, "node", n.Name)
+		if n.PodsCapacity > 0 {
+			set.add("inspection_k8s_node_pods_usage_percent", "Pods on the node as percent of its pod capacity", n.PodPercent, "cluster", cluster, "node", n.Name)
+		}
+	}
+}
+
+// addIssues 按检查项和级别统计未被豁免的问题数量
+func addIssues(set *familySet, target string, issues []models.Issue) {
+	type key struct {
+		severity, category, check string
+	}
+	counts := make(map[key]int)
+	var keys []key
+	for _, issue := range issues {
+		if issue.Suppressed {
+			continue
+		}
+		k := key{string(issue.Level), issue.Category, issue.CheckID}
+		if counts[k] == 0 {
+			keys = append(keys, k)
+		}
+		counts[k]++
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		if keys[i].check != keys[j].check {
+			return keys[i].check < keys[j].check
+		}
+		if keys[i].severity != keys[j].severity {
+			return keys[i].severity < keys[j].severity
+		}
+		return keys[i].category < keys[j].category
+	})
+	for _, k := range keys {
+		set.add("inspection_issue", "Number of issues found by the latest inspection, waived issues excluded",
+			float64(counts[k]), "target", target, "severity", k.severity, "category", k.category, "check", k.check)
+	}
+}
+
+// family 同名指标的所有样本
+type family struct {
+	name, help string
+	samples    []sample
+}
+
+type sample struct {
+	labels []string // 标签名和值交替排列
+	value  float64
+}
+
+// familySet 按首次出现的顺序保存指标
+type familySet struct {
+	families []*family
+	index    map[string]*family
+}
+
+func (s *familySet) add(name, help string, value float64, labels ...string) {
+	f, ok := s.index[name]
+	if !ok {
+		f = &family{name: name, help: help}
+		s.index[name] = f
+		s.families = append(s.families, f)
+	}
+	f.samples = append(f.samples, sample{labels: labels, value: value})
+}
+
+// escapeLabel 转义标签值中的反斜杠、双引号和换行
+func escapeLabel(v string) string {
+	return strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`).Replace(v)
+}
+
+func formatValue(v float64) string {
+	switch {
+	case math.IsNaN(v):
+		return "NaN"
+	case math.IsInf(v, 1):
+		return "+Inf"
+	case math.IsInf(v, -1):
+		return "-Inf"
+	}
+	return strconv.FormatFloat(v, 'g', -1, 64)
+}
+
+func boolValue(b bool) float64 {
+	if b {
+		return 1
+	}
+	return 0
+}
@@ -0,0 +1,99 @@
+package metrics
+
+import (
+	"inspection-tool/pkg/models"
+	"inspection-tool/pkg/report"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+var start = time.Date(2024, 1, 1, 8, 0, 0, 0, time.UTC)
+
+// exporterTestReport 在示例报告上增加需要转义的挂载点和采集失败的内存指标
+func exporterTestReport() *models.InspectionReport {
+	r := report.SampleReport()
+	sr := r.ServerReports[0]
+	sr.Disk = append(sr.Disk, models.DiskMetrics{MountPoint: `/mnt/"data"`, TotalGB: 10, UsedGB: 9, UsagePercent: 90})
+	sr.Collectors = []models.CollectorStatus{
+		{Name: "cpu", Status: models.CollectorOK},
+		{Name: "memory", Status: models.CollectorFailed},
+	}
+	return r
+}
+
+func TestWrite(t *testing.T) {
+	e := NewExporter()
+	e.Update(exporterTestReport())
+
+	var sb strings.Builder
+	if err := e.Write(&sb); err != nil {
+		t.Fatalf("Write failed: %v", err)
+	}
+	out := sb.String()
+
+	for _, want := range []string{
+		"# TYPE inspection_disk_usage_percent gauge\n",
+		`inspection_disk_usage_percent{host="10.0.0.1",mount="/"} 40` + "\n",
+		`inspection_disk_usage_percent{host="10.0.0.1",mount="/mnt/\"data\""} 90` + "\n",
+		`inspection_disk_size_bytes{host="10.0.0.1",mount="/"} 1.073741824e+11` + "\n",
+		`inspection_disk_inodes_usage_percent{host="10.0.0.1",mount="/"} 5` + "\n",
+		`inspection_collector_up{host="10.0.0.1",section="cpu"} 1` + "\n",
+		`inspection_collector_up{host="10.0.0.1",section="memory"} 0` + "\n",
+		`inspection_cpu_usage_percent{host="10.0.0.1"} 12.5` + "\n",
+		`inspection_host_up{host="10.0.0.1"} 1` + "\n",
+		`inspection_host_up{host="10.0.0.2"} 0` + "\n",
+		`inspection_issue{target="10.0.0.1",severity="warning",category="disk",check="server.disk.usage"} 1` + "\n",
+		`inspection_last_run_timestamp_seconds{target="https://10.0.0.10:6443",type="k8s"} 1.704096e+09` + "\n",
+		`inspection_k8s_node_pods_usage_percent{cluster="https://10.0.0.10:6443",node="node-1"} 18.18` + "\n",
+		`inspection_k8s_node_cpu_usage_percent{cluster="https://10.0.0.10:6443",node="node-2"} 25` + "\n",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("expected %q in output:\n%s", want, out)
+		}
+	}
+
+	// 未采集的内存指标、metrics API未返回的节点使用率、被豁免的问题和不可达服务器的指标不输出
+	for _, unwanted := range []string{
+		"inspection_memory_usage_percent",
+		`inspection_k8s_node_cpu_usage_percent{cluster="https://10.0.0.10:6443",node="node-1"}`,
+		`check="server.disk.inodes"`,
+		`inspection_cpu_usage_percent{host="10.0.0.2"}`,
+	} {
+		if strings.Contains(out, unwanted) {
+			t.Errorf("unexpected %q in output:\n%s", unwanted, out)
+		}
+	}
+
+	// 同名指标只有一组 HELP 和 TYPE
+	if n := strings.Count(out, "# TYPE inspection_host_up gauge"); n != 1 {
+		t.Errorf("expected one TYPE line for inspection_host_up, got %d", n)
+	}
+}
+
+func TestUpdateKeepsLatest(t *testing.T) {
+	e := NewExporter()
+	newer := &models.InspectionReport{ServerReport: &models.ServerReport{
+		Host: "10.0.0.1", Timestamp: start.Add(time.Hour), CPU: models.CPUMetrics{UsagePercent: 30},
+	}}
+	older := &models.InspectionReport{ServerReport: &models.ServerReport{
+		Host: "10.0.0.1", Timestamp: start, CPU: models.CPUMetrics{UsagePercent: 10},
+	}}
+	e.Update(newer)
+	e.Update(older)
+
+	rec := httptest.NewRecorder()
+	e.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	if rec.Code != http.StatusOK || rec.Header().Get("Content-Type") != ContentType {
+		t.Fatalf("unexpected response: %d %s", rec.Code, rec.Header().Get("Content-Type"))
+	}
+	if !strings.Contains(rec.Body.String(), `inspection_cpu_usage_percent{host="10.0.0.1"} 30`) {
+		t.Errorf("expected newer report to be kept:\n%s", rec.Body.String())
+	}
+
+	// 未启用时为nil, 更新不报错
+	var disabled *Exporter
+	disabled.Update(newer)
+}
@@ -0,0 +1,500 @@
+package rules
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// 表达式语法:
+//
+//	expr    = or
+//	or      = and { ("or" | "||") and }
+//	and     = unary { ("and" | "&&") unary }
+//	unary   = ("not" | "!") unary | compare
+//	compare = operand [ ("==" | "!=" | ">" | ">=" | "<" | "<=" | "in" | "matches") operand ]
+//	operand = number | string | "true" | "false" | field | "(" expr ")" | "[" [operand {"," operand}] "]"
+//
+// field为报告JSON字段路径, 如 memory.usage_percent; 字符串使用单引号或双引号
+
+// node 表达式语法树节点
+type node interface {
+	eval(s scope) (interface{}, error)
+}
+
+// scope 表达式求值时的字段来源
+type scope interface {
+	lookup(path []string) (interface{}, bool)
+}
+
+type literal struct{ value interface{} }
+
+type field struct {
+	path []string
+}
+
+type list struct{ items []node }
+
+type unaryNot struct{ x node }
+
+type logical struct {
+	op   string // and, or
+	x, y node
+}
+
+type compare struct {
+	op   string
+	x, y node
+	re   *regexp.Regexp // matches右侧为字符串常量时预编译
+}
+
+// token 词法单元
+type token struct {
+	kind string // num, str, ident, op, eof
+	text string
+	pos  int
+}
+
+// lex 词法分析
+func lex(src string) ([]token, error) {
+	var tokens []token
+	i := 0
+	for i < len(src) {
+		c := rune(src[i])
+		switch {
+		case unicode.IsSpace(c):
+			i++
+		case c == '"' || c == '\'':
+			j := i + 1
+			var sb strings.Builder
+			for j < len(src) && rune(src[j]) != c {
+				if src[j] == '\\' && j+1 < len(src) {
+					j++
+				}
+				sb.WriteByte(src[j])
+				j++
+			}
+			if j >= len(src) {
+				return nil, fmt.Errorf("unterminated string at %d", i)
+			}
+			tokens = append(tokens, token{kind: "str", text: sb.String(), pos: i})
+			i = j + 1
+		case unicode.IsDigit(c) || (c == '-' && i+1 < len(src) && unicode.IsDigit(rune(src[i+1])) && expectOperand(tokens)):
+			j := i + 1
+			for j < len(src) && (unicode.IsDigit(rune(src[j])) || src[j] == '.') {
+				j++
+			}
+			tokens = append(tokens, token{kind: "num", text: src[i:j], pos: i})
+			i = j
+		case unicode.IsLetter(c) || c == '_':
+			j := i + 1
+			for j < len(src) && (unicode.IsLetter(rune(src[j])) || unicode.IsDigit(rune(src[j])) || src[j] == '_' || src[j] == '.') {
+				j++
+			}
+			tokens = append(tokens, token{kind: "ident", text: src[i:j], pos: i})
+			i = j
+		default:
+			op := ""
+			for _, candidate := range []string{"==", "!=", ">=", "<=", "&&", "||", ">", "<", "!", "(", ")", "[", "]", ","} {
+				if strings.HasPrefix(src[i:], candidate) {
+					op = candidate
+					break
+				}
+			}
+			if op == "" {
+				return nil, fmt.Errorf("unexpected character %q at %d", c, i)
+			}
+			tokens = append(tokens, token{kind: "op", text: op, pos: i})
+			i += len(op)
+		}
+	}
+	return append(tokens, token{kind: "eof", pos: len(src)}), nil
+}
+
+// expectOperand 判断下一个词法单元是否应为操作数, 用于区分负数
+func expectOperand(tokens []token) bool {
+	if len(tokens) == 0 {
+		return true
+	}
+	last := tokens[len(tokens)-1]
+	return last.kind == "op" && last.text != ")" && last.text != "]" ||
+		last.kind == "ident" && isKeyword(last.text)
+}
+
+func isKeyword(s string) bool {
+	switch s {
+	case "and", "or", "not", "in", "matches", "true", "false":
+		return true
+	}
+	return false
+}
+
+// parser 递归下降语法分析器
+type parser struct {
+	tokens []token
+	pos    int
+}
+
+// parse 解析表达式
+func parse(src string) (node, error) {
+	tokens, err := lex(src)
+	if err != nil {
+		return nil, err
+	}
+	p := &parser{tokens: tokens}
+	n, err := p.or()
+	if err != nil {
+		return nil, err
+	}
+	if t := p.peek(); t.kind != "eof" {
+		return nil, fmt.Errorf("unexpected %q at %d", t.text, t.pos)
+	}
+	return n, nil
+}
+
+func (p *parser) peek() token { return p.tokens[p.pos] }
+
+func (p *parser) next() token {
+	t := p.tokens[p.pos]
+	if t.kind != "eof" {
+		p.pos++
+	}
+	return t
+}
+
+// accept 当前词法单元为候选之一时前进并返回true
+func (p *parser) accept(texts ...string) (string, bool) {
+	t := p.peek()
+	if t.kind != "op" && t.kind != "ident" {
+		return "", false
+	}
+	for _, text := range texts {
+		if t.text == text {
+			p.pos++
+			return text, true
+		}
+	}
+	return "", false
+}
+
+func (p *parser) or() (node, error) {
+	x, err := p.and()
+	if err != nil {
+		return nil, err
+	}
+	for {
+		if _, ok := p.accept("or", "||"); !ok {
+			return x, nil
+		}
+		y, err := p.and()
+		if err != nil {
+			return nil, err
+		}
+		x = &logical{op: "or", x: x, y: y}
+	}
+}
+
+func (p *parser) and() (node, error) {
+	x, err := p.unary()
+	if err != nil {
+		return nil, err
+	}
+	for {
+		if _, ok := p.accept("and", "&&"); !ok {
+			return x, nil
+		}
+		y, err := p.unary()
+		if err != nil {
+			return nil, err
+		}
+		x = &logical{op: "and", x: x, y: y}
+	}
+}
+
+func (p *parser) unary() (node, error) {
+	if _, ok := p.accept("not", "!"); ok {
+		x, err := p.unary()
+		if err != nil {
+			return nil, err
+		}
+		return &unaryNot{x: x}, nil
+	}
+	return p.compare()
+}
+
+func (p *parser) compare() (node, error) {
+	x, err := p.operand()
+	if err != nil {
+		return nil, err
+	}
+	op, ok := p.accept("==", "!=", ">=", "<=", ">", "<", "in", "matches")
+	if !ok {
+		return x, nil
+	}
+	y, err := p.operand()
+	if err != nil {
+		return nil, err
+	}
+
+	c := &compare{op: op, x: x, y: y}
+	if lit, ok := y.(*literal); ok && op == "matches" {
+		pattern, ok := lit.value.(string)
+		if !ok {
+			return nil, fmt.Errorf("matches requires a string pattern")
+		}
+		if c.re, err = regexp.Compile(pattern); err != nil {
+			return nil, fmt.Errorf("invalid pattern %q: %w", pattern, err)
+		}
+	}
+	return c, nil
+}
+
+func (p *parser) operand() (node, error) {
+	t := p.next()
+	switch t.kind {
+	case "num":
+		v, err := strconv.ParseFloat(t.text, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid number %q at %d", t.text, t.pos)
+		}
+		return &literal{value: v}, nil
+	case "str":
+		return &literal{value: t.text}, nil
+	case "ident":
+		switch t.text {
+		case "true":
+			return &literal{value: true}, nil
+		case "false":
+			return &literal{value: false}, nil
+		}
+		if isKeyword(t.text) {
+			return nil, fmt.Errorf("unexpected %q at %d", t.text, t.pos)
+		}
+		return &field{path: strings.Split(t.text, ".")}, nil
+	case "op":
+		switch t.text {
+		case "(":
+			x, err := p.or()
+			if err != nil {
+				return nil, err
+			}
+			if _, ok := p.accept(")"); !ok {
+				return nil, fmt.Errorf("missing ) at %d", p.peek().pos)
+			}
+			return x, nil
+		case "[":
+			l := &list{}
+			if _, ok := p.accept("]"); ok {
+				return l, nil
+			}
+			for {
+				item, err := p.operand()
+				if err != nil {
+					return nil, err
+				}
+				l.items = append(l.items, item)
+				if _, ok := p.accept("]"); ok {
+					return l, nil
+				}
+				if _, ok := p.accept(","); !ok {
+					return nil, fmt.Errorf("expected , or ] at %d", p.peek().pos)
+				}
+			}
+		}
+	case "eof":
+		return nil, fmt.Errorf("unexpected end of expression")
+	}
+	return nil, fmt.Errorf("unexpected %q at %d", t.text, t.pos)
+}
+
+// fields 返回表达式引用的字段
+func fields(n node) []*field {
+	switch n := n.(type) {
+	case *field:
+		return []*field{n}
+	case *list:
+		var fs []*field
+		for _, item := range n.items {
+			fs = append(fs, fields(item)...)
+		}
+		return fs
+	case *unaryNot:
+		return fields(n.x)
+	case *logical:
+		return append(fields(n.x), fields(n.y)...)
+	case *compare:
+		return append(fields(n.x), fields(n.y)...)
+	}
+	return nil
+}
+
+func (n *literal) eval(s scope) (interface{}, error) { return n.value, nil }
+
+func (n *field) eval(s scope) (interface{}, error) {
+	v, _ := s.lookup(n.path)
+	return v, nil
+}
+
+func (n *list) eval(s scope) (interface{}, error) {
+	values := make([]interface{}, 0, len(n.items))
+	for _, item := range n.items {
+		v, err := item.eval(s)
+		if err != nil {
+			return nil, err
+		}
+		values = append(values, v)
+	}
+	return values, nil
+}
+
+func (n *unaryNot) eval(s scope) (interface{}, error) {
+	v, err := evalBool(n.x, s)
+	if err != nil {
+		return nil, err
+	}
+	return !v, nil
+}
+
+func (n *logical) eval(s scope) (interface{}, error) {
+	x, err := evalBool(n.x, s)
+	if err != nil {
+		return nil, err
+	}
+	if n.op == "and" && !x || n.op == "or" && x {
+		return x, nil
+	}
+	return evalBool(n.y, s)
+}
+
+func (n *compare) eval(s scope) (interface{}, error) {
+	x, err := n.x.eval(s)
+	if err != nil {
+		return nil, err
+	}
+	y, err := n.y.eval(s)
+	if err != nil {
+		return nil, err
+	}
+
+	switch n.op {
+	case "==":
+		return equal(x, y), nil
+	case "!=":
+		return !equal(x, y), nil
+	case "in":
+		switch y := y.(type) {
+		case []interface{}:
+			for _, item := range y {
+				if equal(x, item) {
+					return true, nil
+				}
+			}
+			return false, nil
+		case string:
+			xs, ok := x.(string)
+			return ok && strings.Contains(y, xs), nil
+		case nil:
+			return false, nil
+		}
+		return nil, fmt.Errorf("in requires a list or string, got %T", y)
+	case "matches":
+		xs, ok := x.(string)
+		if !ok {
+			return false, nil
+		}
+		re := n.re
+		if re == nil {
+			pattern, ok := y.(string)
+			if !ok {
+				return nil, fmt.Errorf("matches requires a string pattern")
+			}
+			if re, err = regexp.Compile(pattern); err != nil {
+				return nil, fmt.Errorf("invalid pattern %q: %w", pattern, err)
+			}
+		}
+		return re.MatchString(xs), nil
+	}
+
+	// 大小比较: 数值按数值比较, 字符串按字典序; 字段缺失时结果为false
+	if x == nil || y == nil {
+		return false, nil
+	}
+	if xf, ok := toFloat(x); ok {
+		yf, ok := toFloat(y)
+		if !ok {
+			return nil, fmt.Errorf("cannot compare number with %T", y)
+		}
+		return ordered(n.op, compareFloat(xf, yf)), nil
+	}
+	xs, ok1 := x.(string)
+	ys, ok2 := y.(string)
+	if !ok1 || !ok2 {
+		return nil, fmt.Errorf("cannot compare %T with %T", x, y)
+	}
+	return ordered(n.op, strings.Compare(xs, ys)), nil
+}
+
+// evalBool 求值并要求结果为布尔值
+func evalBool(n node, s scope) (bool, error) {
+	v, err := n.eval(s)
+	if err != nil {
+		return false, err
+	}
+	switch v := v.(type) {
+	case bool:
+		return v, nil
+	case nil:
+		return false, nil
+	}
+	return false, fmt.Errorf("expected boolean, got %T", v)
+}
+
+func equal(x, y interface{}) bool {
+	if xf, ok := toFloat(x); ok {
+		yf, ok := toFloat(y)
+		return ok && xf == yf
+	}
+	switch x.(type) {
+	case nil, bool, string:
+	default:
+		// 列表和对象不可比较
+		return false
+	}
+	return x == y
+}
+
+func toFloat(v interface{}) (float64, bool) {
+	switch v := v.(type) {
+	case float64:
+		return v, true
+	case number:
+		return float64(v), true
+	}
+	return 0, false
+}
+
+func compareFloat(x, y float64) int {
+	switch {
+	case x < y:
+		return -1
+	case x > y:
+		return 1
+	}
+	return 0
+}
+
+func ordered(op string, c int) bool {
+	switch op {
+	case ">":
+		return c > 0
+	case ">=":
+		return c >= 0
+	case "<":
+		return c < 0
+	case "<=":
+		return c <= 0
+	}
+	return false
+}
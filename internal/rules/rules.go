@@ -0,0 +1,394 @@
+package rules
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"inspection-tool/internal/checks"
+	"inspection-tool/internal/config"
+	"inspection-tool/pkg/models"
+	"os"
+	"reflect"
+	"strconv"
+	"strings"
+	"text/template"
+
+	"gopkg.in/yaml.v3"
+)
+
+// IDPrefix 自定义规则生成的检查项ID前缀
+const IDPrefix = "rule."
+
+// Rule 规则文件中的一条规则
+type Rule struct {
+	ID         string `yaml:"id"`
+	Target     string `yaml:"target"` // server, k8s
+	Each       string `yaml:"each"`   // 逐个检查的列表字段, 如 disk、pods; 为空时检查整个报告
+	When       string `yaml:"when"`   // 条件表达式
+	Severity   string `yaml:"severity"`
+	Category   string `yaml:"category"` // 默认custom
+	Message    string `yaml:"message"`  // text/template模板, 可引用字段, 如 {{.memory.usage_percent}}
+	Details    string `yaml:"details"`
+	Suggestion string `yaml:"suggestion"`
+}
+
+// File 规则文件
+type File struct {
+	Rules []Rule `yaml:"rules"`
+}
+
+// compiled 编译后的规则, 实现checks.Check
+type compiled struct {
+	rule       Rule
+	target     checks.Target
+	severity   models.Severity
+	each       []string
+	when       node
+	message    *template.Template
+	details    *template.Template
+	suggestion *template.Template
+}
+
+// Load 加载规则文件
+func Load(file string) ([]checks.Check, error) {
+	data, err := os.ReadFile(config.ExpandHome(file))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read rules: %w", err)
+	}
+	return Parse(data)
+}
+
+// Parse 解析并编译规则, 表达式和模板中引用的字段在加载时校验
+func Parse(data []byte) ([]checks.Check, error) {
+	var f File
+	if err := yaml.Unmarshal(data, &f); err != nil {
+		return nil, fmt.Errorf("failed to parse rules: %w", err)
+	}
+
+	seen := make(map[string]bool)
+	result := make([]checks.Check, 0, len(f.Rules))
+	for i, r := range f.Rules {
+		if r.ID == "" {
+			return nil, fmt.Errorf("rules[%d]: id is required", i)
+		}
+		if seen[r.ID] {
+			return nil, fmt.Errorf("rule %s: duplicate id", r.ID)
+		}
+		seen[r.ID] = true
+
+		c, err := compile(r)
+		if err != nil {
+			return nil, fmt.Errorf("rule %s: %w", r.ID, err)
+		}
+		result = append(result, c)
+	}
+	return result, nil
+}
+
+// compile 编译单条规则
+func compile(r Rule) (*compiled, error) {
+	c := &compiled{rule: r}
+
+	var root reflect.Type
+	switch r.Target {
+	case string(checks.TargetServer):
+		root = reflect.TypeOf(models.ServerReport{})
+	case string(checks.TargetK8s):
+		root = reflect.TypeOf(models.K8sReport{})
+	default:
+		return nil, fmt.Errorf("target must be server or k8s, got %q", r.Target)
+	}
+	c.target = checks.Target(r.Target)
+
+	var err error
+	if c.severity, err = models.ParseSeverity(r.Severity); err != nil {
+		return nil, err
+	}
+	if r.Category == "" {
+		c.rule.Category = "custom"
+	}
+	if r.When == "" {
+		return nil, fmt.Errorf("when is required")
+	}
+	if r.Message == "" {
+		return nil, fmt.Errorf("message is required")
+	}
+
+	// each指向的列表元素作为字段查找的第一层, 找不到时再查整个报告
+	types := []reflect.Type{root}
+	if r.Each != "" {
+		c.each = strings.Split(r.Each, ".")
+		t, ok := fieldType(root, c.each)
+		if !ok || t.Kind() != reflect.Slice {
+			return nil, fmt.Errorf("each: %q is not a list field", r.Each)
+		}
+		types = []reflect.Type{derefType(t.Elem()), root}
+	}
+
+	if c.when, err = parse(r.When); err != nil {
+		return nil, fmt.Errorf("when: %w", err)
+	}
+	for _, f := range fields(c.when) {
+		if !resolvable(types, f.path) {
+			return nil, fmt.Errorf("when: unknown field %q", strings.Join(f.path, "."))
+		}
+	}
+
+	if c.message, err = template.New("message").Parse(r.Message); err != nil {
+		return nil, fmt.Errorf("message: %w", err)
+	}
+	if c.details, err = template.New("details").Parse(r.Details); err != nil {
+		return nil, fmt.Errorf("details: %w", err)
+	}
+	if c.suggestion, err = template.New("suggestion").Parse(r.Suggestion); err != nil {
+		return nil, fmt.Errorf("suggestion: %w", err)
+	}
+	return c, nil
+}
+
+func (c *compiled) ID() string                { return IDPrefix + c.rule.ID }
+func (c *compiled) Category() string          { return c.rule.Category }
+func (c *compiled) Severity() models.Severity { return c.severity }
+func (c *compiled) Target() checks.Target     { return c.target }
+
+// Evaluate 对报告(或each指定的每个列表元素)求值, 条件成立时生成问题
+// 求值出错时生成一个info级别的问题, 避免规则被悄悄忽略
+func (c *compiled) Evaluate(in *checks.Input) []models.Issue {
+	var report interface{}
+	switch c.target {
+	case checks.TargetServer:
+		if in.Server == nil {
+			return nil
+		}
+		report = in.Server
+	case checks.TargetK8s:
+		if in.K8s == nil {
+			return nil
+		}
+		report = in.K8s
+	}
+
+	root, err := toMap(report)
+	if err != nil {
+		return []models.Issue{c.failure(err)}
+	}
+
+	scopes := []*mapScope{{root: root}}
+	if c.each != nil {
+		scopes = nil
+		items, _ := lookupPath(root, c.each)
+		list, _ := items.([]interface{})
+		for _, item := range list {
+			if m, ok := item.(map[string]interface{}); ok {
+				scopes = append(scopes, &mapScope{item: m, root: root})
+			}
+		}
+	}
+
+	var issues []models.Issue
+	for _, s := range scopes {
+		ok, err := evalBool(c.when, s)
+		if err != nil {
+			return append(issues, c.failure(err))
+		}
+		if !ok {
+			continue
+		}
+
+		data := s.data()
+		issue := models.Issue{}
+		if issue.Message, err = execute(c.message, data); err == nil {
+			if issue.Details, err = execute(c.details, data); err == nil {
+				issue.Suggestion, err = execute(c.suggestion, data)
+			}
+		}
+		if err != nil {
+			return append(issues, c.failure(err))
+		}
+		c.setResource(&issue, s.item)
+		issues = append(issues, issue)
+	}
+	return issues
+}
+
+// eachResource each列表元素对应的资源类型和作为资源名称的字段
+type eachResource struct {
+	kind      string
+	nameField string
+}
+
+// eachResources each可指向的列表中有资源类型的部分, 其他列表的问题针对整个巡检对象
+var eachResources = map[string]eachResource{
+	"disk":               {models.ResourceDisk, "mount_point"},
+	"network.interfaces": {models.ResourceInterface, "name"},
+	"nodes":              {models.ResourceNode, "name"},
+	"pods":               {models.ResourcePod, "name"},
+}
+
+// setResource 设置问题对应的列表元素, 使每个元素的问题有各自的指纹并能按资源豁免
+func (c *compiled) setResource(issue *models.Issue, item map[string]interface{}) {
+	res, ok := eachResources[c.rule.Each]
+	if !ok || item == nil {
+		return
+	}
+	name, _ := item[res.nameField].(string)
+	if name == "" {
+		return
+	}
+	issue.ResourceKind = res.kind
+	issue.ResourceName = name
+	issue.Namespace, _ = item["namespace"].(string)
+}
+
+// failure 规则执行失败时生成的问题
+func (c *compiled) failure(err error) models.Issue {
+	return models.Issue{
+		Level:      models.SeverityInfo,
+		Message:    fmt.Sprintf("规则 %s 执行失败", c.rule.ID),
+		Details:    err.Error(),
+		Suggestion: "检查规则文件中的表达式和模板",
+	}
+}
+
+// execute 渲染模板
+func execute(t *template.Template, data interface{}) (string, error) {
+	var buf bytes.Buffer
+	if err := t.Execute(&buf, data); err != nil {
+		return "", err
+	}
+	return buf.String(), nil
+}
+
+// number 报告中的数值, 模板中输出时不使用科学计数法
+type number float64
+
+func (n number) String() string {
+	return strconv.FormatFloat(float64(n), 'f', -1, 64)
+}
+
+// toMap 将报告转换为以JSON字段名为键的map
+func toMap(v interface{}) (map[string]interface{}, error) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	var m map[string]interface{}
+	if err := json.Unmarshal(data, &m); err != nil {
+		return nil, err
+	}
+	return convertNumbers(m).(map[string]interface{}), nil
+}
+
+// convertNumbers 将JSON解码得到的float64转换为number
+func convertNumbers(v interface{}) interface{} {
+	switch v := v.(type) {
+	case float64:
+		return number(v)
+	case map[string]interface{}:
+		for k, item := range v {
+			v[k] = convertNumbers(item)
+		}
+	case []interface{}:
+		for i, item := range v {
+			v[i] = convertNumbers(item)
+		}
+	}
+	return v
+}
+
+// mapScope 字段查找顺序: 当前列表元素, 整个报告
+type mapScope struct {
+	item map[string]interface{}
+	root map[string]interface{}
+}
+
+func (s *mapScope) lookup(path []string) (interface{}, bool) {
+	if s.item != nil {
+		if v, ok := lookupPath(s.item, path); ok {
+			return v, true
+		}
+	}
+	return lookupPath(s.root, path)
+}
+
+// data 模板数据: 整个报告的字段, 列表元素的同名字段优先
+func (s *mapScope) data() map[string]interface{} {
+	if s.item == nil {
+		return s.root
+	}
+	data := make(map[string]interface{}, len(s.root)+len(s.item))
+	for k, v := range s.root {
+		data[k] = v
+	}
+	for k, v := range s.item {
+		data[k] = v
+	}
+	return data
+}
+
+// lookupPath 按路径查找字段
+func lookupPath(m map[string]interface{}, path []string) (interface{}, bool) {
+	var cur interface{} = m
+	for _, key := range path {
+		obj, ok := cur.(map[string]interface{})
+		if !ok {
+			return nil, false
+		}
+		if cur, ok = obj[key]; !ok {
+			return nil, false
+		}
+	}
+	return cur, true
+}
+
+// resolvable 判断字段路径在任一类型中存在
+func resolvable(types []reflect.Type, path []string) bool {
+	for _, t := range types {
+		if _, ok := fieldType(t, path); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// fieldType 按JSON字段名解析路径对应的类型
+func fieldType(t reflect.Type, path []string) (reflect.Type, bool) {
+	for _, key := range path {
+		t = derefType(t)
+		switch t.Kind() {
+		case reflect.Struct:
+			f, ok := jsonField(t, key)
+			if !ok {
+				return nil, false
+			}
+			t = f.Type
+		case reflect.Map:
+			t = t.Elem()
+		default:
+			return nil, false
+		}
+	}
+	return t, true
+}
+
+// jsonField 按JSON标签查找结构体字段
+func jsonField(t reflect.Type, name string) (reflect.StructField, bool) {
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		tag := strings.Split(f.Tag.Get("json"), ",")[0]
+		if tag == "-" || f.PkgPath != "" {
+			continue
+		}
+		if tag == name || tag == "" && f.Name == name {
+			return f, true
+		}
+	}
+	return reflect.StructField{}, false
+}
+
+func derefType(t reflect.Type) reflect.Type {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	return t
+}
@@ -0,0 +1,246 @@
+package rules
+
+import (
+	"inspection-tool/internal/checks"
+	"inspection-tool/internal/waivers"
+	"inspection-tool/pkg/models"
+	"strings"
+	"testing"
+)
+
+func TestParseExpression(t *testing.T) {
+	s := &mapScope{root: map[string]interface{}{
+		"group":  "db",
+		"tags":   []interface{}{"prod", "primary"},
+		"memory": map[string]interface{}{"usage_percent": number(85.5)},
+		"host":   "db01.example.com",
+	}}
+
+	tests := []struct {
+		expr string
+		want bool
+	}{
+		{`memory.usage_percent > 80 and group == "db"`, true},
+		{`memory.usage_percent > 90 || group == 'web'`, false},
+		{`not (memory.usage_percent <= 80)`, true},
+		{`"prod" in tags`, true},
+		{`group in ["web", "cache"]`, false},
+		{`host matches "^db[0-9]+\\."`, true},
+		{`missing.field > 0`, false},
+		{`memory.usage_percent != 85.5`, false},
+		{`memory.usage_percent > -1`, true},
+	}
+
+	for _, tt := range tests {
+		n, err := parse(tt.expr)
+		if err != nil {
+			t.Fatalf("parse(%q) failed: %v", tt.expr, err)
+		}
+		got, err := evalBool(n, s)
+		if err != nil {
+			t.Fatalf("eval(%q) failed: %v", tt.expr, err)
+		}
+		if got != tt.want {
+			t.Errorf("eval(%q) = %v, want %v", tt.expr, got, tt.want)
+		}
+	}
+
+	for _, expr := range []string{`a >`, `(a > 1`, `a > 1 b`, `"open`, `a matches "["`, `a # b`} {
+		if _, err := parse(expr); err == nil {
+			t.Errorf("Expected parse error for %q", expr)
+		}
+	}
+}
+
+func TestServerRule(t *testing.T) {
+	cs, err := Parse([]byte(`
+rules:
+  - id: db-memory
+    target: server
+    when: memory.usage_percent > 80 and group == "db"
+    severity: warning
+    category: memory
+    message: "数据库主机内存使用率 {{printf \"%.1f\" .memory.usage_percent}}%"
+    suggestion: "检查 {{.host}} 的缓冲池配置"
+  - id: data-disk
+    target: server
+    each: disk
+    when: mount_point matches "^/data" and usage_percent > 70
+    severity: critical
+    message: "{{.host}} {{.mount_point}} 使用率 {{.usage_percent}}%"
+`))
+	if err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+	if len(cs) != 2 || cs[0].ID() != "rule.db-memory" || cs[0].Target() != checks.TargetServer {
+		t.Fatalf("Unexpected checks: %+v", cs)
+	}
+
+	report := &models.ServerReport{
+		Host:   "db01",
+		Group:  "db",
+		Memory: models.MemoryMetrics{UsagePercent: 85.25},
+		Disk: []models.DiskMetrics{
+			{MountPoint: "/", UsagePercent: 90},
+			{MountPoint: "/data1", UsagePercent: 75},
+			{MountPoint: "/data2", UsagePercent: 20},
+		},
+	}
+	issues := cs[0].Evaluate(&checks.Input{Server: report})
+	if len(issues) != 1 || issues[0].Message != "数据库主机内存使用率 85.2%" || issues[0].Suggestion != "检查 db01 的缓冲池配置" {
+		t.Errorf("Unexpected memory rule issues: %+v", issues)
+	}
+
+	issues = cs[1].Evaluate(&checks.Input{Server: report})
+	if len(issues) != 1 || issues[0].Message != "db01 /data1 使用率 75%" {
+		t.Errorf("Unexpected disk rule issues: %+v", issues)
+	}
+
+	report.Group = "web"
+	if issues := cs[0].Evaluate(&checks.Input{Server: report}); len(issues) != 0 {
+		t.Errorf("Expected no issue for other groups, got %+v", issues)
+	}
+}
+
+func TestK8sRule(t *testing.T) {
+	cs, err := Parse([]byte(`
+rules:
+  - id: pod-restarts
+    target: k8s
+    each: pods
+    when: restart_count > 3 and namespace != "kube-system"
+    severity: warning
+    message: "Pod {{.namespace}}/{{.name}} restarted {{.restart_count}} times"
+`))
+	if err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+
+	report := &models.K8sReport{Pods: []models.PodMetrics{
+		{Name: "api-1", Namespace: "default", RestartCount: 5},
+		{Name: "coredns", Namespace: "kube-system", RestartCount: 10},
+		{Name: "api-2", Namespace: "default", RestartCount: 1},
+	}}
+	registry := checks.NewRegistry()
+	for _, c := range cs {
+		if err := registry.Register(c); err != nil {
+			t.Fatal(err)
+		}
+	}
+	set, err := registry.Select(nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	issues := set.Evaluate(checks.TargetK8s, &checks.Input{K8s: report})
+	if len(issues) != 1 {
+		t.Fatalf("Expected 1 issue, got %+v", issues)
+	}
+	issue := issues[0]
+	if issue.CheckID != "rule.pod-restarts" || issue.Level != "warning" || issue.Category != "custom" {
+		t.Errorf("Unexpected issue fields: %+v", issue)
+	}
+	if issue.Message != "Pod default/api-1 restarted 5 times" {
+		t.Errorf("Unexpected message: %q", issue.Message)
+	}
+}
+
+func TestEachResource(t *testing.T) {
+	cs, err := Parse([]byte(`
+rules:
+  - id: pod-restarts
+    target: k8s
+    each: pods
+    when: restart_count > 3
+    severity: warning
+    message: "Pod {{.name}} restarted"
+  - id: disk-usage
+    target: server
+    each: disk
+    when: usage_percent > 50
+    severity: warning
+    message: "{{.mount_point}}"
+`))
+	if err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+
+	pods := cs[0].Evaluate(&checks.Input{K8s: &models.K8sReport{Pods: []models.PodMetrics{
+		{Name: "a", Namespace: "default", RestartCount: 5},
+		{Name: "b", Namespace: "default", RestartCount: 5},
+	}}})
+	if len(pods) != 2 || pods[0].ResourceKind != models.ResourcePod || pods[0].ResourceName != "a" || pods[0].Namespace != "default" {
+		t.Fatalf("Expected pod resources on issues, got %+v", pods)
+	}
+	for i := range pods {
+		pods[i].CheckID = cs[0].ID()
+	}
+	models.IdentifyIssues(pods, "https://10.0.0.10:6443")
+	if pods[0].Fingerprint == pods[1].Fingerprint {
+		t.Errorf("Expected each pod to have its own fingerprint, got %q", pods[0].Fingerprint)
+	}
+
+	list, err := waivers.Parse([]byte(`
+waivers:
+  - {pod: a, reason: r, owner: o, expires: "2999-01-01"}
+`))
+	if err != nil {
+		t.Fatalf("waivers.Parse failed: %v", err)
+	}
+	pods = list.Apply(pods, "")
+	if !pods[0].Suppressed || pods[1].Suppressed {
+		t.Errorf("Expected waiver for pod a to suppress only its issue, got %v / %v", pods[0].Suppressed, pods[1].Suppressed)
+	}
+
+	disks := cs[1].Evaluate(&checks.Input{Server: &models.ServerReport{Disk: []models.DiskMetrics{
+		{MountPoint: "/", UsagePercent: 60},
+		{MountPoint: "/data", UsagePercent: 70},
+	}}})
+	if len(disks) != 2 || disks[1].ResourceKind != models.ResourceDisk || disks[1].ResourceName != "/data" {
+		t.Errorf("Expected disk resources on issues, got %+v", disks)
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	tests := map[string]string{
+		"unknown field": `
+rules:
+  - {id: a, target: server, severity: warning, when: "memory.usage > 1", message: m}`,
+		"not a list field": `
+rules:
+  - {id: a, target: server, each: memory, severity: warning, when: "true", message: m}`,
+		"severity": `
+rules:
+  - {id: a, target: server, severity: high, when: "true", message: m}`,
+		"target": `
+rules:
+  - {id: a, target: db, severity: warning, when: "true", message: m}`,
+		"duplicate": `
+rules:
+  - {id: a, target: server, severity: warning, when: "true", message: m}
+  - {id: a, target: server, severity: warning, when: "true", message: m}`,
+		"template": `
+rules:
+  - {id: a, target: server, severity: warning, when: "true", message: "{{.host"}`,
+	}
+
+	for name, data := range tests {
+		if _, err := Parse([]byte(data)); err == nil {
+			t.Errorf("%s: expected error", name)
+		}
+	}
+}
+
+func TestEvaluateError(t *testing.T) {
+	cs, err := Parse([]byte(`
+rules:
+  - {id: bad, target: server, severity: warning, when: "host > 1", message: m}`))
+	if err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+
+	issues := cs[0].Evaluate(&checks.Input{Server: &models.ServerReport{Host: "web01"}})
+	if len(issues) != 1 || issues[0].Level != "info" || !strings.Contains(issues[0].Message, "bad") {
+		t.Errorf("Expected an info issue describing the failure, got %+v", issues)
+	}
+}
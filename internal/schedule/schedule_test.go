@@ -0,0 +1,93 @@
+package schedule
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseNext(t *testing.T) {
+	// 2024-01-01 为周一
+	from := time.Date(2024, 1, 1, 10, 30, 15, 0, time.UTC)
+
+	tests := []struct {
+		spec string
+		next time.Time
+	}{
+		{"* * * * *", time.Date(2024, 1, 1, 10, 31, 0, 0, time.UTC)},
+		{"*/15 * * * *", time.Date(2024, 1, 1, 10, 45, 0, 0, time.UTC)},
+		{"0 2 * * *", time.Date(2024, 1, 2, 2, 0, 0, 0, time.UTC)},
+		{"30 10 * * *", time.Date(2024, 1, 2, 10, 30, 0, 0, time.UTC)},
+		{"0 9-17/4 * * *", time.Date(2024, 1, 1, 13, 0, 0, 0, time.UTC)},
+		{"5,35 * * * *", time.Date(2024, 1, 1, 10, 35, 0, 0, time.UTC)},
+		{"10/20 * * * *", time.Date(2024, 1, 1, 10, 50, 0, 0, time.UTC)},
+		{"0 0 * * 0", time.Date(2024, 1, 7, 0, 0, 0, 0, time.UTC)},
+		{"0 0 * * 7", time.Date(2024, 1, 7, 0, 0, 0, 0, time.UTC)},
+		{"0 0 29 2 *", time.Date(2024, 2, 29, 0, 0, 0, 0, time.UTC)},
+		// 日和周都有限定时满足其一即可: 15日或周五
+		{"0 0 15 * 5", time.Date(2024, 1, 5, 0, 0, 0, 0, time.UTC)},
+		// 以*开头的周字段不限定, 只按日匹配
+		{"0 0 15 * */2", time.Date(2024, 1, 15, 0, 0, 0, 0, time.UTC)},
+		{"@hourly", time.Date(2024, 1, 1, 11, 0, 0, 0, time.UTC)},
+		{"@daily", time.Date(2024, 1, 2, 0, 0, 0, 0, time.UTC)},
+		{"@weekly", time.Date(2024, 1, 7, 0, 0, 0, 0, time.UTC)},
+		{"@monthly", time.Date(2024, 2, 1, 0, 0, 0, 0, time.UTC)},
+		{"@yearly", time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)},
+		{"@every 90m", from.Add(90 * time.Minute)},
+	}
+	for _, tt := range tests {
+		s, err := Parse(tt.spec)
+		if err != nil {
+			t.Errorf("%q: %v", tt.spec, err)
+			continue
+		}
+		if next := s.Next(from); !next.Equal(tt.next) {
+			t.Errorf("%q: next = %v, want %v", tt.spec, next, tt.next)
+		}
+	}
+}
+
+func TestNextNeverMatches(t *testing.T) {
+	s, err := Parse("0 0 30 2 *")
+	if err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+	if next := s.Next(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)); !next.IsZero() {
+		t.Errorf("expected no next time for Feb 30, got %v", next)
+	}
+}
+
+func TestNextHalfHourZone(t *testing.T) {
+	// 与UTC相差半小时的时区按本地整点执行
+	zone := time.FixedZone("IST", 5*3600+1800)
+	s, err := Parse("0 */6 * * *")
+	if err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+	next := s.Next(time.Date(2024, 1, 1, 7, 10, 0, 0, zone))
+	if want := time.Date(2024, 1, 1, 12, 0, 0, 0, zone); !next.Equal(want) {
+		t.Errorf("next = %v, want %v", next, want)
+	}
+}
+
+func TestParseInvalid(t *testing.T) {
+	for _, spec := range []string{
+		"",
+		"* * * *",
+		"60 * * * *",
+		"* 24 * * *",
+		"* * 0 * *",
+		"* * * 13 *",
+		"* * * * 8",
+		"*/0 * * * *",
+		"5-1 * * * *",
+		"a * * * *",
+		"@every",
+		"@every 10ms",
+		"@every soon",
+		"@fortnightly",
+	} {
+		if _, err := Parse(spec); err == nil {
+			t.Errorf("expected error for %q", spec)
+		}
+	}
+}
@@ -0,0 +1,352 @@
+package server
+
+import (
+	"fmt"
+	"inspection-tool/internal/checks"
+	"inspection-tool/pkg/models"
+	"strings"
+)
+
+// 内置服务器检查项
+func init() {
+	for _, c := range serverChecks() {
+		checks.Register(c)
+	}
+}
+
+// serverCheck 创建服务器检查项
+func serverCheck(id, category string, severity models.Severity, evaluate func(in *checks.Input) []models.Issue) checks.Check {
+	return checks.New(id, category, severity, checks.TargetServer, evaluate)
+}
+
+// serverChecks 返回内置服务器检查项
+func serverChecks() []checks.Check {
+	return []checks.Check{
+		serverCheck("server.collector.incomplete", "collector", "info", checkCollectors),
+
+		serverCheck("server.cpu.load", "cpu", "critical", func(in *checks.Input) []models.Issue {
+			r, t := in.Server, in.ServerThresholds
+			if !loadPerCoreExceeded(in) {
+				return nil
+			}
+			issue := models.Issue{
+				Message:    fmt.Sprintf("CPU负载过高: %.2f (核心数: %d)", r.CPU.Load1, r.CPU.CoreCount),
+				Details:    fmt.Sprintf("1分钟平均负载超过核心数的%.1f倍", t.CPU.LoadPerCore),
+				Suggestion: "检查高CPU进程,考虑优化或扩容",
+			}.Observe(r.CPU.Load1, float64(r.CPU.CoreCount)*t.CPU.LoadPerCore, "")
+			return []models.Issue{issue}
+		}),
+
+		// 负载已超过核心数倍数时只报告严重问题
+		serverCheck("server.cpu.load_average", "cpu", "warning", func(in *checks.Input) []models.Issue {
+			r, t := in.Server, in.ServerThresholds
+			if loadPerCoreExceeded(in) {
+				return nil
+			}
+			var exceeded []string
+			if t.CPU.Load1Min > 0 && r.CPU.Load1 > t.CPU.Load1Min {
+				exceeded = append(exceeded, fmt.Sprintf("1分钟 %.2f > %.2f", r.CPU.Load1, t.CPU.Load1Min))
+			}
+			if t.CPU.Load5Min > 0 && r.CPU.Load5 > t.CPU.Load5Min {
+				exceeded = append(exceeded, fmt.Sprintf("5分钟 %.2f > %.2f", r.CPU.Load5, t.CPU.Load5Min))
+			}
+			if t.CPU.Load15Min > 0 && r.CPU.Load15 > t.CPU.Load15Min {
+				exceeded = append(exceeded, fmt.Sprintf("15分钟 %.2f > %.2f", r.CPU.Load15, t.CPU.Load15Min))
+			}
+			if len(exceeded) == 0 {
+				return nil
+			}
+			return []models.Issue{{
+				Message:    fmt.Sprintf("CPU负载偏高: %.2f / %.2f / %.2f", r.CPU.Load1, r.CPU.Load5, r.CPU.Load15),
+				Details:    strings.Join(exceeded, "; "),
+				Suggestion: "关注负载趋势,检查高CPU进程",
+			}}
+		}),
+
+		serverCheck("server.cpu.usage", "cpu", "warning", func(in *checks.Input) []models.Issue {
+			r := in.Server
+			if r.CPU.UsagePercent <= in.ServerThresholds.CPU.UsagePercent {
+				return nil
+			}
+			issue := models.Issue{
+				Message:    fmt.Sprintf("CPU使用率过高: %.2f%%", r.CPU.UsagePercent),
+				Details:    fmt.Sprintf("用户态: %.2f%%, 内核态: %.2f%%", r.CPU.UserPercent, r.CPU.SystemPercent),
+				Suggestion: "检查高CPU进程,考虑优化或扩容",
+			}.Observe(r.CPU.UsagePercent, in.ServerThresholds.CPU.UsagePercent, "%")
+			return []models.Issue{issue}
+		}),
+
+		serverCheck("server.cpu.iowait", "cpu", "warning", func(in *checks.Input) []models.Issue {
+			r := in.Server
+			if r.CPU.IowaitPercent <= in.ServerThresholds.Disk.IowaitPercent {
+				return nil
+			}
+			issue := models.Issue{
+				Message:    fmt.Sprintf("IO等待时间过高: %.2f%%", r.CPU.IowaitPercent),
+				Details:    "CPU大量时间在等待IO操作",
+				Suggestion: "检查磁盘IO性能,优化IO密集型操作",
+			}.Observe(r.CPU.IowaitPercent, in.ServerThresholds.Disk.IowaitPercent, "%")
+			return []models.Issue{issue}
+		}),
+
+		// 上下文切换次数为开机以来的累计值,按运行时间折算为平均速率
+		serverCheck("server.cpu.context_switches", "cpu", "warning", func(in *checks.Input) []models.Issue {
+			r, t := in.Server, in.ServerThresholds
+			if t.CPU.ContextSwitchRate <= 0 || r.OS.Uptime <= 0 {
+				return nil
+			}
+			rate := float64(r.CPU.ContextSwitches) / float64(r.OS.Uptime)
+			if rate <= t.CPU.ContextSwitchRate {
+				return nil
+			}
+			issue := models.Issue{
+				Message:    fmt.Sprintf("上下文切换频繁: %.0f次/秒", rate),
+				Details:    fmt.Sprintf("开机以来累计: %d", r.CPU.ContextSwitches),
+				Suggestion: "检查线程数过多或锁竞争的进程",
+			}.Observe(rate, t.CPU.ContextSwitchRate, "/s")
+			return []models.Issue{issue}
+		}),
+
+		serverCheck("server.cpu.blocked_tasks", "cpu", "warning", func(in *checks.Input) []models.Issue {
+			r := in.Server
+			if r.CPU.BlockedTasks <= in.ServerThresholds.System.BlockedTasks {
+				return nil
+			}
+			issue := models.Issue{
+				Message:    fmt.Sprintf("阻塞任务数量过多: %d", r.CPU.BlockedTasks),
+				Details:    "有大量任务处于不可中断睡眠状态",
+				Suggestion: "检查IO子系统和锁竞争问题",
+			}.Observe(float64(r.CPU.BlockedTasks), float64(in.ServerThresholds.System.BlockedTasks), "")
+			return []models.Issue{issue}
+		}),
+
+		serverCheck("server.memory.usage", "memory", "critical", func(in *checks.Input) []models.Issue {
+			r := in.Server
+			if r.Memory.UsagePercent <= in.ServerThresholds.Memory.UsagePercent {
+				return nil
+			}
+			issue := models.Issue{
+				Message:    fmt.Sprintf("内存使用率过高: %.2f%%", r.Memory.UsagePercent),
+				Details:    fmt.Sprintf("可用内存: %d MB", r.Memory.AvailableMB),
+				Suggestion: "释放内存或增加物理内存",
+			}.Observe(r.Memory.UsagePercent, in.ServerThresholds.Memory.UsagePercent, "%")
+			return []models.Issue{issue}
+		}),
+
+		// 使用率已超过阈值时只报告严重问题
+		serverCheck("server.memory.available", "memory", "warning", func(in *checks.Input) []models.Issue {
+			r, t := in.Server, in.ServerThresholds
+			if r.Memory.UsagePercent > t.Memory.UsagePercent || !r.Collected("memory.meminfo") {
+				return nil
+			}
+			if r.Memory.TotalMB <= 0 || r.Memory.AvailableMB >= t.Memory.AvailableMB {
+				return nil
+			}
+			issue := models.Issue{
+				Message:    fmt.Sprintf("可用内存不足: %d MB", r.Memory.AvailableMB),
+				Details:    fmt.Sprintf("总内存: %d MB", r.Memory.TotalMB),
+				Suggestion: "释放内存或增加物理内存",
+			}.Observe(float64(r.Memory.AvailableMB), float64(t.Memory.AvailableMB), "MB")
+			return []models.Issue{issue}
+		}),
+
+		serverCheck("server.memory.swap", "memory", "warning", func(in *checks.Input) []models.Issue {
+			r := in.Server
+			if r.Memory.SwapPercent <= in.ServerThresholds.Memory.SwapPercent {
+				return nil
+			}
+			issue := models.Issue{
+				Message:    fmt.Sprintf("Swap使用率过高: %.2f%%", r.Memory.SwapPercent),
+				Details:    "系统在使用交换空间,可能影响性能",
+				Suggestion: "检查内存泄漏,考虑增加物理内存",
+			}.Observe(r.Memory.SwapPercent, in.ServerThresholds.Memory.SwapPercent, "%")
+			return []models.Issue{issue}
+		}),
+
+		serverCheck("server.memory.pressure", "memory", "critical", func(in *checks.Input) []models.Issue {
+			r := in.Server
+			if !strings.Contains(r.Memory.Pressure, "some") && !strings.Contains(r.Memory.Pressure, "full") {
+				return nil
+			}
+			return []models.Issue{{
+				Message:    "检测到内存压力",
+				Details:    fmt.Sprintf("内存压力状态: %s", r.Memory.Pressure),
+				Suggestion: "系统正在经历内存压力,需要立即处理",
+			}}
+		}),
+
+		serverCheck("server.disk.usage", "disk", "critical", func(in *checks.Input) []models.Issue {
+			var issues []models.Issue
+			for _, disk := range in.Server.Disk {
+				if disk.UsagePercent > in.ServerThresholds.Disk.UsagePercent {
+					issues = append(issues, models.Issue{
+						Message:      fmt.Sprintf("磁盘空间不足: %s (%.2f%%)", disk.MountPoint, disk.UsagePercent),
+						Details:      fmt.Sprintf("剩余空间: %.2f GB", disk.FreeGB),
+						Suggestion:   "清理磁盘空间或扩容",
+						ResourceKind: models.ResourceDisk,
+						ResourceName: disk.MountPoint,
+					}.Observe(disk.UsagePercent, in.ServerThresholds.Disk.UsagePercent, "%"))
+				}
+			}
+			return issues
+		}),
+
+		serverCheck("server.disk.inodes", "disk", "warning", func(in *checks.Input) []models.Issue {
+			var issues []models.Issue
+			for _, disk := range in.Server.Disk {
+				if disk.InodesPercent > in.ServerThresholds.Disk.InodeUsagePercent {
+					issues = append(issues, models.Issue{
+						Message:      fmt.Sprintf("Inode使用率过高: %s (%.2f%%)", disk.MountPoint, disk.InodesPercent),
+						Details:      fmt.Sprintf("剩余Inode: %d", disk.InodesFree),
+						Suggestion:   "删除不需要的小文件",
+						ResourceKind: models.ResourceDisk,
+						ResourceName: disk.MountPoint,
+					}.Observe(disk.InodesPercent, in.ServerThresholds.Disk.InodeUsagePercent, "%"))
+				}
+			}
+			return issues
+		}),
+
+		serverCheck("server.disk.io_util", "disk", "warning", func(in *checks.Input) []models.Issue {
+			var issues []models.Issue
+			for _, disk := range in.Server.Disk {
+				if disk.IOUtilPercent > in.ServerThresholds.Disk.IOUtilPercent {
+					issues = append(issues, models.Issue{
+						Message:      fmt.Sprintf("磁盘IO利用率过高: %s (%.2f%%)", disk.Device, disk.IOUtilPercent),
+						Details:      fmt.Sprintf("平均等待时间: %.2f ms", disk.AvgAwaitMs),
+						Suggestion:   "优化IO操作或升级存储",
+						ResourceKind: models.ResourceDevice,
+						ResourceName: disk.Device,
+					}.Observe(disk.IOUtilPercent, in.ServerThresholds.Disk.IOUtilPercent, "%"))
+				}
+			}
+			return issues
+		}),
+
+		serverCheck("server.disk.io_errors", "disk", "critical", func(in *checks.Input) []models.Issue {
+			var issues []models.Issue
+			for _, disk := range in.Server.Disk {
+				if disk.IOErrors > 0 {
+					issues = append(issues, models.Issue{
+						Message:      fmt.Sprintf("检测到磁盘IO错误: %s", disk.Device),
+						Details:      fmt.Sprintf("错误计数: %d", disk.IOErrors),
+						Suggestion:   "检查磁盘健康状态,可能需要更换磁盘",
+						ResourceKind: models.ResourceDevice,
+						ResourceName: disk.Device,
+					}.Observe(float64(disk.IOErrors), 0, ""))
+				}
+			}
+			return issues
+		}),
+
+		serverCheck("server.network.error_rate", "network", "warning", func(in *checks.Input) []models.Issue {
+			var issues []models.Issue
+			for _, iface := range in.Server.Network.Interfaces {
+				if iface.ErrorRate > in.ServerThresholds.Network.PacketErrorRate {
+					issues = append(issues, models.Issue{
+						Message:      fmt.Sprintf("网络接口错误率过高: %s (%.4f%%)", iface.Name, iface.ErrorRate*100),
+						Details:      fmt.Sprintf("接收错误: %d, 发送错误: %d", iface.RxErrors, iface.TxErrors),
+						Suggestion:   "检查网络硬件和线缆",
+						ResourceKind: models.ResourceInterface,
+						ResourceName: iface.Name,
+					}.Observe(iface.ErrorRate, in.ServerThresholds.Network.PacketErrorRate, ""))
+				}
+			}
+			return issues
+		}),
+
+		serverCheck("server.network.retransmits", "network", "warning", func(in *checks.Input) []models.Issue {
+			tcp := in.Server.Network.TCPConnections
+			if tcp.RetransmitRate <= in.ServerThresholds.Network.RetransmitRate {
+				return nil
+			}
+			issue := models.Issue{
+				Message:    fmt.Sprintf("TCP重传率过高: %.2f%%", tcp.RetransmitRate*100),
+				Details:    fmt.Sprintf("重传次数: %d", tcp.Retransmits),
+				Suggestion: "检查网络质量和TCP参数配置",
+			}.Observe(tcp.RetransmitRate, in.ServerThresholds.Network.RetransmitRate, "")
+			return []models.Issue{issue}
+		}),
+
+		serverCheck("server.network.time_wait", "network", "info", func(in *checks.Input) []models.Issue {
+			tcp := in.Server.Network.TCPConnections
+			if tcp.TimeWait <= in.ServerThresholds.Network.TimeWait {
+				return nil
+			}
+			issue := models.Issue{
+				Message:    fmt.Sprintf("TIME_WAIT连接数过多: %d", tcp.TimeWait),
+				Details:    "可能影响可用端口数",
+				Suggestion: "调整net.ipv4.tcp_tw_reuse参数",
+			}.Observe(float64(tcp.TimeWait), float64(in.ServerThresholds.Network.TimeWait), "")
+			return []models.Issue{issue}
+		}),
+
+		serverCheck("server.system.file_handles", "system", "warning", func(in *checks.Input) []models.Issue {
+			sys := in.Server.System
+			if sys.FileHandlesPercent <= in.ServerThresholds.System.FileHandleUsagePercent {
+				return nil
+			}
+			issue := models.Issue{
+				Message:    fmt.Sprintf("文件句柄使用率过高: %.2f%%", sys.FileHandlesPercent),
+				Details:    fmt.Sprintf("已分配: %d, 最大值: %d", sys.FileHandlesAllocated, sys.FileHandlesMax),
+				Suggestion: "增加fs.file-max参数或排查句柄泄漏",
+			}.Observe(sys.FileHandlesPercent, in.ServerThresholds.System.FileHandleUsagePercent, "%")
+			return []models.Issue{issue}
+		}),
+
+		serverCheck("server.system.time_offset", "system", "warning", func(in *checks.Input) []models.Issue {
+			offset, limit := in.Server.System.TimeOffset, in.ServerThresholds.System.TimeOffsetSeconds
+			if offset <= limit && offset >= -limit {
+				return nil
+			}
+			issue := models.Issue{
+				Message:    fmt.Sprintf("时间偏差过大: %.2f秒", offset),
+				Details:    "系统时间与NTP服务器不同步",
+				Suggestion: "配置NTP服务并同步时间",
+			}.Observe(offset, limit, "s")
+			return []models.Issue{issue}
+		}),
+	}
+}
+
+// loadPerCoreExceeded 判断1分钟负载是否超过核心数的倍数
+func loadPerCoreExceeded(in *checks.Input) bool {
+	r := in.Server
+	return r.CPU.CoreCount > 0 && r.CPU.Load1 > float64(r.CPU.CoreCount)*in.ServerThresholds.CPU.LoadPerCore
+}
+
+// checkCollectors 为未完整采集的部分生成问题, 提示相关指标不可用
+func checkCollectors(in *checks.Input) []models.Issue {
+	var issues []models.Issue
+	for _, c := range in.Server.Collectors {
+		if c.Status == models.CollectorOK {
+			continue
+		}
+
+		var details []string
+		for _, f := range c.Failures {
+			reason := f.Stderr
+			if reason == "" {
+				reason = f.Error
+			}
+			details = append(details, fmt.Sprintf("%s: %s", f.Command, reason))
+		}
+
+		issue := models.Issue{
+			Message:      fmt.Sprintf("部分%s指标未采集: %s", c.Name, strings.Join(c.Missing, ", ")),
+			Details:      strings.Join(details, "; "),
+			Suggestion:   "安装缺失的命令或检查巡检用户权限",
+			ResourceKind: models.ResourceCollector,
+			ResourceName: c.Name,
+		}
+		if c.Status == models.CollectorFailed {
+			issue.Level = "warning"
+			issue.Message = fmt.Sprintf("%s指标采集失败", c.Name)
+		}
+		if len(c.Missing) == 0 {
+			issue.Message = fmt.Sprintf("%s采集命令执行出错, 已使用部分输出", c.Name)
+		}
+		issues = append(issues, issue)
+	}
+	return issues
+}
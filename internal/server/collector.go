@@ -0,0 +1,88 @@
+package server
+
+import (
+	"inspection-tool/pkg/models"
+	"strings"
+)
+
+// commandRunner 执行远程命令, 分别返回标准输出和标准错误
+type commandRunner interface {
+	Run(cmd string) (string, string, error)
+}
+
+// collection 记录一个采集步骤中各命令的执行结果
+type collection struct {
+	runner    commandRunner
+	status    models.CollectorStatus
+	attempted []string // 尝试采集的指标
+}
+
+// newCollection 创建采集步骤记录
+func newCollection(runner commandRunner, name string) *collection {
+	return &collection{
+		runner: runner,
+		status: models.CollectorStatus{Name: name, Status: models.CollectorOK},
+	}
+}
+
+// run 执行采集命令, metric为该命令对应的指标
+// 命令返回错误或报告命令不存在时记录失败; 没有可用输出时将指标记为未采集
+func (c *collection) run(metric, cmd string) string {
+	c.attempted = appendMetric(c.attempted, metric)
+
+	stdout, stderr, err := c.runner.Run(cmd)
+	notFound := strings.Contains(stderr, "not found")
+	empty := strings.TrimSpace(stdout) == ""
+	if err == nil && !notFound && (!empty || strings.TrimSpace(stderr) == "") {
+		return stdout
+	}
+
+	failure := models.CommandFailure{
+		Command: strings.TrimSpace(cmd),
+		Stderr:  strings.TrimSpace(stderr),
+	}
+	if err != nil {
+		failure.Error = err.Error()
+	}
+	c.status.Failures = append(c.status.Failures, failure)
+
+	// 命令报错但仍有输出(如df对个别挂载点无权限)时保留输出
+	if empty || notFound {
+		c.status.Missing = appendMetric(c.status.Missing, metric)
+		return ""
+	}
+	return stdout
+}
+
+// collected 判断指标是否采集成功
+func (c *collection) collected(metric string) bool {
+	for _, m := range c.status.Missing {
+		if m == metric {
+			return false
+		}
+	}
+	return true
+}
+
+// finish 根据命令执行结果确定采集步骤状态
+func (c *collection) finish() models.CollectorStatus {
+	switch {
+	case len(c.status.Failures) == 0:
+		c.status.Status = models.CollectorOK
+	case len(c.status.Missing) == len(c.attempted):
+		c.status.Status = models.CollectorFailed
+	default:
+		c.status.Status = models.CollectorDegraded
+	}
+	return c.status
+}
+
+// appendMetric 追加不重复的指标名
+func appendMetric(list []string, metric string) []string {
+	for _, m := range list {
+		if m == metric {
+			return list
+		}
+	}
+	return append(list, metric)
+}
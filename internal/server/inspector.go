@@ -2,7 +2,10 @@ package server
 
 import (
 	"fmt"
+	"inspection-tool/internal/checks"
+	"inspection-tool/internal/config"
 	"inspection-tool/internal/ssh"
+	"inspection-tool/internal/waivers"
 	"inspection-tool/pkg/models"
 	"strings"
 	"time"
@@ -10,59 +13,74 @@ import (
 
 // Inspector 服务器巡检器
 type Inspector struct {
-	sshClient *ssh.Client
-	localIP   string
+	sshClient      *ssh.Client
+	runner         commandRunner
+	host           string
+	localIP        string
+	thresholds     config.ServerThresholds
+	checks         *checks.Set
+	waivers        *waivers.List
+	history        checks.History
+	sampleInterval time.Duration // 磁盘IO和网络速率两次采样的间隔
 }
 
-// NewInspector 创建巡检器
-func NewInspector(sshClient *ssh.Client) (*Inspector, error) {
+// NewInspector 创建巡检器, thresholds为nil时使用默认阈值
+func NewInspector(sshClient *ssh.Client, thresholds *config.ServerThresholds) (*Inspector, error) {
 	localIP, err := ssh.GetLocalIP()
 	if err != nil {
 		localIP = ""
 	}
 
+	t := config.DefaultServerThresholds()
+	if thresholds != nil {
+		t = *thresholds
+	}
+
 	return &Inspector{
-		sshClient: sshClient,
-		localIP:   localIP,
+		sshClient:      sshClient,
+		runner:         sshClient,
+		host:           sshClient.GetHost(),
+		localIP:        localIP,
+		thresholds:     t,
+		sampleInterval: time.Second,
 	}, nil
 }
 
 // Inspect 执行巡检
+// 单个采集步骤失败不会中断巡检, 各步骤的状态记录在报告的Collectors中;
+// 所有步骤均失败时返回错误
 func (i *Inspector) Inspect() (*models.ServerReport, error) {
 	report := &models.ServerReport{
-		Host:      i.sshClient.GetHost(),
+		Host:      i.host,
 		Timestamp: time.Now(),
 		Issues:    []models.Issue{},
 	}
 
-	// 收集操作系统信息
-	if err := i.collectOSInfo(report); err != nil {
-		return nil, fmt.Errorf("failed to collect OS info: %w", err)
-	}
-
-	// 收集CPU指标
-	if err := i.collectCPUMetrics(report); err != nil {
-		return nil, fmt.Errorf("failed to collect CPU metrics: %w", err)
-	}
-
-	// 收集内存指标
-	if err := i.collectMemoryMetrics(report); err != nil {
-		return nil, fmt.Errorf("failed to collect memory metrics: %w", err)
-	}
-
-	// 收集磁盘指标
-	if err := i.collectDiskMetrics(report); err != nil {
-		return nil, fmt.Errorf("failed to collect disk metrics: %w", err)
-	}
-
-	// 收集网络指标
-	if err := i.collectNetworkMetrics(report); err != nil {
-		return nil, fmt.Errorf("failed to collect network metrics: %w", err)
+	collectors := []struct {
+		name    string
+		collect func(*models.ServerReport, *collection)
+	}{
+		{"os", i.collectOSInfo},
+		{"cpu", i.collectCPUMetrics},
+		{"memory", i.collectMemoryMetrics},
+		{"disk", i.collectDiskMetrics},
+		{"network", i.collectNetworkMetrics},
+		{"system", i.collectSystemMetrics},
+	}
+
+	failed := 0
+	for _, c := range collectors {
+		col := newCollection(i.runner, c.name)
+		c.collect(report, col)
+		status := col.finish()
+		if status.Status == models.CollectorFailed {
+			failed++
+		}
+		report.Collectors = append(report.Collectors, status)
 	}
 
-	// 收集系统指标
-	if err := i.collectSystemMetrics(report); err != nil {
-		return nil, fmt.Errorf("failed to collect system metrics: %w", err)
+	if failed == len(collectors) {
+		return nil, fmt.Errorf("all collectors failed: %s", firstFailure(report.Collectors))
 	}
 
 	// 分析问题
@@ -71,304 +89,157 @@ func (i *Inspector) Inspect() (*models.ServerReport, error) {
 	return report, nil
 }
 
+// firstFailure 返回第一条失败命令的错误信息
+func firstFailure(statuses []models.CollectorStatus) string {
+	for _, s := range statuses {
+		for _, f := range s.Failures {
+			if f.Stderr != "" {
+				return f.Stderr
+			}
+			if f.Error != "" {
+				return f.Error
+			}
+		}
+	}
+	return "no output"
+}
+
 // collectOSInfo 收集操作系统信息
-func (i *Inspector) collectOSInfo(report *models.ServerReport) error {
+func (i *Inspector) collectOSInfo(report *models.ServerReport, c *collection) {
 	// hostname
-	hostname, err := i.sshClient.Execute("hostname")
-	if err != nil {
-		return err
-	}
+	hostname := c.run("os.hostname", "hostname")
 
 	// 系统信息
-	osRelease, _ := i.sshClient.Execute("cat /etc/os-release 2>/dev/null || cat /etc/redhat-release 2>/dev/null")
-	kernelVer, _ := i.sshClient.Execute("uname -r")
-	uptime, _ := i.sshClient.Execute("cat /proc/uptime | awk '{print $1}'")
+	osRelease := c.run("os.version", "cat /etc/os-release 2>/dev/null || cat /etc/redhat-release")
+	kernelVer := c.run("os.kernel_version", "uname -r")
+	uptime := c.run("os.uptime", "cat /proc/uptime | awk '{print $1}'")
 
 	report.OS = models.OSInfo{
 		Hostname:  strings.TrimSpace(hostname),
 		Platform:  "linux",
-		Family:    extractOSFamily(osRelease),
-		Version:   extractOSVersion(osRelease),
 		KernelVer: strings.TrimSpace(kernelVer),
 		Uptime:    parseUptime(uptime),
 	}
-
-	return nil
+	if c.collected("os.version") {
+		report.OS.Family = extractOSFamily(osRelease)
+		report.OS.Version = extractOSVersion(osRelease)
+	}
 }
 
 // collectCPUMetrics 收集CPU指标
-func (i *Inspector) collectCPUMetrics(report *models.ServerReport) error {
+func (i *Inspector) collectCPUMetrics(report *models.ServerReport, c *collection) {
 	// CPU核心数
-	coreCount, _ := i.sshClient.Execute("grep -c ^processor /proc/cpuinfo")
-	
+	coreCount := c.run("cpu.core_count", "grep -c ^processor /proc/cpuinfo")
+
 	// 负载
-	loadavg, _ := i.sshClient.Execute("cat /proc/loadavg")
-	
+	loadavg := c.run("cpu.load", "cat /proc/loadavg")
+
 	// CPU使用率 (通过top命令获取)
-	cpuUsage, _ := i.sshClient.Execute("top -bn2 -d 0.5 | grep 'Cpu(s)' | tail -n 1")
-	
+	cpuUsage := c.run("cpu.usage", "top -bn2 -d 0.5 | grep 'Cpu(s)' | tail -n 1")
+
 	// 上下文切换和中断
-	vmstat, _ := i.sshClient.Execute("cat /proc/stat | grep -E '^(ctxt|intr|procs_running|procs_blocked)'")
-	
+	vmstat := c.run("cpu.stat", "cat /proc/stat | grep -E '^(ctxt|intr|procs_running|procs_blocked)'")
+
 	// 解析数据
 	report.CPU = parseCPUMetrics(coreCount, loadavg, cpuUsage, vmstat)
-	
-	return nil
 }
 
 // collectMemoryMetrics 收集内存指标
-func (i *Inspector) collectMemoryMetrics(report *models.ServerReport) error {
+func (i *Inspector) collectMemoryMetrics(report *models.ServerReport, c *collection) {
 	// 内存信息
-	meminfo, _ := i.sshClient.Execute("cat /proc/meminfo")
-	
-	// 内存压力 (PSI - Pressure Stall Information)
-	memPressure, _ := i.sshClient.Execute("cat /proc/pressure/memory 2>/dev/null || echo 'none'")
-	
+	meminfo := c.run("memory.meminfo", "cat /proc/meminfo")
+
+	// 内存压力 (PSI - Pressure Stall Information), 旧内核没有时视为无压力
+	memPressure := c.run("memory.pressure", "cat /proc/pressure/memory 2>/dev/null || echo 'none'")
+
 	report.Memory = parseMemoryMetrics(meminfo, memPressure)
-	
-	return nil
 }
 
 // collectDiskMetrics 收集磁盘指标
-func (i *Inspector) collectDiskMetrics(report *models.ServerReport) error {
+func (i *Inspector) collectDiskMetrics(report *models.ServerReport, c *collection) {
 	// 磁盘使用情况
-	df, _ := i.sshClient.Execute("df -BG -x tmpfs -x devtmpfs")
-	
+	df := c.run("disk.usage", "df -BG -x tmpfs -x devtmpfs")
+
 	// Inode使用情况
-	dfInodes, _ := i.sshClient.Execute("df -i -x tmpfs -x devtmpfs")
-	
+	dfInodes := c.run("disk.inodes", "df -i -x tmpfs -x devtmpfs")
+
 	// IO统计 (需要两次采样)
-	iostat1, _ := i.sshClient.Execute("cat /proc/diskstats")
-	time.Sleep(1 * time.Second)
-	iostat2, _ := i.sshClient.Execute("cat /proc/diskstats")
-	
+	iostat1 := c.run("disk.io", "cat /proc/diskstats")
+	time.Sleep(i.sampleInterval)
+	iostat2 := c.run("disk.io", "cat /proc/diskstats")
+
 	// IO错误
-	ioErrors, _ := i.sshClient.Execute("grep -r '' /sys/block/*/stat 2>/dev/null | grep -v '0 0 0 0 0 0 0 0 0 0 0' || echo ''")
-	
+	ioErrors := c.run("disk.io_errors", "grep -r '' /sys/block/*/stat 2>/dev/null | grep -v '0 0 0 0 0 0 0 0 0 0 0' || echo ''")
+
 	report.Disk = parseDiskMetrics(df, dfInodes, iostat1, iostat2, ioErrors)
-	
-	return nil
 }
 
 // collectNetworkMetrics 收集网络指标
-func (i *Inspector) collectNetworkMetrics(report *models.ServerReport) error {
+func (i *Inspector) collectNetworkMetrics(report *models.ServerReport, c *collection) {
 	// 网络接口统计 (两次采样计算速率)
-	netdev1, _ := i.sshClient.Execute("cat /proc/net/dev")
-	time.Sleep(1 * time.Second)
-	netdev2, _ := i.sshClient.Execute("cat /proc/net/dev")
-	
+	netdev1 := c.run("network.interfaces", "cat /proc/net/dev")
+	time.Sleep(i.sampleInterval)
+	netdev2 := c.run("network.interfaces", "cat /proc/net/dev")
+
 	// TCP连接统计
-	tcpStats, _ := i.sshClient.Execute("ss -tan state all | tail -n +2 | awk '{print $1}' | sort | uniq -c")
-	
+	tcpStats := c.run("network.tcp_connections", "ss -tan state all | tail -n +2 | awk '{print $1}' | sort | uniq -c")
+
 	// TCP重传
-	netstat, _ := i.sshClient.Execute("cat /proc/net/netstat | grep TcpExt")
-	
+	netstat := c.run("network.retransmits", "cat /proc/net/netstat | grep TcpExt")
+
 	report.Network = parseNetworkMetrics(netdev1, netdev2, tcpStats, netstat, i.localIP)
-	
-	return nil
 }
 
 // collectSystemMetrics 收集系统指标
-func (i *Inspector) collectSystemMetrics(report *models.ServerReport) error {
+func (i *Inspector) collectSystemMetrics(report *models.ServerReport, c *collection) {
 	// 文件句柄
-	fileHandle, _ := i.sshClient.Execute("cat /proc/sys/fs/file-nr")
-	
+	fileHandle := c.run("system.file_handles", "cat /proc/sys/fs/file-nr")
+
 	// 进程和线程数
-	procCount, _ := i.sshClient.Execute("ps aux | wc -l")
-	threadCount, _ := i.sshClient.Execute("ps -eLf | wc -l")
-	
-	// 时间同步
-	ntpStatus, _ := i.sshClient.Execute("timedatectl status 2>/dev/null || echo 'unknown'")
-	timeOffset, _ := i.sshClient.Execute("ntpq -p 2>/dev/null | tail -n 1 | awk '{print $9}' || echo '0'")
-	
+	procCount := c.run("system.process_count", "ps aux | wc -l")
+	threadCount := c.run("system.thread_count", "ps -eLf | wc -l")
+
+	// 时间同步, 命令不存在时记录为未采集而不是未同步
+	ntpStatus := c.run("system.ntp_synced", "timedatectl status")
+	timeOffset := c.run("system.time_offset", "ntpq -p | tail -n 1 | awk '{print $9}'")
+
 	// 关键内核参数
-	kernelParams, _ := i.sshClient.Execute(`
+	kernelParams := c.run("system.kernel_params", `
 		echo "net.core.somaxconn=$(cat /proc/sys/net/core/somaxconn)"
 		echo "net.ipv4.tcp_max_syn_backlog=$(cat /proc/sys/net/ipv4/tcp_max_syn_backlog)"
 		echo "fs.file-max=$(cat /proc/sys/fs/file-max)"
 		echo "vm.swappiness=$(cat /proc/sys/vm/swappiness)"
 	`)
-	
+
 	report.System = parseSystemMetrics(fileHandle, procCount, threadCount, ntpStatus, timeOffset, kernelParams)
-	
-	return nil
 }
 
-// analyzeIssues 分析问题
+// analyzeIssues 分析问题, 并对全部问题(包括采集失败)应用豁免、计算指纹
 func (i *Inspector) analyzeIssues(report *models.ServerReport) {
-	// CPU问题分析
-	if report.CPU.Load1 > float64(report.CPU.CoreCount)*2 {
-		report.Issues = append(report.Issues, models.Issue{
-			Level:    "critical",
-			Category: "cpu",
-			Message:  fmt.Sprintf("CPU负载过高: %.2f (核心数: %d)", report.CPU.Load1, report.CPU.CoreCount),
-			Details:  "1分钟平均负载超过核心数的2倍",
-			Timestamp: time.Now(),
-			Suggestion: "检查高CPU进程,考虑优化或扩容",
-		})
-	}
-	
-	if report.CPU.IowaitPercent > 30 {
-		report.Issues = append(report.Issues, models.Issue{
-			Level:    "warning",
-			Category: "cpu",
-			Message:  fmt.Sprintf("IO等待时间过高: %.2f%%", report.CPU.IowaitPercent),
-			Details:  "CPU大量时间在等待IO操作",
-			Timestamp: time.Now(),
-			Suggestion: "检查磁盘IO性能,优化IO密集型操作",
-		})
-	}
-	
-	if report.CPU.BlockedTasks > 10 {
-		report.Issues = append(report.Issues, models.Issue{
-			Level:    "warning",
-			Category: "cpu",
-			Message:  fmt.Sprintf("阻塞任务数量过多: %d", report.CPU.BlockedTasks),
-			Details:  "有大量任务处于不可中断睡眠状态",
-			Timestamp: time.Now(),
-			Suggestion: "检查IO子系统和锁竞争问题",
-		})
-	}
-	
-	// 内存问题分析
-	if report.Memory.UsagePercent > 90 {
-		report.Issues = append(report.Issues, models.Issue{
-			Level:    "critical",
-			Category: "memory",
-			Message:  fmt.Sprintf("内存使用率过高: %.2f%%", report.Memory.UsagePercent),
-			Details:  fmt.Sprintf("可用内存: %d MB", report.Memory.AvailableMB),
-			Timestamp: time.Now(),
-			Suggestion: "释放内存或增加物理内存",
-		})
-	}
-	
-	if report.Memory.SwapPercent > 50 {
-		report.Issues = append(report.Issues, models.Issue{
-			Level:    "warning",
-			Category: "memory",
-			Message:  fmt.Sprintf("Swap使用率过高: %.2f%%", report.Memory.SwapPercent),
-			Details:  "系统在使用交换空间,可能影响性能",
-			Timestamp: time.Now(),
-			Suggestion: "检查内存泄漏,考虑增加物理内存",
-		})
-	}
-	
-	if strings.Contains(report.Memory.Pressure, "some") || strings.Contains(report.Memory.Pressure, "full") {
-		report.Issues = append(report.Issues, models.Issue{
-			Level:    "critical",
-			Category: "memory",
-			Message:  "检测到内存压力",
-			Details:  fmt.Sprintf("内存压力状态: %s", report.Memory.Pressure),
-			Timestamp: time.Now(),
-			Suggestion: "系统正在经历内存压力,需要立即处理",
-		})
-	}
-	
-	// 磁盘问题分析
-	for _, disk := range report.Disk {
-		if disk.UsagePercent > 85 {
-			report.Issues = append(report.Issues, models.Issue{
-				Level:    "critical",
-				Category: "disk",
-				Message:  fmt.Sprintf("磁盘空间不足: %s (%.2f%%)", disk.MountPoint, disk.UsagePercent),
-				Details:  fmt.Sprintf("剩余空间: %.2f GB", disk.FreeGB),
-				Timestamp: time.Now(),
-				Suggestion: "清理磁盘空间或扩容",
-			})
-		}
-		
-		if disk.InodesPercent > 80 {
-			report.Issues = append(report.Issues, models.Issue{
-				Level:    "warning",
-				Category: "disk",
-				Message:  fmt.Sprintf("Inode使用率过高: %s (%.2f%%)", disk.MountPoint, disk.InodesPercent),
-				Details:  fmt.Sprintf("剩余Inode: %d", disk.InodesFree),
-				Timestamp: time.Now(),
-				Suggestion: "删除不需要的小文件",
-			})
-		}
-		
-		if disk.IOUtilPercent > 80 {
-			report.Issues = append(report.Issues, models.Issue{
-				Level:    "warning",
-				Category: "disk",
-				Message:  fmt.Sprintf("磁盘IO利用率过高: %s (%.2f%%)", disk.Device, disk.IOUtilPercent),
-				Details:  fmt.Sprintf("平均等待时间: %.2f ms", disk.AvgAwaitMs),
-				Timestamp: time.Now(),
-				Suggestion: "优化IO操作或升级存储",
-			})
-		}
-		
-		if disk.IOErrors > 0 {
-			report.Issues = append(report.Issues, models.Issue{
-				Level:    "critical",
-				Category: "disk",
-				Message:  fmt.Sprintf("检测到磁盘IO错误: %s", disk.Device),
-				Details:  fmt.Sprintf("错误计数: %d", disk.IOErrors),
-				Timestamp: time.Now(),
-				Suggestion: "检查磁盘健康状态,可能需要更换磁盘",
-			})
-		}
-	}
-	
-	// 网络问题分析
-	for _, iface := range report.Network.Interfaces {
-		if iface.ErrorRate > 0.01 {
-			report.Issues = append(report.Issues, models.Issue{
-				Level:    "warning",
-				Category: "network",
-				Message:  fmt.Sprintf("网络接口错误率过高: %s (%.4f%%)", iface.Name, iface.ErrorRate*100),
-				Details:  fmt.Sprintf("接收错误: %d, 发送错误: %d", iface.RxErrors, iface.TxErrors),
-				Timestamp: time.Now(),
-				Suggestion: "检查网络硬件和线缆",
-			})
-		}
-	}
-	
-	if report.Network.TCPConnections.RetransmitRate > 0.05 {
-		report.Issues = append(report.Issues, models.Issue{
-			Level:    "warning",
-			Category: "network",
-			Message:  fmt.Sprintf("TCP重传率过高: %.2f%%", report.Network.TCPConnections.RetransmitRate*100),
-			Details:  fmt.Sprintf("重传次数: %d", report.Network.TCPConnections.Retransmits),
-			Timestamp: time.Now(),
-			Suggestion: "检查网络质量和TCP参数配置",
-		})
-	}
-	
-	if report.Network.TCPConnections.TimeWait > 10000 {
-		report.Issues = append(report.Issues, models.Issue{
-			Level:    "info",
-			Category: "network",
-			Message:  fmt.Sprintf("TIME_WAIT连接数过多: %d", report.Network.TCPConnections.TimeWait),
-			Details:  "可能影响可用端口数",
-			Timestamp: time.Now(),
-			Suggestion: "调整net.ipv4.tcp_tw_reuse参数",
-		})
-	}
-	
-	// 系统问题分析
-	if report.System.FileHandlesPercent > 80 {
-		report.Issues = append(report.Issues, models.Issue{
-			Level:    "warning",
-			Category: "system",
-			Message:  fmt.Sprintf("文件句柄使用率过高: %.2f%%", report.System.FileHandlesPercent),
-			Details:  fmt.Sprintf("已分配: %d, 最大值: %d", report.System.FileHandlesAllocated, report.System.FileHandlesMax),
-			Timestamp: time.Now(),
-			Suggestion: "增加fs.file-max参数或排查句柄泄漏",
-		})
-	}
-	
-	if report.System.TimeOffset > 5 || report.System.TimeOffset < -5 {
-		report.Issues = append(report.Issues, models.Issue{
-			Level:    "warning",
-			Category: "system",
-			Message:  fmt.Sprintf("时间偏差过大: %.2f秒", report.System.TimeOffset),
-			Details:  "系统时间与NTP服务器不同步",
-			Timestamp: time.Now(),
-			Suggestion: "配置NTP服务并同步时间",
-		})
-	}
+	issues := analyzeServerIssues(report, i.thresholds, i.checks, i.history)
+	report.Issues = i.waivers.Apply(issues, report.Host)
+	models.IdentifyIssues(report.Issues, report.Host)
+}
+
+// analyzeServerIssues 使用启用的检查项分析服务器问题, 返回报告中已有的问题加上新发现的问题
+// set为nil时执行全部检查项, h为nil时趋势类检查项不生成问题
+func analyzeServerIssues(report *models.ServerReport, t config.ServerThresholds, set *checks.Set, h checks.History) []models.Issue {
+	return append(report.Issues, set.Evaluate(checks.TargetServer, &checks.Input{Server: report, ServerThresholds: t, History: h})...)
+}
+
+// SetChecks 设置本次巡检启用的检查项, 未设置时执行全部检查项
+func (i *Inspector) SetChecks(set *checks.Set) {
+	i.checks = set
+}
+
+// SetWaivers 设置本次巡检的豁免
+func (i *Inspector) SetWaivers(list *waivers.List) {
+	i.waivers = list
+}
+
+// SetHistory 设置趋势类检查项读取的历史记录
+func (i *Inspector) SetHistory(h checks.History) {
+	i.history = h
 }
 
 // Close 关闭巡检器
@@ -0,0 +1,199 @@
+package server
+
+import (
+	"errors"
+	"inspection-tool/internal/checks"
+	"inspection-tool/internal/config"
+	"inspection-tool/internal/waivers"
+	"inspection-tool/pkg/models"
+	"strings"
+	"testing"
+)
+
+func TestAnalyzeServerIssuesThresholds(t *testing.T) {
+	report := &models.ServerReport{
+		Host:   "test-server",
+		Memory: models.MemoryMetrics{TotalMB: 16000, AvailableMB: 4000, UsagePercent: 75},
+		Disk: []models.DiskMetrics{
+			{Device: "/dev/sda1", MountPoint: "/data", UsagePercent: 88},
+		},
+	}
+
+	// 默认阈值: 磁盘85%告警, 内存85%不告警
+	issues := analyzeServerIssues(report, config.DefaultServerThresholds(), nil, nil)
+	if countCategory(issues, "disk") != 1 {
+		t.Errorf("Expected 1 disk issue with default thresholds, got %d", countCategory(issues, "disk"))
+	}
+	if countCategory(issues, "memory") != 0 {
+		t.Errorf("Expected no memory issue with default thresholds, got %d", countCategory(issues, "memory"))
+	}
+
+	// 调整阈值后结果随之变化
+	thresholds := config.DefaultServerThresholds()
+	thresholds.Disk.UsagePercent = 90
+	thresholds.Memory.UsagePercent = 70
+
+	issues = analyzeServerIssues(report, thresholds, nil, nil)
+	if countCategory(issues, "disk") != 0 {
+		t.Errorf("Expected no disk issue with raised threshold, got %d", countCategory(issues, "disk"))
+	}
+	if countCategory(issues, "memory") != 1 {
+		t.Errorf("Expected 1 memory issue with lowered threshold, got %d", countCategory(issues, "memory"))
+	}
+}
+
+func TestAnalyzeServerIssuesLoad(t *testing.T) {
+	report := &models.ServerReport{
+		CPU:    models.CPUMetrics{CoreCount: 4, Load1: 9, Load5: 5, Load15: 3},
+		Memory: models.MemoryMetrics{TotalMB: 16000, AvailableMB: 8000},
+	}
+
+	issues := analyzeServerIssues(report, config.DefaultServerThresholds(), nil, nil)
+	if len(issues) != 1 || issues[0].Level != "critical" {
+		t.Fatalf("Expected a single critical load issue, got %+v", issues)
+	}
+
+	report.CPU.CoreCount = 16
+	issues = analyzeServerIssues(report, config.DefaultServerThresholds(), nil, nil)
+	if len(issues) != 1 || issues[0].Level != "warning" {
+		t.Fatalf("Expected a single load warning, got %+v", issues)
+	}
+}
+
+func countCategory(issues []models.Issue, category string) int {
+	count := 0
+	for _, issue := range issues {
+		if issue.Category == category {
+			count++
+		}
+	}
+	return count
+}
+
+// fakeResult 预设的命令执行结果
+type fakeResult struct {
+	stdout, stderr string
+	err            error
+}
+
+// fakeRunner 按命令前缀返回预设结果, 未预设的命令视为不存在
+type fakeRunner map[string]fakeResult
+
+func (r fakeRunner) Run(cmd string) (string, string, error) {
+	cmd = strings.TrimSpace(cmd)
+	for prefix, res := range r {
+		if strings.HasPrefix(cmd, prefix) {
+			return res.stdout, res.stderr, res.err
+		}
+	}
+	name := strings.Fields(cmd)[0]
+	return "", "sh: 1: " + name + ": not found\n", errors.New("command failed: Process exited with status 127")
+}
+
+func TestInspectPartialCollection(t *testing.T) {
+	runner := fakeRunner{
+		"hostname":           {stdout: "web01\n"},
+		"cat /proc/meminfo":  {stdout: "MemTotal: 16384000 kB\nMemAvailable: 8192000 kB\n"},
+		"cat /proc/pressure": {stdout: "none\n"},
+		"cat /proc/loadavg":  {stdout: "0.50 0.40 0.30 1/100 1234\n"},
+		"cat /proc/sys/fs":   {stdout: "1024 0 65536\n"},
+		// 管道中的命令不存在时仍以0退出, 只在stderr中报告
+		"ss ":   {stderr: "sh: 1: ss: not found\n"},
+		"ntpq ": {stderr: "sh: 1: ntpq: not found\n"},
+	}
+	inspector := &Inspector{runner: runner, host: "web01", thresholds: config.DefaultServerThresholds()}
+
+	report, err := inspector.Inspect()
+	if err != nil {
+		t.Fatalf("Expected partial report, got error: %v", err)
+	}
+
+	statuses := make(map[string]models.CollectorStatus)
+	for _, c := range report.Collectors {
+		statuses[c.Name] = c
+	}
+	if len(statuses) != 6 {
+		t.Fatalf("Expected 6 collector statuses, got %+v", report.Collectors)
+	}
+	if s := statuses["memory"]; s.Status != models.CollectorOK {
+		t.Errorf("Expected memory collector ok, got %+v", s)
+	}
+	if s := statuses["network"]; s.Status != models.CollectorFailed {
+		t.Errorf("Expected network collector failed, got %+v", s)
+	}
+	if s := statuses["system"]; s.Status != models.CollectorDegraded || len(s.Failures) == 0 || s.Failures[0].Stderr == "" {
+		t.Errorf("Expected system collector degraded with failing commands, got %+v", s)
+	}
+
+	if report.Collected("network.tcp_connections") || report.Collected("system.time_offset") {
+		t.Error("Expected missing metrics to be reported as not collected")
+	}
+	if !report.Collected("system.file_handles") || !report.Collected("memory") {
+		t.Error("Expected collected metrics to be reported as collected")
+	}
+	if report.Memory.TotalMB != 16000 {
+		t.Errorf("Expected memory metrics to be parsed, got %d", report.Memory.TotalMB)
+	}
+	if countCategory(report.Issues, "collector") == 0 {
+		t.Error("Expected collector issues for incomplete sections")
+	}
+}
+
+func TestAnalyzeServerIssuesWaivesCollectorFailures(t *testing.T) {
+	list, err := waivers.Parse([]byte(`
+waivers:
+  - host: web01
+    category: collector
+    reason: 该主机未安装ntpq
+    owner: ops
+    expires: "2099-12-31"
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	inspector := &Inspector{host: "web01", thresholds: config.DefaultServerThresholds()}
+	inspector.SetWaivers(list)
+
+	report := &models.ServerReport{
+		Host:   "web01",
+		Memory: models.MemoryMetrics{TotalMB: 16000, AvailableMB: 8000},
+		Issues: []models.Issue{{Level: models.SeverityWarning, Category: "collector", Message: "采集失败: system.time_offset"}},
+	}
+	inspector.analyzeIssues(report)
+	if issues := report.Issues; len(issues) != 1 || !issues[0].Suppressed || issues[0].Fingerprint == "" {
+		t.Errorf("Expected collector failure to be waived and identified, got %+v", issues)
+	}
+}
+
+func TestInspectAllCollectorsFailed(t *testing.T) {
+	inspector := &Inspector{runner: fakeRunner{}, host: "web01", thresholds: config.DefaultServerThresholds()}
+	if _, err := inspector.Inspect(); err == nil {
+		t.Fatal("Expected error when no metrics could be collected")
+	}
+}
+
+func TestAnalyzeServerIssuesChecks(t *testing.T) {
+	report := &models.ServerReport{
+		Memory: models.MemoryMetrics{TotalMB: 16000, AvailableMB: 8000},
+		Disk: []models.DiskMetrics{
+			{Device: "/dev/sda1", MountPoint: "/", UsagePercent: 95, InodesPercent: 95},
+		},
+	}
+
+	issues := analyzeServerIssues(report, config.DefaultServerThresholds(), nil, nil)
+	if len(issues) != 2 {
+		t.Fatalf("Expected disk usage and inode issues, got %+v", issues)
+	}
+	if issues[0].CheckID != "server.disk.usage" || issues[1].CheckID != "server.disk.inodes" {
+		t.Errorf("Expected issues to carry check ids, got %q and %q", issues[0].CheckID, issues[1].CheckID)
+	}
+
+	set, err := checks.Default.Select(nil, []string{"server.disk.inodes"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	issues = analyzeServerIssues(report, config.DefaultServerThresholds(), set, nil)
+	if len(issues) != 1 || issues[0].CheckID != "server.disk.usage" {
+		t.Errorf("Expected skipped check to produce no issue, got %+v", issues)
+	}
+}
@@ -487,10 +487,14 @@ func parseSystemMetrics(fileHandle, procCount, threadCount, ntpStatus, timeOffse
 	
 	// 进程和线程数
 	count, _ := strconv.Atoi(strings.TrimSpace(procCount))
-	metrics.ProcessCount = count - 1 // 减去标题行
+	if count > 0 {
+		metrics.ProcessCount = count - 1 // 减去标题行
+	}
 	
 	count, _ = strconv.Atoi(strings.TrimSpace(threadCount))
-	metrics.ThreadCount = count - 1
+	if count > 0 {
+		metrics.ThreadCount = count - 1
+	}
 	
 	// NTP同步状态
 	metrics.NTPSynced = strings.Contains(ntpStatus, "synchronized: yes") || 
@@ -0,0 +1,217 @@
+package ssh
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"golang.org/x/crypto/ssh"
+	"golang.org/x/crypto/ssh/agent"
+)
+
+// 认证方式名称
+const (
+	AuthAgent               = "agent"
+	AuthPublicKey           = "publickey"
+	AuthPassword            = "password"
+	AuthKeyboardInteractive = "keyboard-interactive"
+)
+
+// ErrAuthFailed SSH认证失败
+var ErrAuthFailed = errors.New("ssh authentication failed")
+
+// authPlan 认证方式构建结果
+type authPlan struct {
+	methods []ssh.AuthMethod
+	tried   []string // 实际提供给服务器的认证方式
+	skipped []string // 因本地原因未能使用的认证方式及原因
+	closers []func() error
+}
+
+// close 释放认证过程中打开的资源(如agent连接)
+func (p *authPlan) close() {
+	for _, c := range p.closers {
+		c()
+	}
+}
+
+// buildAuthMethods 按固定顺序构建认证方式: 指定的私钥文件 → ssh-agent → 默认私钥文件 → 密码 → keyboard-interactive
+// golang.org/x/crypto/ssh 对同名认证方式只尝试一次, 因此私钥和agent的签名者合并为一个publickey方式;
+// 与OpenSSH相同, 指定的私钥排在agent之前, 避免agent中密钥过多时超过服务器的MaxAuthTries
+func buildAuthMethods(config *Config) *authPlan {
+	plan := &authPlan{}
+	var signers []ssh.Signer
+	var sources []string
+
+	addKeyFiles := func(keyFiles []string) {
+		for _, path := range keyFiles {
+			signer, err := loadKeyFile(path, config.KeyPassphrase)
+			if err != nil {
+				plan.skipped = append(plan.skipped, fmt.Sprintf("%s(%s): %v", AuthPublicKey, path, err))
+				continue
+			}
+			signers = append(signers, signer)
+			sources = append(sources, fmt.Sprintf("%s(%s)", AuthPublicKey, path))
+		}
+	}
+
+	addKeyFiles(config.KeyFiles)
+
+	if config.UseAgent {
+		agentSigners, closer, err := agentSigners()
+		if err != nil {
+			plan.skipped = append(plan.skipped, fmt.Sprintf("%s: %v", AuthAgent, err))
+		} else {
+			plan.closers = append(plan.closers, closer)
+			if len(agentSigners) == 0 {
+				plan.skipped = append(plan.skipped, fmt.Sprintf("%s: no identities", AuthAgent))
+			} else {
+				// 已作为私钥文件提供的密钥不再重复提供
+				added := false
+				for _, signer := range agentSigners {
+					if !containsKey(signers, signer.PublicKey()) {
+						signers = append(signers, signer)
+						added = true
+					}
+				}
+				if added {
+					sources = append(sources, AuthAgent)
+				}
+			}
+		}
+	}
+
+	if len(config.KeyFiles) == 0 && config.UseDefaultKeys {
+		addKeyFiles(DefaultKeyFiles())
+	}
+
+	if len(signers) > 0 {
+		plan.methods = append(plan.methods, ssh.PublicKeys(signers...))
+		plan.tried = append(plan.tried, sources...)
+	}
+
+	if config.Password != "" {
+		plan.methods = append(plan.methods, ssh.Password(config.Password))
+		plan.tried = append(plan.tried, AuthPassword)
+	}
+
+	if config.KeyboardInteractive && config.Password != "" {
+		// 大多数PAM配置的提示只有密码一项, 对所有提示都回答密码
+		password := config.Password
+		plan.methods = append(plan.methods, ssh.KeyboardInteractive(
+			func(user, instruction string, questions []string, echos []bool) ([]string, error) {
+				answers := make([]string, len(questions))
+				for i := range questions {
+					answers[i] = password
+				}
+				return answers, nil
+			}))
+		plan.tried = append(plan.tried, AuthKeyboardInteractive)
+	}
+
+	return plan
+}
+
+// authError 生成包含已尝试和被跳过认证方式的错误信息
+func (p *authPlan) authError(err error) error {
+	msg := fmt.Sprintf("tried: %s", strings.Join(p.tried, ", "))
+	if len(p.tried) == 0 {
+		msg = "no usable authentication method"
+	}
+	if len(p.skipped) > 0 {
+		msg += fmt.Sprintf("; skipped: %s", strings.Join(p.skipped, "; "))
+	}
+	if err != nil {
+		return fmt.Errorf("%w (%s): %w", ErrAuthFailed, msg, err)
+	}
+	return fmt.Errorf("%w (%s)", ErrAuthFailed, msg)
+}
+
+// containsKey 判断signers中是否已有该公钥
+func containsKey(signers []ssh.Signer, key ssh.PublicKey) bool {
+	for _, signer := range signers {
+		if bytes.Equal(signer.PublicKey().Marshal(), key.Marshal()) {
+			return true
+		}
+	}
+	return false
+}
+
+// agentSigners 从SSH_AUTH_SOCK获取agent中的密钥
+func agentSigners() ([]ssh.Signer, func() error, error) {
+	socket := os.Getenv("SSH_AUTH_SOCK")
+	if socket == "" {
+		return nil, nil, fmt.Errorf("SSH_AUTH_SOCK not set")
+	}
+
+	conn, err := net.Dial("unix", socket)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to connect agent: %w", err)
+	}
+
+	signers, err := agent.NewClient(conn).Signers()
+	if err != nil {
+		conn.Close()
+		return nil, nil, fmt.Errorf("failed to list agent keys: %w", err)
+	}
+
+	return signers, conn.Close, nil
+}
+
+// loadKeyFile 加载私钥文件, 支持带密码的私钥
+func loadKeyFile(path, passphrase string) (ssh.Signer, error) {
+	data, err := os.ReadFile(ExpandPath(path))
+	if err != nil {
+		return nil, err
+	}
+
+	signer, err := ssh.ParsePrivateKey(data)
+	if err == nil {
+		return signer, nil
+	}
+
+	var missing *ssh.PassphraseMissingError
+	if !errors.As(err, &missing) {
+		return nil, err
+	}
+	if passphrase == "" {
+		return nil, fmt.Errorf("key is encrypted and no passphrase given")
+	}
+
+	signer, err = ssh.ParsePrivateKeyWithPassphrase(data, []byte(passphrase))
+	if err != nil {
+		return nil, fmt.Errorf("failed to decrypt key: %w", err)
+	}
+	return signer, nil
+}
+
+// DefaultKeyFiles 返回~/.ssh下存在的默认私钥文件
+func DefaultKeyFiles() []string {
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		return nil
+	}
+
+	var files []string
+	for _, name := range []string{"id_ed25519", "id_ecdsa", "id_rsa"} {
+		path := filepath.Join(homeDir, ".ssh", name)
+		if _, err := os.Stat(path); err == nil {
+			files = append(files, path)
+		}
+	}
+	return files
+}
+
+// ExpandPath 展开路径中的 ~ 前缀
+func ExpandPath(path string) string {
+	if path == "~" || strings.HasPrefix(path, "~/") {
+		if homeDir, err := os.UserHomeDir(); err == nil {
+			return filepath.Join(homeDir, strings.TrimPrefix(path, "~"))
+		}
+	}
+	return path
+}
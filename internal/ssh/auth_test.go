@@ -0,0 +1,176 @@
+package ssh
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/pem"
+	"errors"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"golang.org/x/crypto/ssh"
+	"golang.org/x/crypto/ssh/agent"
+)
+
+// writeTestKey 生成ed25519私钥并写入临时文件
+func writeTestKey(t *testing.T, passphrase string) string {
+	t.Helper()
+
+	_, priv, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var block *pem.Block
+	if passphrase == "" {
+		block, err = ssh.MarshalPrivateKey(priv, "test")
+	} else {
+		block, err = ssh.MarshalPrivateKeyWithPassphrase(priv, "test", []byte(passphrase))
+	}
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	path := filepath.Join(t.TempDir(), "id_ed25519")
+	if err := os.WriteFile(path, pem.EncodeToMemory(block), 0600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestLoadKeyFile(t *testing.T) {
+	plain := writeTestKey(t, "")
+	if _, err := loadKeyFile(plain, ""); err != nil {
+		t.Errorf("Expected plain key to load, got %v", err)
+	}
+
+	encrypted := writeTestKey(t, "secret")
+	if _, err := loadKeyFile(encrypted, ""); err == nil {
+		t.Error("Expected error for encrypted key without passphrase")
+	}
+	if _, err := loadKeyFile(encrypted, "wrong"); err == nil {
+		t.Error("Expected error for encrypted key with wrong passphrase")
+	}
+	if _, err := loadKeyFile(encrypted, "secret"); err != nil {
+		t.Errorf("Expected encrypted key to load with passphrase, got %v", err)
+	}
+}
+
+func TestBuildAuthMethodsOrder(t *testing.T) {
+	t.Setenv("SSH_AUTH_SOCK", "")
+	key := writeTestKey(t, "")
+
+	plan := buildAuthMethods(&Config{
+		Password:            "pass",
+		KeyFiles:            []string{key, "/nonexistent/id_rsa"},
+		UseAgent:            true,
+		KeyboardInteractive: true,
+	})
+	defer plan.close()
+
+	expected := []string{AuthPublicKey + "(" + key + ")", AuthPassword, AuthKeyboardInteractive}
+	if strings.Join(plan.tried, ",") != strings.Join(expected, ",") {
+		t.Errorf("Expected tried %v, got %v", expected, plan.tried)
+	}
+
+	// agent和私钥合并为一个publickey方式
+	if len(plan.methods) != 3 {
+		t.Errorf("Expected 3 auth methods, got %d", len(plan.methods))
+	}
+
+	if len(plan.skipped) != 2 {
+		t.Errorf("Expected agent and missing key to be skipped, got %v", plan.skipped)
+	}
+}
+
+// startTestAgent 启动持有keys的ssh-agent并设置SSH_AUTH_SOCK
+func startTestAgent(t *testing.T, keys ...interface{}) {
+	t.Helper()
+
+	dir, err := os.MkdirTemp("", "agent")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { os.RemoveAll(dir) })
+	socket := filepath.Join(dir, "agent.sock")
+	ln, err := net.Listen("unix", socket)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { ln.Close() })
+
+	keyring := agent.NewKeyring()
+	for _, key := range keys {
+		if err := keyring.Add(agent.AddedKey{PrivateKey: key}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			go agent.ServeAgent(keyring, conn)
+		}
+	}()
+	t.Setenv("SSH_AUTH_SOCK", socket)
+}
+
+func TestBuildAuthMethodsKeyBeforeAgent(t *testing.T) {
+	path := writeTestKey(t, "")
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	fileKey, err := ssh.ParseRawPrivateKey(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, otherKey, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// 指定的私钥在agent中的密钥之前提供
+	startTestAgent(t, otherKey, fileKey)
+	plan := buildAuthMethods(&Config{KeyFiles: []string{path}, UseAgent: true})
+	defer plan.close()
+	expected := []string{AuthPublicKey + "(" + path + ")", AuthAgent}
+	if strings.Join(plan.tried, ",") != strings.Join(expected, ",") {
+		t.Errorf("Expected tried %v, got %v", expected, plan.tried)
+	}
+
+	// agent中只有同一个密钥时不重复提供
+	startTestAgent(t, fileKey)
+	plan = buildAuthMethods(&Config{KeyFiles: []string{path}, UseAgent: true})
+	defer plan.close()
+	expected = []string{AuthPublicKey + "(" + path + ")"}
+	if strings.Join(plan.tried, ",") != strings.Join(expected, ",") {
+		t.Errorf("Expected tried %v, got %v", expected, plan.tried)
+	}
+}
+
+func TestNoAuthMethods(t *testing.T) {
+	t.Setenv("SSH_AUTH_SOCK", "")
+
+	_, err := NewClient(&Config{
+		Host:     "127.0.0.1",
+		Port:     22,
+		User:     "root",
+		KeyFiles: []string{"/nonexistent/id_rsa"},
+		UseAgent: true,
+	})
+	if !errors.Is(err, ErrAuthFailed) {
+		t.Fatalf("Expected ErrAuthFailed, got %v", err)
+	}
+
+	for _, want := range []string{"agent", "/nonexistent/id_rsa"} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("Expected error to mention '%s', got: %v", want, err)
+		}
+	}
+}
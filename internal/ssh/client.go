@@ -1,18 +1,36 @@
 package ssh
 
 import (
+	"bytes"
+	"errors"
 	"fmt"
 	"io"
 	"net"
+	"os"
+	"strconv"
+	"strings"
 	"time"
 
 	"golang.org/x/crypto/ssh"
 )
 
+// ErrTimeout SSH连接或握手超时
+var ErrTimeout = errors.New("ssh connection timed out")
+
+// IsTimeout 判断错误是否由连接或握手超时引起
+func IsTimeout(err error) bool {
+	if errors.Is(err, ErrTimeout) || errors.Is(err, os.ErrDeadlineExceeded) {
+		return true
+	}
+	var netErr net.Error
+	return errors.As(err, &netErr) && netErr.Timeout()
+}
+
 // Client SSH客户端
 type Client struct {
 	conn   *ssh.Client
 	config *Config
+	jump   *Jump // 由该客户端独占的跳板连接, 关闭客户端时一并关闭
 }
 
 // Config SSH配置
@@ -22,33 +40,148 @@ type Config struct {
 	User     string
 	Password string
 	Timeout  time.Duration
+
+	// 私钥认证, KeyPassphrase用于解密带密码的私钥
+	KeyFiles       []string
+	KeyPassphrase  string
+	UseDefaultKeys bool // KeyFiles为空时尝试~/.ssh/id_ed25519、id_ecdsa、id_rsa
+	// 使用SSH_AUTH_SOCK指向的ssh-agent
+	UseAgent bool
+	// 服务器只开放keyboard-interactive时使用密码应答
+	KeyboardInteractive bool
+
+	// 主机密钥校验: strict、tofu(默认)或insecure, KnownHostsFile为空时使用~/.ssh/known_hosts
+	HostKeyPolicy  string
+	KnownHostsFile string
+
+	// 从ssh_config(默认~/.ssh/config)读取主机别名对应的HostName、User、Port、IdentityFile和ProxyJump
+	UseSSHConfig  bool
+	SSHConfigFile string
+
+	// 跳板机(ProxyJump), 按顺序逐跳连接, 每一跳使用各自的认证配置
+	JumpHosts []*Config
+	// 共享的跳板连接, 设置后优先于JumpHosts, 多个目标主机复用同一条跳板连接
+	Jump *Jump
+	// 设置后JumpHosts(包括ssh_config中的ProxyJump)对应的跳板连接从中获取, 相同跳板机的目标主机共享连接
+	JumpPool *JumpPool
 }
 
 // NewClient 创建SSH客户端
 func NewClient(config *Config) (*Client, error) {
+	if config.Host == "" {
+		return nil, fmt.Errorf("host is required")
+	}
+
+	resolved, err := config.Resolve()
+	if err != nil {
+		return nil, err
+	}
+
+	jump := resolved.Jump
+	ownJump := false
+	if jump == nil && len(resolved.JumpHosts) > 0 {
+		if resolved.JumpPool != nil {
+			jump = resolved.JumpPool.Get(resolved.JumpHosts)
+		} else {
+			jump = NewJump(resolved.JumpHosts)
+			ownJump = true
+		}
+	}
+
+	var dial dialFunc
+	if jump != nil {
+		dial = jump.Dial
+	}
+
+	conn, err := connect(resolved, dial)
+	if err != nil {
+		if ownJump {
+			jump.Close()
+		}
+		return nil, err
+	}
+
+	client := &Client{
+		conn:   conn,
+		config: config,
+	}
+	if ownJump {
+		client.jump = jump
+	}
+	return client, nil
+}
+
+// dialFunc 在timeout内建立到目标地址的底层连接
+type dialFunc func(addr string, timeout time.Duration) (net.Conn, error)
+
+// connect 通过dial(为空时直连)建立SSH连接并完成认证
+func connect(config *Config, dial dialFunc) (*ssh.Client, error) {
+	if config.Host == "" {
+		return nil, fmt.Errorf("host is required")
+	}
+	config, err := config.Resolve()
+	if err != nil {
+		return nil, err
+	}
 	if config.Timeout == 0 {
 		config.Timeout = 30 * time.Second
 	}
 
+	hostKeyCallback, err := hostKeyCallback(config.HostKeyPolicy, config.KnownHostsFile)
+	if err != nil {
+		return nil, err
+	}
+
+	auth := buildAuthMethods(config)
+	defer auth.close()
+	if len(auth.methods) == 0 {
+		return nil, auth.authError(nil)
+	}
+
+	addr := net.JoinHostPort(config.Host, strconv.Itoa(config.Port))
+
 	sshConfig := &ssh.ClientConfig{
-		User: config.User,
-		Auth: []ssh.AuthMethod{
-			ssh.Password(config.Password),
-		},
-		HostKeyCallback: ssh.InsecureIgnoreHostKey(),
-		Timeout:         config.Timeout,
+		User:              config.User,
+		Auth:              auth.methods,
+		HostKeyCallback:   hostKeyCallback,
+		HostKeyAlgorithms: knownHostKeyAlgorithms(config.HostKeyPolicy, config.KnownHostsFile, addr),
+		Timeout:           config.Timeout,
 	}
 
-	addr := fmt.Sprintf("%s:%d", config.Host, config.Port)
-	conn, err := ssh.Dial("tcp", addr, sshConfig)
+	var netConn net.Conn
+	if dial != nil {
+		netConn, err = dial(addr, config.Timeout)
+	} else {
+		netConn, err = net.DialTimeout("tcp", addr, config.Timeout)
+	}
 	if err != nil {
 		return nil, fmt.Errorf("failed to dial: %w", err)
 	}
 
-	return &Client{
-		conn:   conn,
-		config: config,
-	}, nil
+	conn, err := handshake(netConn, addr, sshConfig, config.Timeout)
+	if err != nil {
+		netConn.Close()
+		if strings.Contains(err.Error(), "unable to authenticate") {
+			return nil, auth.authError(err)
+		}
+		return nil, fmt.Errorf("failed to dial: %w", err)
+	}
+
+	return conn, nil
+}
+
+// handshake 在已建立的连接上完成SSH握手
+// 经跳板机转发的连接不支持SetDeadline, 超时后直接关闭连接使握手返回
+func handshake(netConn net.Conn, addr string, sshConfig *ssh.ClientConfig, timeout time.Duration) (*ssh.Client, error) {
+	timer := time.AfterFunc(timeout, func() { netConn.Close() })
+	c, chans, reqs, err := ssh.NewClientConn(netConn, addr, sshConfig)
+	if !timer.Stop() && err != nil {
+		return nil, fmt.Errorf("%w: handshake not finished after %v: %w", ErrTimeout, timeout, err)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return ssh.NewClient(c, chans, reqs), nil
 }
 
 // Execute 执行命令
@@ -67,6 +200,24 @@ func (c *Client) Execute(cmd string) (string, error) {
 	return string(output), nil
 }
 
+// Run 执行命令, 分别返回标准输出和标准错误
+func (c *Client) Run(cmd string) (string, string, error) {
+	session, err := c.conn.NewSession()
+	if err != nil {
+		return "", "", fmt.Errorf("failed to create session: %w", err)
+	}
+	defer session.Close()
+
+	var stdout, stderr bytes.Buffer
+	session.Stdout = &stdout
+	session.Stderr = &stderr
+	if err := session.Run(cmd); err != nil {
+		return stdout.String(), stderr.String(), fmt.Errorf("command failed: %w", err)
+	}
+
+	return stdout.String(), stderr.String(), nil
+}
+
 // ExecuteWithTimeout 带超时的命令执行
 func (c *Client) ExecuteWithTimeout(cmd string, timeout time.Duration) (string, error) {
 	session, err := c.conn.NewSession()
@@ -150,10 +301,14 @@ func (c *Client) TestConnection() error {
 
 // Close 关闭连接
 func (c *Client) Close() error {
+	var err error
 	if c.conn != nil {
-		return c.conn.Close()
+		err = c.conn.Close()
 	}
-	return nil
+	if c.jump != nil {
+		c.jump.Close()
+	}
+	return err
 }
 
 // GetHost 获取主机地址
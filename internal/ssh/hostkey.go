@@ -0,0 +1,229 @@
+package ssh
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"golang.org/x/crypto/ssh"
+	"golang.org/x/crypto/ssh/knownhosts"
+)
+
+// 主机密钥校验策略
+const (
+	// HostKeyStrict 只接受known_hosts中已记录的主机密钥
+	HostKeyStrict = "strict"
+	// HostKeyTOFU 首次连接时记录主机密钥(trust on first use), 之后严格校验
+	HostKeyTOFU = "tofu"
+	// HostKeyInsecure 不校验主机密钥, 仅用于测试环境
+	HostKeyInsecure = "insecure"
+)
+
+// ErrHostKeyUnknown strict模式下主机密钥未记录在known_hosts中
+var ErrHostKeyUnknown = errors.New("host key is not in known_hosts")
+
+// HostKeyChangedError 主机密钥与known_hosts记录不一致, 可能存在中间人攻击
+type HostKeyChangedError struct {
+	Host        string
+	Fingerprint string   // 服务器当前提供的密钥指纹
+	Known       []string // known_hosts中记录的密钥(文件:行号 指纹)
+}
+
+func (e *HostKeyChangedError) Error() string {
+	return fmt.Sprintf("host key for %s has changed: got %s, known %s",
+		e.Host, e.Fingerprint, strings.Join(e.Known, ", "))
+}
+
+// IsHostKeyChanged 判断错误是否为主机密钥变更
+func IsHostKeyChanged(err error) (*HostKeyChangedError, bool) {
+	var changed *HostKeyChangedError
+	if errors.As(err, &changed) {
+		return changed, true
+	}
+	return nil, false
+}
+
+// knownHostsMu 串行化对known_hosts文件的写入
+var knownHostsMu sync.Mutex
+
+// DefaultKnownHostsFile 默认known_hosts文件路径
+func DefaultKnownHostsFile() string {
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(homeDir, ".ssh", "known_hosts")
+}
+
+// hostKeyCallback 根据策略创建主机密钥校验回调
+func hostKeyCallback(policy, file string) (ssh.HostKeyCallback, error) {
+	switch policy {
+	case HostKeyInsecure:
+		return ssh.InsecureIgnoreHostKey(), nil
+	case "", HostKeyTOFU, HostKeyStrict:
+	default:
+		return nil, fmt.Errorf("unknown host key policy: %s", policy)
+	}
+	if policy == "" {
+		policy = HostKeyTOFU
+	}
+
+	file = knownHostsPath(file)
+	if file == "" {
+		return nil, fmt.Errorf("known_hosts file not found")
+	}
+
+	return func(hostname string, remote net.Addr, key ssh.PublicKey) error {
+		check, err := loadKnownHosts(file)
+		if err != nil {
+			return err
+		}
+
+		err = check(hostname, remote, key)
+		if err == nil {
+			return nil
+		}
+
+		var keyErr *knownhosts.KeyError
+		if !errors.As(err, &keyErr) {
+			return err
+		}
+
+		// 只有记录了同类型的密钥时才是密钥变更, 服务器提供未记录类型的密钥视为未知密钥
+		for _, known := range keyErr.Want {
+			if known.Key.Type() != key.Type() {
+				continue
+			}
+			changed := &HostKeyChangedError{
+				Host:        hostname,
+				Fingerprint: ssh.FingerprintSHA256(key),
+			}
+			for _, known := range keyErr.Want {
+				changed.Known = append(changed.Known,
+					fmt.Sprintf("%s:%d %s", known.Filename, known.Line, ssh.FingerprintSHA256(known.Key)))
+			}
+			return changed
+		}
+
+		if policy == HostKeyStrict {
+			return fmt.Errorf("%w: %s (%s)", ErrHostKeyUnknown, hostname, ssh.FingerprintSHA256(key))
+		}
+
+		return appendKnownHost(file, hostname, key)
+	}, nil
+}
+
+// knownHostsPath known_hosts文件路径, 为空时使用默认路径
+func knownHostsPath(file string) string {
+	if file == "" {
+		file = DefaultKnownHostsFile()
+	}
+	if file == "" {
+		return ""
+	}
+	return ExpandPath(file)
+}
+
+// hostKeyAlgorithms 主机密钥算法, 按优先顺序排列
+var hostKeyAlgorithms = []string{
+	ssh.KeyAlgoED25519,
+	ssh.KeyAlgoECDSA256, ssh.KeyAlgoECDSA384, ssh.KeyAlgoECDSA521,
+	ssh.KeyAlgoRSASHA512, ssh.KeyAlgoRSASHA256, ssh.KeyAlgoRSA,
+	ssh.KeyAlgoDSA,
+	ssh.CertAlgoED25519v01,
+	ssh.CertAlgoECDSA256v01, ssh.CertAlgoECDSA384v01, ssh.CertAlgoECDSA521v01,
+	ssh.CertAlgoRSASHA512v01, ssh.CertAlgoRSASHA256v01, ssh.CertAlgoRSAv01,
+	ssh.CertAlgoDSAv01,
+}
+
+// knownHostKeyAlgorithms 用作ClientConfig.HostKeyAlgorithms: 与OpenSSH相同, known_hosts中为该主机记录的密钥类型在前,
+// 使服务器优先提供已记录的密钥, 其余算法在后; 没有记录时返回nil, 使用默认顺序
+func knownHostKeyAlgorithms(policy, file, addr string) []string {
+	if policy == HostKeyInsecure {
+		return nil
+	}
+	if file = knownHostsPath(file); file == "" {
+		return nil
+	}
+	check, err := loadKnownHosts(file)
+	if err != nil {
+		return nil
+	}
+
+	// 用不会匹配任何记录的密钥查询, KeyError.Want为该主机记录的全部密钥
+	var keyErr *knownhosts.KeyError
+	if !errors.As(check(addr, &net.TCPAddr{}, probeKey{}), &keyErr) || len(keyErr.Want) == 0 {
+		return nil
+	}
+	known := make(map[string]bool)
+	for _, k := range keyErr.Want {
+		known[k.Key.Type()] = true
+	}
+
+	var preferred, others []string
+	for _, algo := range hostKeyAlgorithms {
+		typ := algo
+		if algo == ssh.KeyAlgoRSASHA512 || algo == ssh.KeyAlgoRSASHA256 {
+			typ = ssh.KeyAlgoRSA
+		}
+		if known[typ] {
+			preferred = append(preferred, algo)
+		} else {
+			others = append(others, algo)
+		}
+	}
+	return append(preferred, others...)
+}
+
+// probeKey 查询known_hosts记录用的空密钥
+type probeKey struct{}
+
+func (probeKey) Type() string    { return "" }
+func (probeKey) Marshal() []byte { return nil }
+func (probeKey) Verify([]byte, *ssh.Signature) error {
+	return errors.New("probe key cannot verify signatures")
+}
+
+// loadKnownHosts 读取known_hosts, 文件不存在时视为空
+func loadKnownHosts(file string) (ssh.HostKeyCallback, error) {
+	knownHostsMu.Lock()
+	defer knownHostsMu.Unlock()
+
+	if _, err := os.Stat(file); os.IsNotExist(err) {
+		return func(hostname string, remote net.Addr, key ssh.PublicKey) error {
+			return &knownhosts.KeyError{}
+		}, nil
+	}
+
+	check, err := knownhosts.New(file)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load known_hosts %s: %w", file, err)
+	}
+	return check, nil
+}
+
+// appendKnownHost 将新主机密钥记录到known_hosts
+func appendKnownHost(file, hostname string, key ssh.PublicKey) error {
+	knownHostsMu.Lock()
+	defer knownHostsMu.Unlock()
+
+	if err := os.MkdirAll(filepath.Dir(file), 0700); err != nil {
+		return fmt.Errorf("failed to create known_hosts directory: %w", err)
+	}
+
+	f, err := os.OpenFile(file, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600)
+	if err != nil {
+		return fmt.Errorf("failed to open known_hosts: %w", err)
+	}
+	defer f.Close()
+
+	line := knownhosts.Line([]string{knownhosts.Normalize(hostname)}, key)
+	if _, err := fmt.Fprintln(f, line); err != nil {
+		return fmt.Errorf("failed to write known_hosts: %w", err)
+	}
+	return nil
+}
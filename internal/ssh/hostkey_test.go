@@ -0,0 +1,171 @@
+package ssh
+
+import (
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"errors"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"golang.org/x/crypto/ssh"
+)
+
+func newTestPublicKey(t *testing.T) ssh.PublicKey {
+	t.Helper()
+
+	pub, _, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	key, err := ssh.NewPublicKey(pub)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return key
+}
+
+func newTestECDSAPublicKey(t *testing.T) ssh.PublicKey {
+	t.Helper()
+
+	priv, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	key, err := ssh.NewPublicKey(&priv.PublicKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return key
+}
+
+func TestHostKeyTOFU(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "known_hosts")
+	remote := &net.TCPAddr{IP: net.ParseIP("192.168.1.100"), Port: 22}
+	key := newTestPublicKey(t)
+
+	callback, err := hostKeyCallback(HostKeyTOFU, file)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// 首次连接记录密钥
+	if err := callback("192.168.1.100:22", remote, key); err != nil {
+		t.Fatalf("Expected first connection to be trusted, got %v", err)
+	}
+	if data, err := os.ReadFile(file); err != nil || len(data) == 0 {
+		t.Fatalf("Expected known_hosts to be written, err=%v", err)
+	}
+
+	// 相同密钥再次连接通过
+	if err := callback("192.168.1.100:22", remote, key); err != nil {
+		t.Errorf("Expected known key to pass, got %v", err)
+	}
+
+	// 密钥变更被拒绝
+	err = callback("192.168.1.100:22", remote, newTestPublicKey(t))
+	changed, ok := IsHostKeyChanged(err)
+	if !ok {
+		t.Fatalf("Expected HostKeyChangedError, got %v", err)
+	}
+	if changed.Host != "192.168.1.100:22" || len(changed.Known) != 1 {
+		t.Errorf("Unexpected change details: %+v", changed)
+	}
+}
+
+func TestHostKeyStrict(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "known_hosts")
+	remote := &net.TCPAddr{IP: net.ParseIP("10.0.0.1"), Port: 22}
+
+	callback, err := hostKeyCallback(HostKeyStrict, file)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = callback("10.0.0.1:22", remote, newTestPublicKey(t))
+	if !errors.Is(err, ErrHostKeyUnknown) {
+		t.Errorf("Expected ErrHostKeyUnknown, got %v", err)
+	}
+	if _, err := os.Stat(file); !os.IsNotExist(err) {
+		t.Error("Strict mode should not write known_hosts")
+	}
+}
+
+func TestHostKeyOtherType(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "known_hosts")
+	remote := &net.TCPAddr{IP: net.ParseIP("10.0.0.1"), Port: 22}
+	if err := appendKnownHost(file, "10.0.0.1:22", newTestPublicKey(t)); err != nil {
+		t.Fatal(err)
+	}
+
+	// 只记录了ed25519密钥时, 优先要求服务器提供ed25519密钥, 其他类型仍可协商
+	got := knownHostKeyAlgorithms(HostKeyStrict, file, "10.0.0.1:22")
+	if len(got) != len(hostKeyAlgorithms) || got[0] != ssh.KeyAlgoED25519 || got[1] != ssh.KeyAlgoECDSA256 {
+		t.Errorf("Expected %s first followed by the other algorithms, got %v", ssh.KeyAlgoED25519, got)
+	}
+	if got := knownHostKeyAlgorithms(HostKeyStrict, file, "10.0.0.2:22"); got != nil {
+		t.Errorf("Expected no algorithms for unknown host, got %v", got)
+	}
+
+	// 服务器提供未记录类型的密钥不是密钥变更
+	strict, err := hostKeyCallback(HostKeyStrict, file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = strict("10.0.0.1:22", remote, newTestECDSAPublicKey(t))
+	if _, ok := IsHostKeyChanged(err); ok || !errors.Is(err, ErrHostKeyUnknown) {
+		t.Errorf("Expected ErrHostKeyUnknown for other key type, got %v", err)
+	}
+
+	tofu, err := hostKeyCallback(HostKeyTOFU, file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	key := newTestECDSAPublicKey(t)
+	if err := tofu("10.0.0.1:22", remote, key); err != nil {
+		t.Fatalf("Expected other key type to be recorded, got %v", err)
+	}
+	if err := tofu("10.0.0.1:22", remote, key); err != nil {
+		t.Errorf("Expected recorded key to pass, got %v", err)
+	}
+}
+
+func TestKnownHostKeyAlgorithmsRSA(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "known_hosts")
+	priv, err := rsa.GenerateKey(rand.Reader, 1024)
+	if err != nil {
+		t.Fatal(err)
+	}
+	key, err := ssh.NewPublicKey(&priv.PublicKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := appendKnownHost(file, "10.0.0.1:22", key); err != nil {
+		t.Fatal(err)
+	}
+
+	got := knownHostKeyAlgorithms(HostKeyTOFU, file, "10.0.0.1:22")
+	want := []string{ssh.KeyAlgoRSASHA512, ssh.KeyAlgoRSASHA256, ssh.KeyAlgoRSA, ssh.KeyAlgoED25519}
+	if len(got) < len(want) {
+		t.Fatalf("Expected at least %v, got %v", want, got)
+	}
+	for i, algo := range want {
+		if got[i] != algo {
+			t.Errorf("Expected %v first, got %v", want, got)
+			break
+		}
+	}
+	if got := knownHostKeyAlgorithms(HostKeyInsecure, file, "10.0.0.1:22"); got != nil {
+		t.Errorf("Expected no algorithms for insecure policy, got %v", got)
+	}
+}
+
+func TestHostKeyUnknownPolicy(t *testing.T) {
+	if _, err := hostKeyCallback("bogus", ""); err == nil {
+		t.Error("Expected error for unknown policy")
+	}
+}
@@ -0,0 +1,238 @@
+package ssh
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/crypto/ssh"
+)
+
+// Jump 跳板机连接链(ProxyJump), 按顺序连接每一跳, 最后一跳负责转发到目标主机
+// 连接在首次Dial时建立, 可被多个目标主机共享, 并发安全
+type Jump struct {
+	hops []*Config
+
+	mu      sync.Mutex
+	clients []*ssh.Client
+}
+
+// NewJump 创建跳板机连接链, 每一跳使用各自的认证和主机密钥配置
+func NewJump(hops []*Config) *Jump {
+	return &Jump{hops: hops}
+}
+
+// Dial 经跳板机连接到目标地址, 跳板连接断开时自动重连一次; timeout为每次转发的超时时间
+func (j *Jump) Dial(addr string, timeout time.Duration) (net.Conn, error) {
+	last, err := j.last(nil)
+	if err != nil {
+		return nil, err
+	}
+
+	conn, err := dialThrough(last, addr, timeout)
+	if err == nil {
+		return conn, nil
+	}
+	if errors.Is(err, ErrTimeout) {
+		return nil, fmt.Errorf("jump host failed to reach %s: %w", addr, err)
+	}
+
+	// 区分目标不可达与跳板连接已断开: 仅在跳板连接失效时重建
+	if _, _, pingErr := last.SendRequest("keepalive@openssh.com", true, nil); pingErr == nil {
+		return nil, fmt.Errorf("jump host failed to reach %s: %w", addr, err)
+	}
+
+	last, err = j.last(last)
+	if err != nil {
+		return nil, err
+	}
+	conn, err = dialThrough(last, addr, timeout)
+	if err != nil {
+		return nil, fmt.Errorf("jump host failed to reach %s: %w", addr, err)
+	}
+	return conn, nil
+}
+
+// dialThrough 经SSH连接转发到目标地址; 目标不响应时跳板机可能一直不回复, 超时后返回ErrTimeout
+func dialThrough(client *ssh.Client, addr string, timeout time.Duration) (net.Conn, error) {
+	if timeout <= 0 {
+		timeout = 30 * time.Second
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	conn, err := client.DialContext(ctx, "tcp", addr)
+	if errors.Is(err, context.DeadlineExceeded) {
+		return nil, fmt.Errorf("%w: dial %s not finished after %v", ErrTimeout, addr, timeout)
+	}
+	return conn, err
+}
+
+// last 返回连接链的最后一跳, 必要时建立连接链; stale为调用方发现已断开的最后一跳,
+// 只有当前连接链仍是它时才重建, 避免多个目标同时失败时关闭其他目标刚重建的连接链
+func (j *Jump) last(stale *ssh.Client) (*ssh.Client, error) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	if stale != nil && len(j.clients) > 0 && j.clients[len(j.clients)-1] == stale {
+		j.closeLocked()
+	}
+	if len(j.clients) == 0 {
+		if err := j.connectLocked(); err != nil {
+			return nil, err
+		}
+	}
+	return j.clients[len(j.clients)-1], nil
+}
+
+// connectLocked 逐跳建立连接, 第一跳直连, 之后每一跳经上一跳转发
+func (j *Jump) connectLocked() error {
+	if len(j.hops) == 0 {
+		return fmt.Errorf("no jump hosts configured")
+	}
+
+	for i, hop := range j.hops {
+		var dial dialFunc
+		if i > 0 {
+			prev := j.clients[i-1]
+			dial = func(addr string, timeout time.Duration) (net.Conn, error) {
+				return dialThrough(prev, addr, timeout)
+			}
+		}
+
+		// 跳板机自身的ProxyJump无法与连接链同时生效, 需要在连接链中列出完整的跳板机
+		resolved, err := hop.Resolve()
+		if err != nil {
+			j.closeLocked()
+			return fmt.Errorf("failed to resolve jump host %s: %w", hop.Host, err)
+		}
+		if len(resolved.JumpHosts) > 0 || resolved.Jump != nil {
+			j.closeLocked()
+			return fmt.Errorf("jump host %s has its own ProxyJump, list every hop in the jump host chain instead", hop.Host)
+		}
+
+		client, err := connect(resolved, dial)
+		if err != nil {
+			j.closeLocked()
+			return fmt.Errorf("failed to connect jump host %s: %w", hop.Host, err)
+		}
+		j.clients = append(j.clients, client)
+	}
+	return nil
+}
+
+// Close 关闭整条跳板连接链
+func (j *Jump) Close() error {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	j.closeLocked()
+	return nil
+}
+
+// closeLocked 从最后一跳开始依次关闭连接
+func (j *Jump) closeLocked() {
+	for i := len(j.clients) - 1; i >= 0; i-- {
+		j.clients[i].Close()
+	}
+	j.clients = nil
+}
+
+// JumpPool 按跳板机链共享跳板连接
+type JumpPool struct {
+	mu    sync.Mutex
+	jumps map[string]*Jump
+}
+
+// NewJumpPool 创建跳板连接池
+func NewJumpPool() *JumpPool {
+	return &JumpPool{jumps: make(map[string]*Jump)}
+}
+
+// Get 返回跳板机链对应的连接, 用户、地址和端口都相同的跳板机链共享同一个Jump
+func (p *JumpPool) Get(hops []*Config) *Jump {
+	keys := make([]string, len(hops))
+	for i, hop := range hops {
+		keys[i] = fmt.Sprintf("%s@%s:%d", hop.User, hop.Host, hop.Port)
+	}
+	key := strings.Join(keys, ",")
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	jump, ok := p.jumps[key]
+	if !ok {
+		jump = NewJump(hops)
+		p.jumps[key] = jump
+	}
+	return jump
+}
+
+// Close 关闭所有跳板连接
+func (p *JumpPool) Close() error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	for key, jump := range p.jumps {
+		jump.Close()
+		delete(p.jumps, key)
+	}
+	return nil
+}
+
+// ParseJumpSpec 解析ProxyJump格式的跳板机列表: [user@]host[:port][,[user@]host[:port]...]
+// 每一跳继承base中的私钥、agent、主机密钥和ssh_config配置; 未指定用户时使用base.User, 不继承目标主机的密码
+// 未指定端口时Port为0, 连接时由ssh_config或默认值22决定
+func ParseJumpSpec(spec string, base *Config) ([]*Config, error) {
+	var hops []*Config
+	for _, part := range strings.Split(spec, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+
+		hop := &Config{}
+		if base != nil {
+			hop.User = base.User
+			hop.Timeout = base.Timeout
+			hop.KeyFiles = base.KeyFiles
+			hop.KeyPassphrase = base.KeyPassphrase
+			hop.UseDefaultKeys = base.UseDefaultKeys
+			hop.UseAgent = base.UseAgent
+			hop.HostKeyPolicy = base.HostKeyPolicy
+			hop.KnownHostsFile = base.KnownHostsFile
+			hop.UseSSHConfig = base.UseSSHConfig
+			hop.SSHConfigFile = base.SSHConfigFile
+		}
+
+		if at := strings.LastIndex(part, "@"); at >= 0 {
+			hop.User = part[:at]
+			part = part[at+1:]
+		}
+
+		host, portStr, err := net.SplitHostPort(part)
+		if err != nil {
+			// 未指定端口
+			host = strings.Trim(part, "[]")
+		} else {
+			port, err := strconv.Atoi(portStr)
+			if err != nil || port <= 0 || port > 65535 {
+				return nil, fmt.Errorf("invalid jump host port: %s", part)
+			}
+			hop.Port = port
+		}
+		if host == "" {
+			return nil, fmt.Errorf("invalid jump host: %q", part)
+		}
+		hop.Host = host
+
+		hops = append(hops, hop)
+	}
+
+	if len(hops) == 0 {
+		return nil, fmt.Errorf("empty jump host spec")
+	}
+	return hops, nil
+}
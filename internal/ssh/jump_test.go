@@ -0,0 +1,187 @@
+package ssh
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"errors"
+	"net"
+	"strings"
+	"testing"
+	"time"
+
+	"golang.org/x/crypto/ssh"
+)
+
+func TestParseJumpSpec(t *testing.T) {
+	base := &Config{
+		User:          "root",
+		Password:      "target-secret",
+		KeyFiles:      []string{"~/.ssh/deploy"},
+		UseAgent:      true,
+		HostKeyPolicy: HostKeyStrict,
+	}
+
+	hops, err := ParseJumpSpec("jump@bastion.example.com:2222, 10.0.0.1,[fd00::1]:22", base)
+	if err != nil {
+		t.Fatalf("ParseJumpSpec failed: %v", err)
+	}
+
+	if len(hops) != 3 {
+		t.Fatalf("Expected 3 hops, got %d", len(hops))
+	}
+
+	want := []struct {
+		user string
+		host string
+		port int
+	}{
+		{"jump", "bastion.example.com", 2222},
+		{"root", "10.0.0.1", 0},
+		{"root", "fd00::1", 22},
+	}
+	for i, w := range want {
+		hop := hops[i]
+		if hop.User != w.user || hop.Host != w.host || hop.Port != w.port {
+			t.Errorf("Hop %d: expected %s@%s:%d, got %s@%s:%d", i, w.user, w.host, w.port, hop.User, hop.Host, hop.Port)
+		}
+		if hop.Password != "" {
+			t.Errorf("Hop %d: target password should not be inherited", i)
+		}
+		if !hop.UseAgent || hop.HostKeyPolicy != HostKeyStrict || len(hop.KeyFiles) != 1 {
+			t.Errorf("Hop %d: expected key and host key settings to be inherited, got %+v", i, hop)
+		}
+	}
+}
+
+func TestParseJumpSpecInvalid(t *testing.T) {
+	for _, spec := range []string{"", " , ", "bastion:abc", "bastion:70000", "user@"} {
+		if _, err := ParseJumpSpec(spec, nil); err == nil {
+			t.Errorf("Expected error for spec %q", spec)
+		}
+	}
+}
+
+func TestJumpNoHops(t *testing.T) {
+	jump := NewJump(nil)
+	defer jump.Close()
+
+	_, err := jump.Dial("127.0.0.1:22", time.Second)
+	if err == nil || !strings.Contains(err.Error(), "no jump hosts") {
+		t.Errorf("Expected no jump hosts error, got %v", err)
+	}
+}
+
+// newSilentJumpHost 建立到本地SSH服务器的连接, 服务器不回复转发请求, 模拟目标主机丢弃流量
+func newSilentJumpHost(t *testing.T) *ssh.Client {
+	t.Helper()
+
+	_, priv, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	signer, err := ssh.NewSignerFromKey(priv)
+	if err != nil {
+		t.Fatal(err)
+	}
+	serverConfig := &ssh.ServerConfig{NoClientAuth: true}
+	serverConfig.AddHostKey(signer)
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { ln.Close() })
+	go func() {
+		serverConn, err := ln.Accept()
+		if err != nil {
+			return
+		}
+		_, chans, reqs, err := ssh.NewServerConn(serverConn, serverConfig)
+		if err != nil {
+			return
+		}
+		go ssh.DiscardRequests(reqs)
+		// 收到的转发请求既不接受也不拒绝
+		for range chans {
+		}
+	}()
+
+	client, err := ssh.Dial("tcp", ln.Addr().String(), &ssh.ClientConfig{
+		User:            "root",
+		HostKeyCallback: ssh.InsecureIgnoreHostKey(),
+		Timeout:         5 * time.Second,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { client.Close() })
+	return client
+}
+
+func TestDialThroughTimeout(t *testing.T) {
+	client := newSilentJumpHost(t)
+
+	start := time.Now()
+	_, err := dialThrough(client, "10.0.0.1:22", 100*time.Millisecond)
+	if !errors.Is(err, ErrTimeout) || !IsTimeout(err) {
+		t.Fatalf("Expected ErrTimeout, got %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Errorf("Dial returned after %v, expected to time out", elapsed)
+	}
+}
+
+func TestJumpReconnectOnlyStale(t *testing.T) {
+	current, stale := newSilentJumpHost(t), newSilentJumpHost(t)
+	jump := &Jump{clients: []*ssh.Client{current}}
+
+	// 其他目标已重建连接链, 不再关闭
+	last, err := jump.last(stale)
+	if err != nil || last != current {
+		t.Fatalf("Expected current chain to be kept, got %v, %v", last, err)
+	}
+	if _, _, err := current.SendRequest("keepalive@openssh.com", true, nil); err != nil {
+		t.Errorf("Expected current chain to stay open, got %v", err)
+	}
+
+	// 当前连接链已断开时重建
+	if _, err := jump.last(current); err == nil || !strings.Contains(err.Error(), "no jump hosts") {
+		t.Errorf("Expected reconnect to be attempted, got %v", err)
+	}
+	if _, _, err := current.SendRequest("keepalive@openssh.com", true, nil); err == nil {
+		t.Error("Expected stale chain to be closed")
+	}
+}
+
+func TestJumpPool(t *testing.T) {
+	pool := NewJumpPool()
+	defer pool.Close()
+
+	a, _ := ParseJumpSpec("jump@bastion:2222", nil)
+	b, _ := ParseJumpSpec("jump@bastion:2222", nil)
+	c, _ := ParseJumpSpec("jump@bastion:2222,10.0.0.1", nil)
+	if pool.Get(a) != pool.Get(b) {
+		t.Error("Expected the same jump host chain to share a connection")
+	}
+	if pool.Get(a) == pool.Get(c) {
+		t.Error("Expected different jump host chains to use different connections")
+	}
+}
+
+func TestJumpNestedProxyJump(t *testing.T) {
+	path := writeSSHConfig(t, `
+Host bastion
+  ProxyJump outer
+`)
+	hops, err := ParseJumpSpec("bastion", &Config{UseSSHConfig: true, SSHConfigFile: path})
+	if err != nil {
+		t.Fatal(err)
+	}
+	jump := NewJump(hops)
+	defer jump.Close()
+
+	_, err = jump.Dial("10.0.0.1:22", time.Second)
+	if err == nil || !strings.Contains(err.Error(), "has its own ProxyJump") {
+		t.Errorf("Expected nested ProxyJump to be rejected, got %v", err)
+	}
+}
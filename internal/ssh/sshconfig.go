@@ -0,0 +1,277 @@
+package ssh
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"os/user"
+	"path"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// 未指定且ssh_config中未配置时使用的默认值
+const (
+	DefaultUser = "root"
+	DefaultPort = 22
+)
+
+// HostSettings ssh_config中某个主机生效的配置
+type HostSettings struct {
+	HostName      string
+	User          string
+	Port          int
+	IdentityFiles []string
+	ProxyJump     string
+}
+
+// sshConfigBlock Host块, patterns为空表示文件开头的全局配置
+type sshConfigBlock struct {
+	patterns []string
+	options  [][2]string // 按出现顺序保存的 关键字(小写)/值
+}
+
+// SSHConfigFile 解析后的OpenSSH客户端配置文件
+// 支持Host、HostName、User、Port、IdentityFile、ProxyJump和Include, 其余关键字忽略; Match块整体跳过
+type SSHConfigFile struct {
+	blocks []*sshConfigBlock
+}
+
+// DefaultSSHConfigFile 默认ssh_config文件路径
+func DefaultSSHConfigFile() string {
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(homeDir, ".ssh", "config")
+}
+
+// LoadSSHConfig 加载ssh_config, 文件不存在时返回空配置
+func LoadSSHConfig(file string) (*SSHConfigFile, error) {
+	if file == "" {
+		file = DefaultSSHConfigFile()
+	}
+	cfg := &SSHConfigFile{}
+	if file == "" {
+		return cfg, nil
+	}
+
+	current := &sshConfigBlock{}
+	cfg.blocks = append(cfg.blocks, current)
+	if err := cfg.parseFile(ExpandPath(file), &current, 0); err != nil {
+		return nil, err
+	}
+	return cfg, nil
+}
+
+// parseFile 解析单个文件, Include的文件在当前位置展开
+func (c *SSHConfigFile) parseFile(file string, current **sshConfigBlock, depth int) error {
+	if depth > 8 {
+		return fmt.Errorf("ssh config include nested too deeply: %s", file)
+	}
+
+	f, err := os.Open(file)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return fmt.Errorf("failed to open ssh config %s: %w", file, err)
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	lineNo := 0
+	for scanner.Scan() {
+		lineNo++
+		keyword, value, ok := parseSSHConfigLine(scanner.Text())
+		if !ok {
+			continue
+		}
+
+		switch keyword {
+		case "host":
+			*current = &sshConfigBlock{patterns: strings.Fields(value)}
+			c.blocks = append(c.blocks, *current)
+		case "match":
+			// 不支持Match条件, 其后的配置在下一个Host之前都不生效
+			*current = &sshConfigBlock{patterns: []string{"!*"}}
+			c.blocks = append(c.blocks, *current)
+		case "include":
+			for _, pattern := range strings.Fields(value) {
+				pattern = ExpandPath(pattern)
+				if !filepath.IsAbs(pattern) {
+					pattern = filepath.Join(filepath.Dir(file), pattern)
+				}
+				matches, err := filepath.Glob(pattern)
+				if err != nil {
+					return fmt.Errorf("%s:%d: invalid include pattern: %w", file, lineNo, err)
+				}
+				for _, m := range matches {
+					if err := c.parseFile(m, current, depth+1); err != nil {
+						return err
+					}
+				}
+			}
+		default:
+			(*current).options = append((*current).options, [2]string{keyword, value})
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return fmt.Errorf("failed to read ssh config %s: %w", file, err)
+	}
+	return nil
+}
+
+// parseSSHConfigLine 解析 "Keyword value" 或 "Keyword=value" 格式的一行
+func parseSSHConfigLine(line string) (string, string, bool) {
+	line = strings.TrimSpace(line)
+	if line == "" || strings.HasPrefix(line, "#") {
+		return "", "", false
+	}
+
+	idx := strings.IndexAny(line, " \t=")
+	if idx < 0 {
+		return "", "", false
+	}
+	keyword := strings.ToLower(line[:idx])
+	value := strings.TrimSpace(line[idx:])
+	value = strings.TrimSpace(strings.TrimPrefix(value, "="))
+	value = strings.Trim(value, `"`)
+	return keyword, value, true
+}
+
+// matchHost 判断主机名是否匹配Host行中的模式, 任一否定模式(!pattern)匹配时不生效
+func matchHost(patterns []string, host string) bool {
+	if len(patterns) == 0 {
+		return true
+	}
+
+	matched := false
+	for _, p := range patterns {
+		negate := strings.HasPrefix(p, "!")
+		p = strings.TrimPrefix(p, "!")
+		if ok, _ := path.Match(strings.ToLower(p), strings.ToLower(host)); ok {
+			if negate {
+				return false
+			}
+			matched = true
+		}
+	}
+	return matched
+}
+
+// Lookup 查找主机生效的配置, 与OpenSSH一致: 每个关键字取第一个匹配值, IdentityFile累加
+func (c *SSHConfigFile) Lookup(alias string) HostSettings {
+	var s HostSettings
+	for _, block := range c.blocks {
+		if !matchHost(block.patterns, alias) {
+			continue
+		}
+		for _, opt := range block.options {
+			keyword, value := opt[0], opt[1]
+			switch keyword {
+			case "hostname":
+				if s.HostName == "" {
+					s.HostName = value
+				}
+			case "user":
+				if s.User == "" {
+					s.User = value
+				}
+			case "port":
+				if s.Port == 0 {
+					if port, err := strconv.Atoi(value); err == nil {
+						s.Port = port
+					}
+				}
+			case "identityfile":
+				s.IdentityFiles = append(s.IdentityFiles, value)
+			case "proxyjump":
+				if s.ProxyJump == "" {
+					s.ProxyJump = value
+				}
+			}
+		}
+	}
+
+	if s.HostName != "" {
+		s.HostName = strings.ReplaceAll(s.HostName, "%h", alias)
+	}
+	return s
+}
+
+// expandTokens 展开IdentityFile中的 %d(本地家目录)、%u(本地用户)、%h(远程主机)、%r(远程用户)
+func expandTokens(value, host, remoteUser string) string {
+	if !strings.Contains(value, "%") {
+		return value
+	}
+
+	homeDir, _ := os.UserHomeDir()
+	localUser := ""
+	if u, err := user.Current(); err == nil {
+		localUser = u.Username
+	}
+
+	return strings.NewReplacer(
+		"%%", "%",
+		"%d", homeDir,
+		"%u", localUser,
+		"%h", host,
+		"%r", remoteUser,
+	).Replace(value)
+}
+
+// Resolve 返回应用ssh_config和默认值后的连接配置, 不修改原配置
+// 显式设置的字段优先于ssh_config: User为空、Port为0、KeyFiles为空时才使用ssh_config中的值; 跳板机以ssh_config中的ProxyJump为准
+func (c *Config) Resolve() (*Config, error) {
+	resolved := *c
+
+	if c.UseSSHConfig && c.Host != "" {
+		file, err := LoadSSHConfig(c.SSHConfigFile)
+		if err != nil {
+			return nil, err
+		}
+		settings := file.Lookup(c.Host)
+
+		if settings.HostName != "" {
+			resolved.Host = settings.HostName
+		}
+		if resolved.User == "" {
+			resolved.User = settings.User
+		}
+		if resolved.Port == 0 {
+			resolved.Port = settings.Port
+		}
+		if resolved.User == "" {
+			resolved.User = DefaultUser
+		}
+		if len(resolved.KeyFiles) == 0 {
+			for _, f := range settings.IdentityFiles {
+				resolved.KeyFiles = append(resolved.KeyFiles, expandTokens(f, resolved.Host, resolved.User))
+			}
+		}
+		// 主机的ProxyJump优先于对所有主机生效的跳板机(JumpHosts、Jump), ProxyJump none表示直连
+		switch {
+		case strings.EqualFold(settings.ProxyJump, "none"):
+			resolved.JumpHosts, resolved.Jump = nil, nil
+		case settings.ProxyJump != "":
+			hops, err := ParseJumpSpec(settings.ProxyJump, c)
+			if err != nil {
+				return nil, fmt.Errorf("invalid ProxyJump for %s: %w", c.Host, err)
+			}
+			resolved.JumpHosts, resolved.Jump = hops, nil
+		}
+
+		// 已解析完成, 避免重复解析
+		resolved.UseSSHConfig = false
+	}
+
+	if resolved.User == "" {
+		resolved.User = DefaultUser
+	}
+	if resolved.Port == 0 {
+		resolved.Port = DefaultPort
+	}
+	return &resolved, nil
+}
@@ -0,0 +1,148 @@
+package ssh
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func writeSSHConfig(t *testing.T, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "config")
+	if err := os.WriteFile(path, []byte(content), 0600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestSSHConfigLookup(t *testing.T) {
+	dir := t.TempDir()
+	include := filepath.Join(dir, "extra.conf")
+	if err := os.WriteFile(include, []byte("Host cache*\n  User redis\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	path := writeSSHConfig(t, `
+# 注释
+Include `+include+`
+
+Host db01 db02
+  HostName 10.0.0.%h
+  User dba
+  Port=2222
+  IdentityFile ~/.ssh/db_key
+  ProxyJump bastion
+
+Host db01
+  HostName ignored
+  User ignored
+
+Host *.internal !skip.internal
+  User ops
+
+Match host db01
+  User matched
+
+Host *
+  User fallback
+  IdentityFile ~/.ssh/id_ed25519
+`)
+
+	cfg, err := LoadSSHConfig(path)
+	if err != nil {
+		t.Fatalf("LoadSSHConfig failed: %v", err)
+	}
+
+	db := cfg.Lookup("db01")
+	if db.HostName != "10.0.0.db01" || db.User != "dba" || db.Port != 2222 || db.ProxyJump != "bastion" {
+		t.Errorf("Unexpected settings for db01: %+v", db)
+	}
+	if len(db.IdentityFiles) != 2 {
+		t.Errorf("Expected IdentityFile to accumulate, got %v", db.IdentityFiles)
+	}
+
+	if got := cfg.Lookup("web.internal").User; got != "ops" {
+		t.Errorf("Expected user ops for web.internal, got %s", got)
+	}
+	if got := cfg.Lookup("skip.internal").User; got != "fallback" {
+		t.Errorf("Expected negated pattern to fall through, got %s", got)
+	}
+	if got := cfg.Lookup("cache01").User; got != "redis" {
+		t.Errorf("Expected included config to apply, got %s", got)
+	}
+}
+
+func TestLoadSSHConfigMissing(t *testing.T) {
+	cfg, err := LoadSSHConfig(filepath.Join(t.TempDir(), "missing"))
+	if err != nil {
+		t.Fatalf("Expected missing file to be ignored, got %v", err)
+	}
+	if s := cfg.Lookup("any"); s.HostName != "" || s.User != "" {
+		t.Errorf("Expected empty settings, got %+v", s)
+	}
+}
+
+func TestConfigResolve(t *testing.T) {
+	path := writeSSHConfig(t, `
+Host db01
+  HostName 10.0.0.1
+  User dba
+  Port 2222
+  IdentityFile /keys/%r@%h
+  ProxyJump jump@bastion:2200
+`)
+
+	resolved, err := (&Config{Host: "db01", UseSSHConfig: true, SSHConfigFile: path}).Resolve()
+	if err != nil {
+		t.Fatalf("Resolve failed: %v", err)
+	}
+	if resolved.Host != "10.0.0.1" || resolved.User != "dba" || resolved.Port != 2222 {
+		t.Errorf("Unexpected resolved target: %s@%s:%d", resolved.User, resolved.Host, resolved.Port)
+	}
+	if len(resolved.KeyFiles) != 1 || resolved.KeyFiles[0] != "/keys/dba@10.0.0.1" {
+		t.Errorf("Unexpected key files: %v", resolved.KeyFiles)
+	}
+	if len(resolved.JumpHosts) != 1 || resolved.JumpHosts[0].Host != "bastion" || resolved.JumpHosts[0].Port != 2200 {
+		t.Errorf("Unexpected jump hosts: %+v", resolved.JumpHosts)
+	}
+
+	// 显式指定的值优先于ssh_config
+	resolved, err = (&Config{Host: "db01", User: "root", Port: 22, UseSSHConfig: true, SSHConfigFile: path}).Resolve()
+	if err != nil {
+		t.Fatalf("Resolve failed: %v", err)
+	}
+	if resolved.User != "root" || resolved.Port != 22 {
+		t.Errorf("Expected explicit user and port to win, got %s:%d", resolved.User, resolved.Port)
+	}
+
+	// 主机的ProxyJump优先于全局跳板机, none表示直连
+	global := []*Config{{Host: "global-bastion"}}
+	resolved, err = (&Config{Host: "db01", JumpHosts: global, Jump: NewJump(global), UseSSHConfig: true, SSHConfigFile: path}).Resolve()
+	if err != nil {
+		t.Fatalf("Resolve failed: %v", err)
+	}
+	if resolved.Jump != nil || len(resolved.JumpHosts) != 1 || resolved.JumpHosts[0].Host != "bastion" {
+		t.Errorf("Expected ProxyJump of db01 to win, got %+v", resolved.JumpHosts)
+	}
+	none := writeSSHConfig(t, "Host db01\n  ProxyJump none\n")
+	resolved, err = (&Config{Host: "db01", JumpHosts: global, UseSSHConfig: true, SSHConfigFile: none}).Resolve()
+	if err != nil {
+		t.Fatalf("Resolve failed: %v", err)
+	}
+	if len(resolved.JumpHosts) != 0 {
+		t.Errorf("Expected ProxyJump none to connect directly, got %+v", resolved.JumpHosts)
+	}
+	resolved, _ = (&Config{Host: "web01", JumpHosts: global, UseSSHConfig: true, SSHConfigFile: path}).Resolve()
+	if len(resolved.JumpHosts) != 1 || resolved.JumpHosts[0].Host != "global-bastion" {
+		t.Errorf("Expected global jump hosts without ProxyJump, got %+v", resolved.JumpHosts)
+	}
+
+	// 关闭ssh_config时只填充默认值
+	resolved, err = (&Config{Host: "db01", SSHConfigFile: path}).Resolve()
+	if err != nil {
+		t.Fatalf("Resolve failed: %v", err)
+	}
+	if resolved.Host != "db01" || resolved.User != DefaultUser || resolved.Port != DefaultPort {
+		t.Errorf("Expected defaults without ssh config, got %s@%s:%d", resolved.User, resolved.Host, resolved.Port)
+	}
+}
@@ -0,0 +1,207 @@
+package trend
+
+import (
+	"fmt"
+	"inspection-tool/internal/checks"
+	"inspection-tool/internal/config"
+	"inspection-tool/internal/history"
+	"inspection-tool/pkg/models"
+	"time"
+)
+
+// 容量预测检查项, 需要历史记录, 未加载历史记录时不生成问题
+func init() {
+	for _, c := range forecastChecks() {
+		checks.Register(c)
+	}
+}
+
+// CheckIDs 容量预测检查项的ID, 只有启用了其中之一时才需要加载历史记录
+var CheckIDs = []string{
+	"server.disk.usage_forecast",
+	"server.disk.inodes_forecast",
+	"server.memory.usage_forecast",
+	"k8s.node.pods_forecast",
+}
+
+// Enabled 判断检查集是否启用了容量预测检查项
+func Enabled(set *checks.Set) bool {
+	for _, id := range CheckIDs {
+		if set.Enabled(id) {
+			return true
+		}
+	}
+	return false
+}
+
+// forecastChecks 返回容量预测检查项
+// 本次的值已超过阈值时由对应的阈值检查项报告, 预测检查项只在超过阈值之前提前告警
+func forecastChecks() []checks.Check {
+	return []checks.Check{
+		checks.New(CheckIDs[0], "disk", "warning", checks.TargetServer, func(in *checks.Input) []models.Issue {
+			r := in.Server
+			if !r.Collected("disk.usage") {
+				return nil
+			}
+			var issues []models.Issue
+			for _, disk := range r.Disk {
+				f, level, ok := forecast(in, r.Host, disk.MountPoint, history.MetricDiskUsage, r.Timestamp,
+					disk.UsagePercent, in.ServerThresholds.Disk.UsagePercent)
+				if !ok {
+					continue
+				}
+				issues = append(issues, models.Issue{
+					Level:        level,
+					Message:      fmt.Sprintf("磁盘 %s 预计%s写满", disk.MountPoint, daysText(f.DaysLeft)),
+					Details:      fmt.Sprintf("当前使用率: %.2f%%, 每天增长约 %.2f 个百分点(最近%d次巡检)", f.Current, f.Rate, f.Samples),
+					Suggestion:   "清理磁盘空间、排查持续增长的文件或提前扩容",
+					ResourceKind: models.ResourceDisk,
+					ResourceName: disk.MountPoint,
+				}.Observe(roundDays(f.DaysLeft), float64(in.History.Trend().WarningDays), "d"))
+			}
+			return issues
+		}),
+
+		checks.New(CheckIDs[1], "disk", "warning", checks.TargetServer, func(in *checks.Input) []models.Issue {
+			r := in.Server
+			if !r.Collected("disk.inodes") {
+				return nil
+			}
+			var issues []models.Issue
+			for _, disk := range r.Disk {
+				if disk.InodesTotal == 0 {
+					continue
+				}
+				f, level, ok := forecast(in, r.Host, disk.MountPoint, history.MetricInodesUsage, r.Timestamp,
+					disk.InodesPercent, in.ServerThresholds.Disk.InodeUsagePercent)
+				if !ok {
+					continue
+				}
+				issues = append(issues, models.Issue{
+					Level:        level,
+					Message:      fmt.Sprintf("磁盘 %s 的Inode预计%s用尽", disk.MountPoint, daysText(f.DaysLeft)),
+					Details:      fmt.Sprintf("当前Inode使用率: %.2f%%, 每天增长约 %.2f 个百分点(最近%d次巡检)", f.Current, f.Rate, f.Samples),
+					Suggestion:   "排查持续产生小文件的程序并清理",
+					ResourceKind: models.ResourceDisk,
+					ResourceName: disk.MountPoint,
+				}.Observe(roundDays(f.DaysLeft), float64(in.History.Trend().WarningDays), "d"))
+			}
+			return issues
+		}),
+
+		checks.New(CheckIDs[2], "memory", "warning", checks.TargetServer, func(in *checks.Input) []models.Issue {
+			r := in.Server
+			if !r.Collected("memory.meminfo") {
+				return nil
+			}
+			f, level, ok := forecast(in, r.Host, "", history.MetricMemoryUsage, r.Timestamp,
+				r.Memory.UsagePercent, in.ServerThresholds.Memory.UsagePercent)
+			if !ok {
+				return nil
+			}
+			issue := models.Issue{
+				Level:      level,
+				Message:    fmt.Sprintf("内存预计%s用尽", daysText(f.DaysLeft)),
+				Details:    fmt.Sprintf("当前使用率: %.2f%%, 每天增长约 %.2f 个百分点(最近%d次巡检)", f.Current, f.Rate, f.Samples),
+				Suggestion: "排查内存持续增长的进程(可能存在内存泄漏)",
+			}.Observe(roundDays(f.DaysLeft), float64(in.History.Trend().WarningDays), "d")
+			return []models.Issue{issue}
+		}),
+
+		checks.New(CheckIDs[3], "node", "warning", checks.TargetK8s, func(in *checks.Input) []models.Issue {
+			var issues []models.Issue
+			for _, node := range in.K8s.Nodes {
+				if node.PodsCapacity == 0 {
+					continue
+				}
+				f, level, ok := forecast(in, in.K8s.Target(), node.Name, history.MetricNodePodsPercent, in.K8s.Timestamp,
+					node.PodPercent, in.K8sThresholds.Node.PodCountPercent)
+				if !ok {
+					continue
+				}
+				issues = append(issues, models.Issue{
+					Level:        level,
+					Message:      fmt.Sprintf("节点 %s 的Pod容量预计%s用满", node.Name, daysText(f.DaysLeft)),
+					Details:      fmt.Sprintf("Pod数量: %d / %d, 每天增长约 %.2f 个百分点(最近%d次巡检)", node.PodCount, node.PodsCapacity, f.Rate, f.Samples),
+					Suggestion:   "提前调大节点的max-pods或增加节点",
+					ResourceKind: models.ResourceNode,
+					ResourceName: node.Name,
+				}.Observe(roundDays(f.DaysLeft), float64(in.History.Trend().WarningDays), "d"))
+			}
+			return issues
+		}),
+	}
+}
+
+// forecast 结合窗口内的历史记录和本次的值预测达到100%的天数, 返回需要生成问题时的级别
+// 本次的值已超过阈值、预计用满时间晚于warning_days或数据不足时返回false
+func forecast(in *checks.Input, target, resource, metric string, now time.Time, current, threshold float64) (Forecast, models.Severity, bool) {
+	h := in.History
+	if h == nil || current > threshold {
+		return Forecast{}, "", false
+	}
+	if now.IsZero() {
+		now = time.Now()
+	}
+	cfg := h.Trend()
+
+	// 窗口内早于本次巡检的历史值
+	var points []Point
+	for _, p := range h.Points(target, resource, metric, now.AddDate(0, 0, -cfg.WindowDays), now) {
+		points = append(points, Point{Time: p.Time, Value: p.Value})
+	}
+	points = append(points, Point{Time: now, Value: current})
+	f, ok := Predict(points, 100, cfg.MinSamples)
+	if !ok {
+		return Forecast{}, "", false
+	}
+
+	switch {
+	case f.DaysLeft <= float64(cfg.CriticalDays):
+		return f, models.SeverityCritical, true
+	case f.DaysLeft <= float64(cfg.WarningDays):
+		return f, models.SeverityWarning, true
+	}
+	return Forecast{}, "", false
+}
+
+// recordHistory 由历史记录文件提供的checks.History
+type recordHistory struct {
+	records []*history.Record
+	trend   config.TrendConfig
+}
+
+// NewHistory 由按时间顺序的历史记录创建趋势类检查项读取的历史指标
+func NewHistory(records []*history.Record, trend config.TrendConfig) checks.History {
+	return &recordHistory{records: records, trend: trend}
+}
+
+func (h *recordHistory) Trend() config.TrendConfig { return h.trend }
+
+func (h *recordHistory) Points(target, resource, metric string, since, until time.Time) []checks.Point {
+	var points []checks.Point
+	for _, record := range h.records {
+		if record.Timestamp.Before(since) || !record.Timestamp.Before(until) {
+			continue
+		}
+		for _, s := range record.Samples {
+			if s.Target == target && s.Resource == resource && s.Metric == metric {
+				points = append(points, checks.Point{Time: record.Timestamp, Value: s.Value})
+				break
+			}
+		}
+	}
+	return points
+}
+
+func roundDays(days float64) float64 {
+	return float64(int(days*10+0.5)) / 10
+}
+
+// daysText 用满时间的描述, 如 "6天后"
+func daysText(days float64) string {
+	if days < 1 {
+		return "1天内"
+	}
+	return fmt.Sprintf("%.0f天后", days)
+}
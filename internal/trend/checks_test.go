@@ -0,0 +1,103 @@
+package trend
+
+import (
+	"inspection-tool/internal/checks"
+	"inspection-tool/internal/config"
+	"inspection-tool/internal/history"
+	"inspection-tool/pkg/models"
+	"strings"
+	"testing"
+)
+
+// trendTestHistory 过去5天/data使用率每天增长5个百分点, 节点Pod使用率每天增长10个百分点
+func trendTestHistory() checks.History {
+	var records []*history.Record
+	for day := 0; day < 5; day++ {
+		records = append(records, history.NewRecord(&models.InspectionReport{
+			Timestamp: start.AddDate(0, 0, day),
+			Type:      "all",
+			ServerReports: []*models.ServerReport{{
+				Host:   "10.0.0.1",
+				Status: models.HostStatusHealthy,
+				Memory: models.MemoryMetrics{UsagePercent: 50},
+				Disk: []models.DiskMetrics{
+					{MountPoint: "/", UsagePercent: 40, InodesTotal: 100, InodesPercent: 10},
+					{MountPoint: "/data", UsagePercent: 50 + 5*float64(day), InodesTotal: 100, InodesPercent: 10},
+				},
+			}},
+			K8sReport: &models.K8sReport{
+				ClusterInfo: models.ClusterInfo{Server: "https://10.0.0.10:6443"},
+				Nodes:       []models.NodeMetrics{{Name: "node-1", PodsCapacity: 100, PodPercent: 20 + 10*float64(day)}},
+			},
+		}))
+	}
+	return NewHistory(records, config.Default().Trend)
+}
+
+func TestServerForecast(t *testing.T) {
+	set, err := checks.Default.Select(CheckIDs, nil)
+	if err != nil {
+		t.Fatalf("Select failed: %v", err)
+	}
+	h := trendTestHistory()
+
+	server := &models.ServerReport{
+		Host:      "10.0.0.1",
+		Timestamp: start.AddDate(0, 0, 5),
+		Memory:    models.MemoryMetrics{UsagePercent: 50},
+		Disk: []models.DiskMetrics{
+			{MountPoint: "/", UsagePercent: 40, InodesTotal: 100, InodesPercent: 10},
+			{MountPoint: "/data", UsagePercent: 75, InodesTotal: 100, InodesPercent: 10},
+		},
+	}
+	thresholds := config.DefaultServerThresholds()
+	issues := set.Evaluate(checks.TargetServer, &checks.Input{Server: server, ServerThresholds: thresholds, History: h})
+	if len(issues) != 1 {
+		t.Fatalf("expected 1 forecast issue, got %+v", issues)
+	}
+	issue := issues[0]
+	if issue.CheckID != "server.disk.usage_forecast" || issue.ResourceName != "/data" || issue.Level != models.SeverityWarning {
+		t.Errorf("unexpected issue: %+v", issue)
+	}
+	if !strings.Contains(issue.Message, "预计5天后写满") || *issue.Observed != 5 || issue.Unit != "d" {
+		t.Errorf("unexpected forecast: %s observed %v", issue.Message, *issue.Observed)
+	}
+
+	// 临近用满时为严重问题
+	server.Disk[1].UsagePercent = 85
+	server.Timestamp = start.AddDate(0, 0, 7)
+	issues = set.Evaluate(checks.TargetServer, &checks.Input{Server: server, ServerThresholds: thresholds, History: h})
+	if len(issues) != 1 || issues[0].Level != models.SeverityCritical {
+		t.Errorf("expected critical forecast, got %+v", issues)
+	}
+
+	// 已超过阈值时由server.disk.usage报告, 不再预测
+	server.Disk[1].UsagePercent = 90
+	if issues := set.Evaluate(checks.TargetServer, &checks.Input{Server: server, ServerThresholds: thresholds, History: h}); len(issues) != 0 {
+		t.Errorf("expected no forecast above threshold, got %+v", issues)
+	}
+
+	// 没有历史记录时不预测
+	server.Disk[1].UsagePercent = 75
+	if issues := set.Evaluate(checks.TargetServer, &checks.Input{Server: server, ServerThresholds: thresholds}); len(issues) != 0 {
+		t.Errorf("expected no forecast without history, got %+v", issues)
+	}
+}
+
+func TestNodePodsForecast(t *testing.T) {
+	set, err := checks.Default.Select([]string{"k8s.node.pods_forecast"}, nil)
+	if err != nil {
+		t.Fatalf("Select failed: %v", err)
+	}
+	h := trendTestHistory()
+
+	k8s := &models.K8sReport{
+		ClusterInfo: models.ClusterInfo{Server: "https://10.0.0.10:6443"},
+		Nodes:       []models.NodeMetrics{{Name: "node-1", PodsCapacity: 100, PodCount: 70, PodPercent: 70}},
+		Timestamp:   start.AddDate(0, 0, 5),
+	}
+	issues := set.Evaluate(checks.TargetK8s, &checks.Input{K8s: k8s, K8sThresholds: config.DefaultK8sThresholds(), History: h})
+	if len(issues) != 1 || issues[0].Level != models.SeverityCritical || !strings.Contains(issues[0].Message, "3天后") {
+		t.Errorf("unexpected node forecast: %+v", issues)
+	}
+}
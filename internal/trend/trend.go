@@ -0,0 +1,108 @@
+package trend
+
+import (
+	"sort"
+	"time"
+)
+
+const (
+	// ResetDrop 相邻两次巡检之间下降超过该值(百分点)视为清理或扩容, 之前的值不参与拟合
+	ResetDrop = 5.0
+	// MinSpan 参与拟合的值至少覆盖的时间跨度, 跨度太短时增长率不可靠
+	MinSpan = 24 * time.Hour
+	// maxPoints 参与拟合的最多点数, 巡检频繁时均匀抽样以限制计算量
+	maxPoints = 200
+)
+
+// Point 指标序列中的一个值
+type Point struct {
+	Time  time.Time
+	Value float64
+}
+
+// Forecast 按增长趋势预测的用满时间
+type Forecast struct {
+	Current  float64 // 最新的值
+	Rate     float64 // 每天的增长量
+	DaysLeft float64 // 预计达到上限的天数
+	Samples  int     // 参与拟合的值的数量
+}
+
+// Predict 预测按当前趋势达到limit的天数, points按时间顺序
+// 增长率用Theil-Sen估计(所有两点间斜率的中位数), 个别异常的值不会明显影响结果
+// 值的数量少于minSamples、时间跨度不足MinSpan或没有增长时返回false
+func Predict(points []Point, limit float64, minSamples int) (Forecast, bool) {
+	points = sinceReset(points)
+	if len(points) < minSamples || len(points) < 2 {
+		return Forecast{}, false
+	}
+	first, last := points[0], points[len(points)-1]
+	if last.Time.Sub(first.Time) < MinSpan {
+		return Forecast{}, false
+	}
+
+	sample := thin(points)
+	days := func(p Point) float64 { return p.Time.Sub(first.Time).Hours() / 24 }
+
+	var slopes []float64
+	for i := range sample {
+		for j := i + 1; j < len(sample); j++ {
+			if dt := days(sample[j]) - days(sample[i]); dt > 0 {
+				slopes = append(slopes, (sample[j].Value-sample[i].Value)/dt)
+			}
+		}
+	}
+	rate := median(slopes)
+	if rate <= 0 {
+		return Forecast{}, false
+	}
+
+	// 拟合直线在最新时间的值, 单次巡检的波动不影响预测
+	offsets := make([]float64, 0, len(sample))
+	for _, p := range sample {
+		offsets = append(offsets, p.Value-rate*days(p))
+	}
+	fitted := median(offsets) + rate*days(last)
+
+	left := (limit - fitted) / rate
+	if left < 0 {
+		left = 0
+	}
+	return Forecast{Current: last.Value, Rate: rate, DaysLeft: left, Samples: len(points)}, true
+}
+
+// sinceReset 最近一次明显下降之后的值
+func sinceReset(points []Point) []Point {
+	for i := len(points) - 1; i > 0; i-- {
+		if points[i-1].Value-points[i].Value > ResetDrop {
+			return points[i:]
+		}
+	}
+	return points
+}
+
+// thin 均匀抽取不超过maxPoints个值, 保留首尾
+func thin(points []Point) []Point {
+	if len(points) <= maxPoints {
+		return points
+	}
+	sample := make([]Point, 0, maxPoints)
+	step := float64(len(points)-1) / float64(maxPoints-1)
+	for i := 0; i < maxPoints; i++ {
+		sample = append(sample, points[int(float64(i)*step+0.5)])
+	}
+	return sample
+}
+
+func median(values []float64) float64 {
+	if len(values) == 0 {
+		return 0
+	}
+	sorted := append([]float64(nil), values...)
+	sort.Float64s(sorted)
+	mid := len(sorted) / 2
+	if len(sorted)%2 == 0 {
+		return (sorted[mid-1] + sorted[mid]) / 2
+	}
+	return sorted[mid]
+}
@@ -0,0 +1,66 @@
+package trend
+
+import (
+	"math"
+	"testing"
+	"time"
+)
+
+var start = time.Date(2024, 1, 1, 2, 0, 0, 0, time.UTC)
+
+// daily 每天一个值
+func daily(values ...float64) []Point {
+	points := make([]Point, 0, len(values))
+	for i, v := range values {
+		points = append(points, Point{Time: start.AddDate(0, 0, i), Value: v})
+	}
+	return points
+}
+
+func TestPredict(t *testing.T) {
+	tests := []struct {
+		name     string
+		points   []Point
+		ok       bool
+		rate     float64
+		daysLeft float64
+	}{
+		{"linear growth", daily(60, 62, 64, 66, 68, 70), true, 2, 15},
+		{"outlier ignored", daily(60, 62, 95, 66, 68, 70), true, 2, 15},
+		{"growth after cleanup", daily(80, 85, 50, 55, 60), true, 5, 8},
+		{"no growth", daily(70, 69, 70, 68, 70), false, 0, 0},
+		{"too few samples", daily(60, 70), false, 0, 0},
+		{"span too short", []Point{{start, 60}, {start.Add(time.Hour), 61}, {start.Add(2 * time.Hour), 62}}, false, 0, 0},
+	}
+	for _, tt := range tests {
+		f, ok := Predict(tt.points, 100, 3)
+		if ok != tt.ok {
+			t.Errorf("%s: ok = %v, want %v", tt.name, ok, tt.ok)
+			continue
+		}
+		if !ok {
+			continue
+		}
+		if math.Abs(f.Rate-tt.rate) > 0.01 || math.Abs(f.DaysLeft-tt.daysLeft) > 0.01 {
+			t.Errorf("%s: rate %.2f days left %.2f, want %.2f and %.2f", tt.name, f.Rate, f.DaysLeft, tt.rate, tt.daysLeft)
+		}
+		if f.Current != tt.points[len(tt.points)-1].Value {
+			t.Errorf("%s: current = %.2f", tt.name, f.Current)
+		}
+	}
+}
+
+func TestPredictManyPoints(t *testing.T) {
+	// 每10分钟一次, 两周共约2000个值, 抽样后结果不变
+	var points []Point
+	for i := 0; i < 2016; i++ {
+		points = append(points, Point{Time: start.Add(time.Duration(i) * 10 * time.Minute), Value: 30 + float64(i)/144})
+	}
+	f, ok := Predict(points, 100, 3)
+	if !ok {
+		t.Fatal("expected forecast")
+	}
+	if math.Abs(f.Rate-1) > 0.01 || f.Samples != len(points) {
+		t.Errorf("unexpected forecast: %+v", f)
+	}
+}
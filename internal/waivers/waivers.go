@@ -0,0 +1,208 @@
+package waivers
+
+import (
+	"fmt"
+	"inspection-tool/internal/config"
+	"inspection-tool/pkg/models"
+	"os"
+	"path"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// DateLayout 过期日期格式
+const DateLayout = "2006-01-02"
+
+// Waiver 一条豁免, 所有已设置的匹配条件都满足时豁免问题
+// 匹配条件支持通配符; check也可以是检查项ID前缀(如 server.disk)
+type Waiver struct {
+	ID        string `yaml:"id"` // 可选, 为空时使用序号
+	Check     string `yaml:"check"`
+	Host      string `yaml:"host"`
+	Category  string `yaml:"category"`
+	Namespace string `yaml:"namespace"`
+	Pod       string `yaml:"pod"`
+
+	Reason  string `yaml:"reason"`
+	Owner   string `yaml:"owner"`
+	Expires string `yaml:"expires"` // YYYY-MM-DD, 当天结束前有效
+
+	expires time.Time
+}
+
+// File 豁免文件
+type File struct {
+	Waivers []Waiver `yaml:"waivers"`
+}
+
+// List 已加载的豁免
+type List struct {
+	waivers []Waiver
+	now     func() time.Time
+}
+
+// Load 加载豁免文件
+func Load(file string) (*List, error) {
+	data, err := os.ReadFile(config.ExpandHome(file))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read waivers: %w", err)
+	}
+	return Parse(data)
+}
+
+// Parse 解析并校验豁免, 原因、负责人和过期日期必须填写
+func Parse(data []byte) (*List, error) {
+	var f File
+	if err := yaml.Unmarshal(data, &f); err != nil {
+		return nil, fmt.Errorf("failed to parse waivers: %w", err)
+	}
+
+	l := &List{now: time.Now}
+	for i, w := range f.Waivers {
+		if w.ID == "" {
+			w.ID = fmt.Sprintf("%d", i+1)
+		}
+		if err := w.validate(); err != nil {
+			return nil, fmt.Errorf("waiver %s: %w", w.ID, err)
+		}
+		l.waivers = append(l.waivers, w)
+	}
+	return l, nil
+}
+
+// validate 校验豁免并解析过期日期
+func (w *Waiver) validate() error {
+	if w.Check == "" && w.Host == "" && w.Category == "" && w.Namespace == "" && w.Pod == "" {
+		return fmt.Errorf("at least one of check, host, category, namespace or pod is required")
+	}
+	for _, p := range []string{w.Check, w.Host, w.Category, w.Namespace, w.Pod} {
+		if _, err := path.Match(p, ""); err != nil {
+			return fmt.Errorf("invalid pattern %q: %w", p, err)
+		}
+	}
+	if strings.TrimSpace(w.Reason) == "" {
+		return fmt.Errorf("reason is required")
+	}
+	if strings.TrimSpace(w.Owner) == "" {
+		return fmt.Errorf("owner is required")
+	}
+	if w.Expires == "" {
+		return fmt.Errorf("expires is required")
+	}
+	day, err := time.ParseInLocation(DateLayout, w.Expires, time.Local)
+	if err != nil {
+		return fmt.Errorf("expires must be YYYY-MM-DD: %w", err)
+	}
+	w.expires = day.AddDate(0, 0, 1)
+	return nil
+}
+
+// Waivers 返回已加载的豁免
+func (l *List) Waivers() []Waiver {
+	if l == nil {
+		return nil
+	}
+	return l.waivers
+}
+
+// Expired 判断豁免在t时刻是否已过期
+func (w *Waiver) Expired(t time.Time) bool {
+	return !t.Before(w.expires)
+}
+
+// Matches 判断豁免是否匹配问题, host为问题所属的服务器, K8s问题为空
+func (w *Waiver) Matches(issue models.Issue, host string) bool {
+	if w.Check != "" && !matchCheck(w.Check, issue.CheckID) {
+		return false
+	}
+	return matchPattern(w.Host, host) &&
+		matchPattern(w.Category, issue.Category) &&
+		matchPattern(w.Namespace, issue.Namespace) &&
+		matchPattern(w.Pod, issuePod(issue))
+}
+
+// Apply 标记被有效豁免匹配的问题, 问题保留在报告中但不计入统计和退出码
+// 匹配到问题的过期豁免不生效, 并为每条过期豁免追加一个info级别的问题
+func (l *List) Apply(issues []models.Issue, host string) []models.Issue {
+	if l == nil || len(l.waivers) == 0 {
+		return issues
+	}
+
+	now := l.now()
+	var expired []*Waiver
+	for i := range issues {
+		if issues[i].Suppressed {
+			continue
+		}
+		for j := range l.waivers {
+			w := &l.waivers[j]
+			if !w.Matches(issues[i], host) {
+				continue
+			}
+			if w.Expired(now) {
+				expired = appendOnce(expired, w)
+				continue
+			}
+			issues[i].Suppressed = true
+			issues[i].Waiver = &models.IssueWaiver{
+				ID:      w.ID,
+				Reason:  w.Reason,
+				Owner:   w.Owner,
+				Expires: w.Expires,
+			}
+			break
+		}
+	}
+
+	for _, w := range expired {
+		issues = append(issues, models.Issue{
+			Level:        models.SeverityInfo,
+			Category:     "waiver",
+			Message:      fmt.Sprintf("豁免已过期: %s", w.ID),
+			Details:      fmt.Sprintf("过期日期: %s, 负责人: %s, 原因: %s", w.Expires, w.Owner, w.Reason),
+			Timestamp:    now,
+			Suggestion:   "处理被豁免的问题, 或由负责人续期该豁免",
+			ResourceKind: models.ResourceWaiver,
+			ResourceName: w.ID,
+		})
+	}
+	return issues
+}
+
+// issuePod 问题涉及的Pod名称, 不涉及Pod时为空
+func issuePod(issue models.Issue) string {
+	if issue.ResourceKind != models.ResourcePod {
+		return ""
+	}
+	return issue.ResourceName
+}
+
+// appendOnce 追加尚未包含的豁免
+func appendOnce(list []*Waiver, w *Waiver) []*Waiver {
+	for _, existing := range list {
+		if existing == w {
+			return list
+		}
+	}
+	return append(list, w)
+}
+
+// matchCheck 检查项ID匹配: 完全相同、ID前缀或通配符
+func matchCheck(pattern, id string) bool {
+	if pattern == id || strings.HasPrefix(id, pattern+".") {
+		return true
+	}
+	ok, _ := path.Match(pattern, id)
+	return ok
+}
+
+// matchPattern 通配符匹配, 空模式匹配任意值
+func matchPattern(pattern, value string) bool {
+	if pattern == "" {
+		return true
+	}
+	ok, _ := path.Match(pattern, value)
+	return ok
+}
@@ -0,0 +1,124 @@
+package waivers
+
+import (
+	"inspection-tool/pkg/models"
+	"strings"
+	"testing"
+	"time"
+)
+
+const testWaivers = `
+waivers:
+  - id: archive-disk
+    check: server.disk.usage
+    host: "archive*"
+    reason: 归档盘按设计保持写满
+    owner: storage-team
+    expires: 2030-06-30
+  - id: batch-restarts
+    check: k8s.pod.restarts
+    namespace: batch
+    pod: "etl-*"
+    reason: 批处理任务失败后由调度器重试
+    owner: data-team
+    expires: 2030-01-31
+  - id: old-swap
+    category: memory
+    reason: 迁移前临时接受
+    owner: ops
+    expires: 2029-12-31
+`
+
+func loadTestList(t *testing.T, now time.Time) *List {
+	t.Helper()
+	l, err := Parse([]byte(testWaivers))
+	if err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+	l.now = func() time.Time { return now }
+	return l
+}
+
+func TestParseValidation(t *testing.T) {
+	tests := []struct {
+		name string
+		data string
+		want string
+	}{
+		{"no matcher", "waivers:\n  - reason: r\n    owner: o\n    expires: 2030-01-01\n", "at least one of"},
+		{"no reason", "waivers:\n  - check: server.disk\n    owner: o\n    expires: 2030-01-01\n", "reason is required"},
+		{"no owner", "waivers:\n  - check: server.disk\n    reason: r\n    expires: 2030-01-01\n", "owner is required"},
+		{"no expiry", "waivers:\n  - check: server.disk\n    reason: r\n    owner: o\n", "expires is required"},
+		{"bad date", "waivers:\n  - check: server.disk\n    reason: r\n    owner: o\n    expires: 2030/01/01\n", "YYYY-MM-DD"},
+		{"bad pattern", "waivers:\n  - host: \"[web\"\n    reason: r\n    owner: o\n    expires: 2030-01-01\n", "invalid pattern"},
+	}
+
+	for _, tt := range tests {
+		_, err := Parse([]byte(tt.data))
+		if err == nil || !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("%s: expected error containing %q, got %v", tt.name, tt.want, err)
+		}
+	}
+}
+
+func TestApply(t *testing.T) {
+	l := loadTestList(t, time.Date(2030, 1, 15, 12, 0, 0, 0, time.Local))
+
+	issues := l.Apply([]models.Issue{
+		{CheckID: "server.disk.usage", Level: "critical", Category: "disk"},
+		{CheckID: "server.disk.inodes", Level: "warning", Category: "disk"},
+	}, "archive01")
+	if !issues[0].Suppressed || issues[0].Waiver == nil || issues[0].Waiver.ID != "archive-disk" {
+		t.Errorf("Expected disk usage issue to be waived, got %+v", issues[0])
+	}
+	if issues[1].Suppressed {
+		t.Errorf("Expected other check not to be waived, got %+v", issues[1])
+	}
+
+	issues = l.Apply([]models.Issue{{CheckID: "server.disk.usage", Level: "critical", Category: "disk"}}, "web01")
+	if issues[0].Suppressed {
+		t.Error("Expected host pattern to restrict the waiver")
+	}
+
+	issues = l.Apply([]models.Issue{
+		{CheckID: "k8s.pod.restarts", Level: "warning", Category: "pod", Namespace: "batch", ResourceKind: models.ResourcePod, ResourceName: "etl-nightly-x7k2p"},
+		{CheckID: "k8s.pod.restarts", Level: "warning", Category: "pod", Namespace: "prod", ResourceKind: models.ResourcePod, ResourceName: "etl-nightly-x7k2p"},
+	}, "")
+	if !issues[0].Suppressed || issues[1].Suppressed {
+		t.Errorf("Expected only the batch namespace pod to be waived, got %+v", issues)
+	}
+}
+
+func TestApplyExpired(t *testing.T) {
+	// batch-restarts 2030-01-31 当天仍有效, 次日过期
+	l := loadTestList(t, time.Date(2030, 1, 31, 23, 0, 0, 0, time.Local))
+	issues := l.Apply([]models.Issue{{CheckID: "k8s.pod.restarts", Category: "pod", Namespace: "batch", ResourceKind: models.ResourcePod, ResourceName: "etl-1"}}, "")
+	if !issues[0].Suppressed {
+		t.Error("Expected waiver to be valid until the end of its expiry date")
+	}
+
+	l.now = func() time.Time { return time.Date(2030, 2, 1, 0, 0, 0, 0, time.Local) }
+	issues = l.Apply([]models.Issue{
+		{CheckID: "k8s.pod.restarts", Level: "warning", Category: "pod", Namespace: "batch", ResourceKind: models.ResourcePod, ResourceName: "etl-1"},
+		{CheckID: "k8s.pod.restarts", Level: "warning", Category: "pod", Namespace: "batch", ResourceKind: models.ResourcePod, ResourceName: "etl-2"},
+		{CheckID: "k8s.pod.memory", Level: "warning", Category: "pod", Namespace: "batch", ResourceKind: models.ResourcePod, ResourceName: "etl-1"},
+	}, "")
+	if len(issues) != 4 {
+		t.Fatalf("Expected one info issue for the expired waiver, got %+v", issues)
+	}
+	if issues[0].Suppressed || issues[1].Suppressed {
+		t.Error("Expected expired waiver not to suppress issues")
+	}
+	expired := issues[3]
+	if expired.Level != "info" || expired.Category != "waiver" || !strings.Contains(expired.Message, "batch-restarts") {
+		t.Errorf("Unexpected expired waiver issue: %+v", expired)
+	}
+}
+
+func TestApplyNil(t *testing.T) {
+	var l *List
+	issues := []models.Issue{{CheckID: "server.disk.usage", Level: "critical"}}
+	if got := l.Apply(issues, "web01"); len(got) != 1 || got[0].Suppressed {
+		t.Errorf("Expected nil list to leave issues unchanged, got %+v", got)
+	}
+}
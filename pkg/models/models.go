@@ -1,29 +1,77 @@
 package models
 
-import "time"
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"strings"
+	"time"
+)
 
 // InspectionReport 巡检报告
 type InspectionReport struct {
-	Timestamp    time.Time         `json:"timestamp" yaml:"timestamp"`
-	Type         string            `json:"type" yaml:"type"` // server, k8s, all
-	ServerReport *ServerReport     `json:"server_report,omitempty" yaml:"server_report,omitempty"`
-	K8sReport    *K8sReport        `json:"k8s_report,omitempty" yaml:"k8s_report,omitempty"`
-	Summary      InspectionSummary `json:"summary" yaml:"summary"`
+	Timestamp     time.Time         `json:"timestamp" yaml:"timestamp"`
+	Type          string            `json:"type" yaml:"type"` // server, k8s, all
+	ServerReport  *ServerReport     `json:"server_report,omitempty" yaml:"server_report,omitempty"`   // 单台服务器巡检
+	ServerReports []*ServerReport   `json:"server_reports,omitempty" yaml:"server_reports,omitempty"` // 多台服务器巡检
+	K8sReport     *K8sReport        `json:"k8s_report,omitempty" yaml:"k8s_report,omitempty"`
+	Summary       InspectionSummary `json:"summary" yaml:"summary"`
+}
+
+// Servers 返回报告中的全部服务器报告(ServerReport与ServerReports合并去重)
+func (r *InspectionReport) Servers() []*ServerReport {
+	servers := make([]*ServerReport, 0, len(r.ServerReports)+1)
+	if r.ServerReport != nil {
+		servers = append(servers, r.ServerReport)
+	}
+	for _, sr := range r.ServerReports {
+		if sr != nil && sr != r.ServerReport {
+			servers = append(servers, sr)
+		}
+	}
+	return servers
 }
 
 // InspectionSummary 巡检摘要
 type InspectionSummary struct {
-	TotalIssues    int      `json:"total_issues" yaml:"total_issues"`
-	CriticalIssues int      `json:"critical_issues" yaml:"critical_issues"`
-	WarningIssues  int      `json:"warning_issues" yaml:"warning_issues"`
-	InfoIssues     int      `json:"info_issues" yaml:"info_issues"`
-	Status         string   `json:"status" yaml:"status"` // healthy, warning, critical
-	Messages       []string `json:"messages" yaml:"messages"`
+	TotalIssues    int           `json:"total_issues" yaml:"total_issues"`
+	CriticalIssues int           `json:"critical_issues" yaml:"critical_issues"`
+	WarningIssues  int           `json:"warning_issues" yaml:"warning_issues"`
+	InfoIssues     int           `json:"info_issues" yaml:"info_issues"`
+	WaivedIssues   int           `json:"waived_issues" yaml:"waived_issues"` // 被豁免的问题, 不计入以上统计
+	Status         string        `json:"status" yaml:"status"` // healthy, warning, critical
+	Messages       []string      `json:"messages" yaml:"messages"`
+	Fleet          *FleetSummary `json:"fleet,omitempty" yaml:"fleet,omitempty"` // 服务器整体情况
+}
+
+// FleetSummary 多台服务器的汇总
+type FleetSummary struct {
+	TotalServers    int           `json:"total_servers" yaml:"total_servers"`
+	HealthyServers  int           `json:"healthy_servers" yaml:"healthy_servers"`
+	WarningServers  int           `json:"warning_servers" yaml:"warning_servers"`
+	CriticalServers int           `json:"critical_servers" yaml:"critical_servers"`
+	FailedServers   int           `json:"failed_servers" yaml:"failed_servers"` // 连接或巡检失败
+	Status          string        `json:"status" yaml:"status"` // 最严重的主机状态
+	Hosts           []HostSummary `json:"hosts" yaml:"hosts"`
+}
+
+// HostSummary 单台服务器的状态和问题数
+type HostSummary struct {
+	Host           string `json:"host" yaml:"host"`
+	Group          string `json:"group,omitempty" yaml:"group,omitempty"`
+	Status         string `json:"status" yaml:"status"`
+	CriticalIssues int    `json:"critical_issues" yaml:"critical_issues"`
+	WarningIssues  int    `json:"warning_issues" yaml:"warning_issues"`
+	InfoIssues     int    `json:"info_issues" yaml:"info_issues"`
 }
 
 // ServerReport 服务器巡检报告
 type ServerReport struct {
 	Host      string               `json:"host" yaml:"host"`
+	Group     string               `json:"group,omitempty" yaml:"group,omitempty"` // 主机清单中的主分组
+	Tags      []string             `json:"tags,omitempty" yaml:"tags,omitempty"`   // 主机清单中的标签
+	Status    string               `json:"status,omitempty" yaml:"status,omitempty"` // 见 HostStatus* 常量
+	NodeName  string               `json:"node_name,omitempty" yaml:"node_name,omitempty"` // 对应的K8s节点名称
 	OS        OSInfo               `json:"os" yaml:"os"`
 	CPU       CPUMetrics           `json:"cpu" yaml:"cpu"`
 	Memory    MemoryMetrics        `json:"memory" yaml:"memory"`
@@ -32,6 +80,67 @@ type ServerReport struct {
 	System    SystemMetrics        `json:"system" yaml:"system"`
 	Issues    []Issue              `json:"issues" yaml:"issues"`
 	Timestamp time.Time            `json:"timestamp" yaml:"timestamp"`
+
+	// 各采集步骤的执行状态, 部分采集失败时报告仍然生成
+	Collectors []CollectorStatus `json:"collectors,omitempty" yaml:"collectors,omitempty"`
+}
+
+// 服务器状态: 巡检成功时按问题级别确定, 连接或巡检失败时为对应的失败状态
+const (
+	HostStatusHealthy          = "healthy"
+	HostStatusWarning          = "warning"
+	HostStatusCritical         = "critical"
+	HostStatusUnreachable      = "unreachable"       // 网络不可达或连接被拒绝
+	HostStatusAuthFailed       = "auth_failed"       // SSH认证失败或主机密钥校验失败
+	HostStatusTimeout          = "timeout"           // 连接或握手超时
+	HostStatusInspectionFailed = "inspection_failed" // 已连接但巡检命令执行失败
+)
+
+// Failed 判断服务器是否因连接或巡检失败而没有采集到指标
+func (r *ServerReport) Failed() bool {
+	switch r.Status {
+	case HostStatusUnreachable, HostStatusAuthFailed, HostStatusTimeout, HostStatusInspectionFailed:
+		return true
+	}
+	return false
+}
+
+// Collected 判断指标是否采集成功, 未采集的指标为零值, 不代表实际值为0
+// metric为 "section" 或 "section.field" 形式, 如 memory、network.tcp_connections
+func (r *ServerReport) Collected(metric string) bool {
+	for _, c := range r.Collectors {
+		if c.Status == CollectorFailed && (metric == c.Name || strings.HasPrefix(metric, c.Name+".")) {
+			return false
+		}
+		for _, m := range c.Missing {
+			if metric == m || strings.HasPrefix(metric, m+".") {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// 采集步骤状态
+const (
+	CollectorOK       = "ok"       // 全部命令执行成功
+	CollectorDegraded = "degraded" // 部分命令失败, 其余指标有效
+	CollectorFailed   = "failed"   // 全部命令失败, 该部分指标均未采集
+)
+
+// CollectorStatus 单个采集步骤(os、cpu、memory、disk、network、system)的执行状态
+type CollectorStatus struct {
+	Name     string           `json:"name" yaml:"name"`
+	Status   string           `json:"status" yaml:"status"`
+	Failures []CommandFailure `json:"failures,omitempty" yaml:"failures,omitempty"`
+	Missing  []string         `json:"missing,omitempty" yaml:"missing,omitempty"` // 未能采集的指标
+}
+
+// CommandFailure 执行失败的采集命令
+type CommandFailure struct {
+	Command string `json:"command" yaml:"command"`
+	Error   string `json:"error,omitempty" yaml:"error,omitempty"`
+	Stderr  string `json:"stderr,omitempty" yaml:"stderr,omitempty"`
 }
 
 // OSInfo 操作系统信息
@@ -153,12 +262,164 @@ type SystemMetrics struct {
 
 // Issue 问题项
 type Issue struct {
-	Level       string    `json:"level" yaml:"level"` // critical, warning, info
+	CheckID     string    `json:"check_id,omitempty" yaml:"check_id,omitempty"` // 生成该问题的检查项ID
+	Fingerprint string    `json:"fingerprint,omitempty" yaml:"fingerprint,omitempty"` // 由检查项ID、巡检对象和资源生成, 跨次巡检稳定
+	Level       Severity  `json:"level" yaml:"level"`
 	Category    string    `json:"category" yaml:"category"`
 	Message     string    `json:"message" yaml:"message"`
 	Details     string    `json:"details" yaml:"details"`
 	Timestamp   time.Time `json:"timestamp" yaml:"timestamp"`
 	Suggestion  string    `json:"suggestion" yaml:"suggestion"`
+
+	// 结构化字段, 供下游工具使用而无需解析问题描述
+	Target       string   `json:"target,omitempty" yaml:"target,omitempty"`               // 服务器地址或K8s集群API地址
+	ResourceKind string   `json:"resource_kind,omitempty" yaml:"resource_kind,omitempty"` // 见 Resource* 常量, 为空时问题针对整个巡检对象
+	ResourceName string   `json:"resource_name,omitempty" yaml:"resource_name,omitempty"`
+	Namespace    string   `json:"namespace,omitempty" yaml:"namespace,omitempty"` // K8s资源所在命名空间
+	Observed     *float64 `json:"observed,omitempty" yaml:"observed,omitempty"`   // 实际值
+	Threshold    *float64 `json:"threshold,omitempty" yaml:"threshold,omitempty"` // 触发问题的阈值
+	Unit         string   `json:"unit,omitempty" yaml:"unit,omitempty"`           // 实际值和阈值的单位, 如 %、MB
+
+	// 被豁免的问题保留在报告中, 但不计入问题统计、状态和退出码
+	Suppressed bool         `json:"suppressed,omitempty" yaml:"suppressed,omitempty"`
+	Waiver     *IssueWaiver `json:"waiver,omitempty" yaml:"waiver,omitempty"`
+}
+
+// Severity 问题级别
+type Severity string
+
+const (
+	SeverityCritical Severity = "critical"
+	SeverityWarning  Severity = "warning"
+	SeverityInfo     Severity = "info"
+)
+
+// ParseSeverity 解析问题级别, 只接受 critical、warning、info
+func ParseSeverity(s string) (Severity, error) {
+	severity := Severity(s)
+	if !severity.Valid() {
+		return "", fmt.Errorf("invalid severity %q, must be critical, warning or info", s)
+	}
+	return severity, nil
+}
+
+// Valid 判断是否为已知的问题级别
+func (s Severity) Valid() bool {
+	switch s {
+	case SeverityCritical, SeverityWarning, SeverityInfo:
+		return true
+	}
+	return false
+}
+
+// Rank 级别的严重程度, 越严重越大, 未知级别为0
+func (s Severity) Rank() int {
+	switch s {
+	case SeverityCritical:
+		return 3
+	case SeverityWarning:
+		return 2
+	case SeverityInfo:
+		return 1
+	}
+	return 0
+}
+
+// legacySeverities 旧版报告或其他工具使用的级别写法
+var legacySeverities = map[string]Severity{
+	"":       SeverityInfo,
+	"fatal":  SeverityCritical,
+	"error":  SeverityCritical,
+	"high":   SeverityCritical,
+	"warn":   SeverityWarning,
+	"medium": SeverityWarning,
+	"low":    SeverityInfo,
+	"notice": SeverityInfo,
+	"debug":  SeverityInfo,
+}
+
+// UnmarshalText 读取报告时解析问题级别, 兼容旧版写法, 空值和未知级别按 info 处理,
+// 避免一条历史数据导致整份报告无法加载
+func (s *Severity) UnmarshalText(text []byte) error {
+	level := strings.ToLower(strings.TrimSpace(string(text)))
+	if severity := Severity(level); severity.Valid() {
+		*s = severity
+		return nil
+	}
+	if severity, ok := legacySeverities[level]; ok {
+		*s = severity
+		return nil
+	}
+	*s = SeverityInfo
+	return nil
+}
+
+// 问题涉及的资源类型
+const (
+	ResourceDisk      = "disk"      // 挂载点
+	ResourceDevice    = "device"    // 块设备
+	ResourceInterface = "interface" // 网络接口
+	ResourceCollector = "collector" // 采集步骤
+	ResourceNode      = "node"
+	ResourcePod       = "pod"
+	ResourceComponent = "component" // 控制平面组件
+	ResourceWaiver    = "waiver"
+)
+
+// Observe 设置实际值、阈值和单位
+func (i Issue) Observe(observed, threshold float64, unit string) Issue {
+	i.Observed = &observed
+	i.Threshold = &threshold
+	i.Unit = unit
+	return i
+}
+
+// Resource 资源标识, 形式为 kind/namespace/name, 未设置的部分为空
+func (i *Issue) Resource() string {
+	if i.ResourceKind == "" && i.ResourceName == "" {
+		return ""
+	}
+	return i.ResourceKind + "/" + i.Namespace + "/" + i.ResourceName
+}
+
+// CollectionFailure 判断问题是否表示指标采集失败而不是巡检对象本身的问题:
+// 采集步骤未完整执行, 或控制平面组件的指标获取失败(不是由检查项生成的组件问题)
+func (i *Issue) CollectionFailure() bool {
+	return i.ResourceKind == ResourceCollector || (i.ResourceKind == ResourceComponent && i.CheckID == "")
+}
+
+// Identify 设置问题所属的巡检对象并计算指纹, 已设置的Target保持不变
+func (i *Issue) Identify(target string) {
+	if i.Target == "" {
+		i.Target = target
+	}
+	i.Fingerprint = IssueFingerprint(i.CheckID, i.Category, i.Target, i.Resource())
+}
+
+// IdentifyIssues 为问题列表设置巡检对象和指纹
+func IdentifyIssues(issues []Issue, target string) {
+	for idx := range issues {
+		issues[idx].Identify(target)
+	}
+}
+
+// IssueFingerprint 计算问题指纹: 相同检查项在同一对象的同一资源上生成的问题指纹相同
+// 不由检查项生成的问题(如连接失败)使用类别代替检查项ID
+func IssueFingerprint(checkID, category, target, resource string) string {
+	key := checkID
+	if key == "" {
+		key = "category:" + category
+	}
+	sum := sha256.Sum256([]byte(key + "\x00" + target + "\x00" + resource))
+	return hex.EncodeToString(sum[:8])
+}
+
+// IssueWaiver 豁免问题的记录
+type IssueWaiver struct {
+	ID      string `json:"id" yaml:"id"`
+	Reason  string `json:"reason" yaml:"reason"`
+	Owner   string `json:"owner" yaml:"owner"`
+	Expires string `json:"expires" yaml:"expires"`
 }
 
 // K8sReport Kubernetes巡检报告
@@ -174,8 +435,17 @@ type K8sReport struct {
 	Timestamp        time.Time          `json:"timestamp" yaml:"timestamp"`
 }
 
+// Target 集群在问题中的标识, 未记录API Server地址时为k8s
+func (r *K8sReport) Target() string {
+	if r.ClusterInfo.Server != "" {
+		return r.ClusterInfo.Server
+	}
+	return "k8s"
+}
+
 // ClusterInfo 集群信息
 type ClusterInfo struct {
+	Server        string `json:"server,omitempty" yaml:"server,omitempty"` // API Server地址
 	Version       string `json:"version" yaml:"version"`
 	NodeCount     int    `json:"node_count" yaml:"node_count"`
 	PodCount      int    `json:"pod_count" yaml:"pod_count"`
@@ -185,6 +455,9 @@ type ClusterInfo struct {
 // NodeMetrics 节点指标
 type NodeMetrics struct {
 	Name              string            `json:"name" yaml:"name"`
+	InternalIP        string            `json:"internal_ip,omitempty" yaml:"internal_ip,omitempty"`
+	ExternalIP        string            `json:"external_ip,omitempty" yaml:"external_ip,omitempty"`
+	Hostname          string            `json:"hostname,omitempty" yaml:"hostname,omitempty"`
 	Ready             bool              `json:"ready" yaml:"ready"`
 	Conditions        []NodeCondition   `json:"conditions" yaml:"conditions"`
 	CPUCapacity       string            `json:"cpu_capacity" yaml:"cpu_capacity"`
@@ -204,6 +477,37 @@ type NodeMetrics struct {
 	KubeletVersion    string            `json:"kubelet_version" yaml:"kubelet_version"`
 }
 
+// 节点地址类型, 与Kubernetes NodeAddressType一致
+const (
+	NodeInternalIP = "InternalIP"
+	NodeExternalIP = "ExternalIP"
+	NodeHostname   = "Hostname"
+)
+
+// Address 按优先顺序返回节点的第一个可用地址, 都没有时返回节点名称
+func (n *NodeMetrics) Address(preference []string) string {
+	for _, t := range preference {
+		var addr string
+		switch t {
+		case NodeInternalIP:
+			addr = n.InternalIP
+		case NodeExternalIP:
+			addr = n.ExternalIP
+		case NodeHostname:
+			addr = n.Hostname
+		}
+		if addr != "" {
+			return addr
+		}
+	}
+	return n.Name
+}
+
+// UsageCollected 判断是否从metrics API获取到节点资源使用, 未获取时使用百分比为零值
+func (n *NodeMetrics) UsageCollected() bool {
+	return n.CPUUsage != "" || n.MemoryUsage != ""
+}
+
 // NodeCondition 节点状态
 type NodeCondition struct {
 	Type    string `json:"type" yaml:"type"`
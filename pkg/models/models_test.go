@@ -1,8 +1,11 @@
 package models
 
 import (
+	"encoding/json"
 	"testing"
 	"time"
+
+	"gopkg.in/yaml.v3"
 )
 
 func TestInspectionReport(t *testing.T) {
@@ -84,6 +87,85 @@ func TestIssue(t *testing.T) {
 	}
 }
 
+func TestSeverity(t *testing.T) {
+	for _, s := range []string{"critical", "warning", "info"} {
+		if _, err := ParseSeverity(s); err != nil {
+			t.Errorf("ParseSeverity(%q) failed: %v", s, err)
+		}
+	}
+	for _, s := range []string{"", "error", "Critical"} {
+		if _, err := ParseSeverity(s); err == nil {
+			t.Errorf("Expected ParseSeverity(%q) to fail", s)
+		}
+	}
+
+	if SeverityCritical.Rank() <= SeverityWarning.Rank() || SeverityWarning.Rank() <= SeverityInfo.Rank() {
+		t.Error("Expected critical > warning > info")
+	}
+
+	var issue Issue
+	if err := json.Unmarshal([]byte(`{"level":"warning"}`), &issue); err != nil || issue.Level != SeverityWarning {
+		t.Errorf("Expected warning level, got %q (%v)", issue.Level, err)
+	}
+	for raw, want := range map[string]Severity{
+		`{"level":""}`:         SeverityInfo,
+		`{}`:                   "",
+		`{"level":"Critical"}`: SeverityCritical,
+		`{"level":"error"}`:    SeverityCritical,
+		`{"level":"warn"}`:     SeverityWarning,
+		`{"level":"urgent"}`:   SeverityInfo,
+	} {
+		var issue Issue
+		if err := json.Unmarshal([]byte(raw), &issue); err != nil || issue.Level != want {
+			t.Errorf("Unmarshal %s: expected %q, got %q (%v)", raw, want, issue.Level, err)
+		}
+	}
+	var loaded struct {
+		Level Severity `yaml:"level"`
+	}
+	if err := yaml.Unmarshal([]byte("level: \"\"\n"), &loaded); err != nil || loaded.Level != SeverityInfo {
+		t.Errorf("Expected empty yaml level to load as info, got %q (%v)", loaded.Level, err)
+	}
+}
+
+func TestIssueFingerprint(t *testing.T) {
+	disk := func(mount string, usage float64) Issue {
+		return Issue{
+			CheckID:      "server.disk.usage",
+			Level:        SeverityCritical,
+			Category:     "disk",
+			Message:      "磁盘空间不足",
+			ResourceKind: ResourceDisk,
+			ResourceName: mount,
+		}.Observe(usage, 85, "%")
+	}
+
+	a, b := disk("/data", 91), disk("/data", 97)
+	a.Identify("10.0.0.1")
+	b.Identify("10.0.0.1")
+	if a.Fingerprint == "" || a.Fingerprint != b.Fingerprint {
+		t.Errorf("Expected same check, target and resource to share a fingerprint, got %q / %q", a.Fingerprint, b.Fingerprint)
+	}
+	if a.Target != "10.0.0.1" || *a.Observed != 91 || *a.Threshold != 85 || a.Unit != "%" {
+		t.Errorf("Unexpected structured fields: %+v", a)
+	}
+
+	other := disk("/var", 91)
+	other.Identify("10.0.0.1")
+	otherHost := disk("/data", 91)
+	otherHost.Identify("10.0.0.2")
+	if other.Fingerprint == a.Fingerprint || otherHost.Fingerprint == a.Fingerprint {
+		t.Error("Expected different resource or target to change the fingerprint")
+	}
+
+	// 没有检查项ID的问题按类别区分
+	conn := Issue{Level: SeverityCritical, Category: "connectivity", Message: "主机不可达"}
+	conn.Identify("10.0.0.1")
+	if conn.Fingerprint == "" || conn.Fingerprint == a.Fingerprint {
+		t.Errorf("Unexpected fingerprint for issue without check id: %q", conn.Fingerprint)
+	}
+}
+
 func TestCPUMetrics(t *testing.T) {
 	metrics := CPUMetrics{
 		CoreCount:     8,
@@ -120,3 +202,23 @@ func TestMemoryMetrics(t *testing.T) {
 		t.Errorf("Expected 50%% usage, got %.2f%%", metrics.UsagePercent)
 	}
 }
+
+func TestNodeAddress(t *testing.T) {
+	node := &NodeMetrics{Name: "node-1", InternalIP: "10.0.0.1", Hostname: "node-1.local"}
+
+	tests := []struct {
+		preference []string
+		expected   string
+	}{
+		{[]string{NodeInternalIP, NodeExternalIP, NodeHostname}, "10.0.0.1"},
+		{[]string{NodeExternalIP, NodeHostname}, "node-1.local"},
+		{[]string{NodeExternalIP}, "node-1"},
+		{nil, "node-1"},
+	}
+
+	for _, tt := range tests {
+		if got := node.Address(tt.preference); got != tt.expected {
+			t.Errorf("Address(%v) = %s, expected %s", tt.preference, got, tt.expected)
+		}
+	}
+}
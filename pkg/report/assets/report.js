@@ -0,0 +1,61 @@
+(function () {
+  var table = document.getElementById("issues");
+  var body = table.tBodies[0];
+  var rows = Array.prototype.slice.call(body.querySelectorAll("tr[data-level]"));
+
+  // 按级别、类别、是否豁免和关键字筛选
+  function applyFilters() {
+    var levels = {};
+    document.querySelectorAll(".level-filter").forEach(function (box) {
+      levels[box.value] = box.checked;
+    });
+    var category = document.getElementById("category-filter").value;
+    var showSuppressed = document.getElementById("show-suppressed").checked;
+    var keyword = document.getElementById("search").value.trim().toLowerCase();
+
+    var shown = 0;
+    rows.forEach(function (row) {
+      var visible = levels[row.dataset.level] !== false &&
+        (!category || row.dataset.category === category) &&
+        (showSuppressed || !row.hasAttribute("data-suppressed")) &&
+        (!keyword || row.textContent.toLowerCase().indexOf(keyword) >= 0);
+      row.style.display = visible ? "" : "none";
+      if (visible) shown++;
+    });
+    document.getElementById("issue-count").textContent = "显示 " + shown + " / " + rows.length;
+  }
+
+  // 单元格排序值: 优先取data-value, 数字按数值比较
+  function cellValue(row, index) {
+    var cell = row.cells[index];
+    var value = cell.dataset.value !== undefined ? cell.dataset.value : cell.textContent.trim();
+    var number = parseFloat(value);
+    return isNaN(number) || String(number) !== value ? value.toLowerCase() : number;
+  }
+
+  table.querySelectorAll("th[data-sort]").forEach(function (th) {
+    th.addEventListener("click", function () {
+      var index = th.cellIndex;
+      var desc = !th.classList.contains("desc");
+      table.querySelectorAll("th").forEach(function (other) {
+        other.classList.remove("asc", "desc");
+      });
+      th.classList.add(desc ? "desc" : "asc");
+
+      rows.sort(function (a, b) {
+        var x = cellValue(a, index), y = cellValue(b, index);
+        var result = x < y ? -1 : x > y ? 1 : 0;
+        return desc ? -result : result;
+      });
+      rows.forEach(function (row) {
+        body.appendChild(row);
+      });
+    });
+  });
+
+  document.querySelectorAll(".filters input, .filters select").forEach(function (el) {
+    el.addEventListener("input", applyFilters);
+    el.addEventListener("change", applyFilters);
+  });
+  applyFilters();
+})();
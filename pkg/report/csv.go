@@ -0,0 +1,80 @@
+package report
+
+import (
+	"bytes"
+	"encoding/csv"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+)
+
+// saveCSV 每张表保存为一个csv文件, 文件名为 <base>_<表名>.csv, 返回匹配这些文件的路径模式
+func (g *Generator) saveCSV(base string, data interface{}) (string, error) {
+	view, err := newReportView(data)
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal report: %w", err)
+	}
+
+	for _, t := range buildTables(view) {
+		content, err := renderCSV(t)
+		if err != nil {
+			return "", fmt.Errorf("failed to marshal report: %w", err)
+		}
+		path := filepath.Join(g.outputDir, fmt.Sprintf("%s_%s.csv", base, t.Name))
+		if err := os.WriteFile(path, content, 0644); err != nil {
+			return "", fmt.Errorf("failed to write report: %w", err)
+		}
+	}
+
+	return filepath.Join(g.outputDir, base+"_*.csv"), nil
+}
+
+// RenderCSVTable 渲染csv报告中的一张表(servers、disks、interfaces、nodes、pods、issues)
+func RenderCSVTable(data interface{}, name string) ([]byte, error) {
+	view, err := newReportView(data)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal report: %w", err)
+	}
+	for _, t := range buildTables(view) {
+		if t.Name == name {
+			return renderCSV(t)
+		}
+	}
+	return nil, fmt.Errorf("report has no %s table", name)
+}
+
+// renderCSV 渲染单张表, 第一行为列名
+func renderCSV(t *table) ([]byte, error) {
+	var buf bytes.Buffer
+	w := csv.NewWriter(&buf)
+
+	if err := w.Write(t.Columns); err != nil {
+		return nil, err
+	}
+	record := make([]string, len(t.Columns))
+	for _, row := range t.Rows {
+		for i, cell := range row {
+			record[i] = formatCell(cell)
+		}
+		if err := w.Write(record); err != nil {
+			return nil, err
+		}
+	}
+	w.Flush()
+	return buf.Bytes(), w.Error()
+}
+
+// formatCell 单元格的文本形式, 数值不做舍入
+func formatCell(cell interface{}) string {
+	switch v := cell.(type) {
+	case nil:
+		return ""
+	case string:
+		return v
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	default:
+		return fmt.Sprint(v)
+	}
+}
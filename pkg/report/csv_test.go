@@ -0,0 +1,115 @@
+package report
+
+import (
+	"encoding/csv"
+	"inspection-tool/pkg/models"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestBuildTables(t *testing.T) {
+	view, err := newReportView(SampleReport())
+	if err != nil {
+		t.Fatalf("newReportView failed: %v", err)
+	}
+
+	tables := buildTables(view)
+	var names []string
+	for _, tb := range tables {
+		names = append(names, tb.Name)
+		for i, row := range tb.Rows {
+			if len(row) != len(tb.Columns) {
+				t.Errorf("table %s row %d has %d cells, want %d", tb.Name, i, len(row), len(tb.Columns))
+			}
+		}
+	}
+	if got := strings.Join(names, ","); got != "servers,disks,interfaces,nodes,pods,issues" {
+		t.Errorf("unexpected tables: %s", got)
+	}
+
+	// 只有服务器的报告不包含K8s表
+	view, _ = newReportView(&models.ServerReport{Host: "10.0.0.1"})
+	names = nil
+	for _, tb := range buildTables(view) {
+		names = append(names, tb.Name)
+	}
+	if got := strings.Join(names, ","); got != "servers,disks,interfaces,issues" {
+		t.Errorf("unexpected tables for server report: %s", got)
+	}
+}
+
+func TestGenerateCSVReport(t *testing.T) {
+	tmpDir := t.TempDir()
+	gen := NewGenerator("csv", tmpDir, true)
+
+	pattern, err := gen.GenerateFullReport(SampleReport())
+	if err != nil {
+		t.Fatalf("Failed to generate csv report: %v", err)
+	}
+
+	files, _ := filepath.Glob(pattern)
+	if len(files) != 6 {
+		t.Fatalf("Expected 6 csv files for %s, got %v", pattern, files)
+	}
+
+	f, err := os.Open(strings.Replace(pattern, "*", "issues", 1))
+	if err != nil {
+		t.Fatalf("Failed to open issues csv: %v", err)
+	}
+	defer f.Close()
+
+	records, err := csv.NewReader(f).ReadAll()
+	if err != nil {
+		t.Fatalf("Failed to parse issues csv: %v", err)
+	}
+	if len(records) != 6 {
+		t.Fatalf("Expected header and 5 issues, got %d records", len(records))
+	}
+	if strings.Join(records[0], ",") != strings.Join(issueColumns, ",") {
+		t.Errorf("unexpected header: %v", records[0])
+	}
+	// 内存问题带有观测值和需要转义的消息
+	row := make(map[string]string)
+	for _, record := range records[1:] {
+		if record[3] != "server.memory.usage" {
+			continue
+		}
+		for i, column := range records[0] {
+			row[column] = record[i]
+		}
+	}
+	want := map[string]string{
+		"timestamp":  "2024-01-01T08:00:00Z",
+		"target":     "10.0.0.1",
+		"level":      "critical",
+		"message":    "内存使用率过高, \"swap\" 已满",
+		"observed":   "92.5",
+		"threshold":  "90",
+		"suppressed": "false",
+	}
+	for column, value := range want {
+		if row[column] != value {
+			t.Errorf("%s = %q, want %q", column, row[column], value)
+		}
+	}
+}
+
+func TestRenderCSVTable(t *testing.T) {
+	content, err := RenderCSVTable(SampleReport(), "pods")
+	if err != nil {
+		t.Fatalf("RenderCSVTable failed: %v", err)
+	}
+	records, err := csv.NewReader(strings.NewReader(string(content))).ReadAll()
+	if err != nil {
+		t.Fatalf("Failed to parse pods csv: %v", err)
+	}
+	if len(records) != 2 || !strings.Contains(strings.Join(records[1], ","), "web-0") {
+		t.Errorf("unexpected pods csv: %v", records)
+	}
+
+	if _, err := RenderCSVTable(&models.ServerReport{Host: "10.0.0.1"}, "pods"); err == nil {
+		t.Error("expected error for missing table")
+	}
+}
@@ -4,8 +4,10 @@ import (
 	"encoding/json"
 	"fmt"
 	"inspection-tool/pkg/models"
+	"io"
 	"os"
 	"path/filepath"
+	"strings"
 	"time"
 
 	"gopkg.in/yaml.v3"
@@ -13,9 +15,18 @@ import (
 
 // Generator 报告生成器
 type Generator struct {
-	format    string // json, yaml
+	format    string // json, yaml, html, markdown, text, csv, xlsx, junit, sarif
 	outputDir string
 	detailed  bool
+	checks    []CheckInfo
+}
+
+// CheckInfo 本次巡检执行的检查项, junit和sarif报告据此列出通过的检查项
+type CheckInfo struct {
+	ID       string
+	Category string
+	Severity models.Severity
+	Target   string // server, k8s
 }
 
 // NewGenerator 创建报告生成器
@@ -27,12 +38,17 @@ func NewGenerator(format, outputDir string, detailed bool) *Generator {
 	}
 }
 
+// SetChecks 设置本次巡检执行的检查项, 未设置时junit报告只包含发现问题的检查项
+func (g *Generator) SetChecks(checks []CheckInfo) {
+	g.checks = checks
+}
+
 // GenerateServerReport 生成服务器巡检报告
 func (g *Generator) GenerateServerReport(report *models.ServerReport) (string, error) {
 	filename := fmt.Sprintf("server_%s_%s.%s",
 		report.Host,
 		report.Timestamp.Format("20060102_150405"),
-		g.format,
+		g.extension(),
 	)
 
 	return g.saveReport(filename, report)
@@ -42,7 +58,7 @@ func (g *Generator) GenerateServerReport(report *models.ServerReport) (string, e
 func (g *Generator) GenerateK8sReport(report *models.K8sReport) (string, error) {
 	filename := fmt.Sprintf("k8s_%s.%s",
 		report.Timestamp.Format("20060102_150405"),
-		g.format,
+		g.extension(),
 	)
 
 	return g.saveReport(filename, report)
@@ -53,12 +69,31 @@ func (g *Generator) GenerateFullReport(report *models.InspectionReport) (string,
 	filename := fmt.Sprintf("inspection_%s_%s.%s",
 		report.Type,
 		report.Timestamp.Format("20060102_150405"),
-		g.format,
+		g.extension(),
 	)
 
 	return g.saveReport(filename, report)
 }
 
+// extension 报告文件扩展名
+func (g *Generator) extension() string {
+	return Extension(g.format)
+}
+
+// Extension 报告格式对应的文件扩展名
+func Extension(format string) string {
+	switch format {
+	case "markdown":
+		return "md"
+	case "text":
+		return "txt"
+	case "junit":
+		return "xml"
+	default:
+		return format
+	}
+}
+
 // saveReport 保存报告
 func (g *Generator) saveReport(filename string, data interface{}) (string, error) {
 	// 确保输出目录存在
@@ -68,6 +103,24 @@ func (g *Generator) saveReport(filename string, data interface{}) (string, error
 
 	filepath := filepath.Join(g.outputDir, filename)
 
+	if g.format == "csv" {
+		// 每张表一个文件
+		return g.saveCSV(strings.TrimSuffix(filename, ".csv"), data)
+	}
+	content, err := g.Render(data)
+	if err != nil {
+		return "", err
+	}
+
+	if err := os.WriteFile(filepath, content, 0644); err != nil {
+		return "", fmt.Errorf("failed to write report: %w", err)
+	}
+
+	return filepath, nil
+}
+
+// Render 按报告格式渲染报告内容, csv格式包含多张表, 需使用RenderCSVTable
+func (g *Generator) Render(data interface{}) ([]byte, error) {
 	var content []byte
 	var err error
 
@@ -80,144 +133,248 @@ func (g *Generator) saveReport(filename string, data interface{}) (string, error
 		}
 	case "yaml":
 		content, err = yaml.Marshal(data)
+	case "html":
+		var view *reportView
+		if view, err = newReportView(data); err == nil {
+			content, err = renderHTML(view)
+		}
+	case "markdown":
+		var view *reportView
+		if view, err = newReportView(data); err == nil {
+			content = renderMarkdown(view)
+		}
+	case "text":
+		content, err = renderText(data)
+	case "xlsx":
+		var view *reportView
+		if view, err = newReportView(data); err == nil {
+			content, err = renderXLSX(buildTables(view))
+		}
+	case "junit":
+		var view *reportView
+		if view, err = newReportView(data); err == nil {
+			content, err = renderJUnit(view, g.checks)
+		}
+	case "sarif":
+		var view *reportView
+		if view, err = newReportView(data); err == nil {
+			content, err = renderSARIF(view, g.checks)
+		}
+	case "csv":
+		return nil, fmt.Errorf("csv report has one file per table, use RenderCSVTable")
 	default:
-		return "", fmt.Errorf("unsupported format: %s", g.format)
+		return nil, fmt.Errorf("unsupported format: %s", g.format)
 	}
 
 	if err != nil {
-		return "", fmt.Errorf("failed to marshal report: %w", err)
+		return nil, fmt.Errorf("failed to marshal report: %w", err)
 	}
-
-	if err := os.WriteFile(filepath, content, 0644); err != nil {
-		return "", fmt.Errorf("failed to write report: %w", err)
-	}
-
-	return filepath, nil
+	return content, nil
 }
 
 // PrintSummary 打印摘要
 func PrintSummary(report *models.InspectionReport) {
-	fmt.Println("\n========================================")
-	fmt.Println("巡检报告摘要")
-	fmt.Println("========================================")
-	fmt.Printf("巡检类型: %s\n", report.Type)
-	fmt.Printf("巡检时间: %s\n", report.Timestamp.Format("2006-01-02 15:04:05"))
-	fmt.Println("========================================")
+	WriteSummary(os.Stdout, report)
+}
+
+// WriteSummary 输出摘要
+func WriteSummary(w io.Writer, report *models.InspectionReport) {
+	fmt.Fprintln(w, "\n========================================")
+	fmt.Fprintln(w, "巡检报告摘要")
+	fmt.Fprintln(w, "========================================")
+	fmt.Fprintf(w, "巡检类型: %s\n", report.Type)
+	fmt.Fprintf(w, "巡检时间: %s\n", report.Timestamp.Format("2006-01-02 15:04:05"))
+	fmt.Fprintln(w, "========================================")
 	
-	fmt.Printf("总问题数: %d\n", report.Summary.TotalIssues)
-	fmt.Printf("  - 严重: %d\n", report.Summary.CriticalIssues)
-	fmt.Printf("  - 警告: %d\n", report.Summary.WarningIssues)
-	fmt.Printf("  - 信息: %d\n", report.Summary.InfoIssues)
-	fmt.Printf("整体状态: %s\n", report.Summary.Status)
-	fmt.Println("========================================")
+	fmt.Fprintf(w, "总问题数: %d\n", report.Summary.TotalIssues)
+	fmt.Fprintf(w, "  - 严重: %d\n", report.Summary.CriticalIssues)
+	fmt.Fprintf(w, "  - 警告: %d\n", report.Summary.WarningIssues)
+	fmt.Fprintf(w, "  - 信息: %d\n", report.Summary.InfoIssues)
+	if report.Summary.WaivedIssues > 0 {
+		fmt.Fprintf(w, "已豁免: %d\n", report.Summary.WaivedIssues)
+	}
+	fmt.Fprintf(w, "整体状态: %s\n", report.Summary.Status)
+	fmt.Fprintln(w, "========================================")
+
+	if fleet := report.Summary.Fleet; fleet != nil {
+		fmt.Fprintf(w, "服务器: %d 台 (正常: %d, 警告: %d, 严重: %d, 失败: %d)\n",
+			fleet.TotalServers, fleet.HealthyServers, fleet.WarningServers, fleet.CriticalServers, fleet.FailedServers)
+		for _, host := range fleet.Hosts {
+			name := host.Host
+			if host.Group != "" {
+				name = fmt.Sprintf("%s [%s]", host.Host, host.Group)
+			}
+			fmt.Fprintf(w, "  %s %s: %s (严重: %d, 警告: %d, 信息: %d)\n",
+				getStatusIcon(host.Status), name, host.Status,
+				host.CriticalIssues, host.WarningIssues, host.InfoIssues)
+		}
+		fmt.Fprintln(w, "========================================")
+	}
 
 	if len(report.Summary.Messages) > 0 {
-		fmt.Println("\n关键问题:")
+		fmt.Fprintln(w, "\n关键问题:")
 		for i, msg := range report.Summary.Messages {
 			if i >= 10 {
-				fmt.Printf("... 还有 %d 条问题\n", len(report.Summary.Messages)-10)
+				fmt.Fprintf(w, "... 还有 %d 条问题\n", len(report.Summary.Messages)-10)
 				break
 			}
-			fmt.Printf("  %d. %s\n", i+1, msg)
+			fmt.Fprintf(w, "  %d. %s\n", i+1, msg)
 		}
-		fmt.Println("========================================")
+		fmt.Fprintln(w, "========================================")
 	}
 }
 
 // PrintServerSummary 打印服务器巡检摘要
 func PrintServerSummary(report *models.ServerReport) {
-	fmt.Println("\n========================================")
-	fmt.Println("服务器巡检摘要")
-	fmt.Println("========================================")
-	fmt.Printf("主机: %s\n", report.Host)
-	fmt.Printf("操作系统: %s %s\n", report.OS.Family, report.OS.Version)
-	fmt.Printf("内核版本: %s\n", report.OS.KernelVer)
-	fmt.Printf("运行时间: %d秒 (%.1f天)\n", report.OS.Uptime, float64(report.OS.Uptime)/86400)
-	fmt.Println("========================================")
-
-	fmt.Println("\nCPU:")
-	fmt.Printf("  核心数: %d\n", report.CPU.CoreCount)
-	fmt.Printf("  负载: %.2f / %.2f / %.2f\n", report.CPU.Load1, report.CPU.Load5, report.CPU.Load15)
-	fmt.Printf("  使用率: %.2f%%\n", report.CPU.UsagePercent)
-	fmt.Printf("  IO等待: %.2f%%\n", report.CPU.IowaitPercent)
-
-	fmt.Println("\n内存:")
-	fmt.Printf("  总量: %d MB\n", report.Memory.TotalMB)
-	fmt.Printf("  已用: %d MB (%.2f%%)\n", report.Memory.UsedMB, report.Memory.UsagePercent)
-	fmt.Printf("  可用: %d MB\n", report.Memory.AvailableMB)
-	fmt.Printf("  Swap: %d / %d MB (%.2f%%)\n", report.Memory.SwapUsedMB, report.Memory.SwapTotalMB, report.Memory.SwapPercent)
-
-	fmt.Println("\n磁盘:")
+	WriteServerSummary(os.Stdout, report)
+}
+
+// WriteServerSummary 输出服务器巡检摘要
+func WriteServerSummary(w io.Writer, report *models.ServerReport) {
+	fmt.Fprintln(w, "\n========================================")
+	fmt.Fprintln(w, "服务器巡检摘要")
+	fmt.Fprintln(w, "========================================")
+	fmt.Fprintf(w, "主机: %s\n", report.Host)
+	if report.Group != "" {
+		fmt.Fprintf(w, "分组: %s\n", report.Group)
+	}
+	if report.Status != "" {
+		fmt.Fprintf(w, "状态: %s\n", report.Status)
+	}
+	if report.Failed() {
+		// 未采集到指标, 只打印失败原因
+		for _, issue := range report.Issues {
+			fmt.Fprintf(w, "  [%s] %s: %s%s\n", issue.Level, issue.Category, issue.Message, waivedMark(issue))
+			if issue.Details != "" {
+				fmt.Fprintf(w, "    %s\n", issue.Details)
+			}
+		}
+		fmt.Fprintln(w, "========================================")
+		return
+	}
+	fmt.Fprintf(w, "操作系统: %s %s\n", report.OS.Family, report.OS.Version)
+	fmt.Fprintf(w, "内核版本: %s\n", report.OS.KernelVer)
+	fmt.Fprintf(w, "运行时间: %d秒 (%.1f天)\n", report.OS.Uptime, float64(report.OS.Uptime)/86400)
+	fmt.Fprintln(w, "========================================")
+
+	fmt.Fprintln(w, "\nCPU:")
+	fmt.Fprintf(w, "  核心数: %d\n", report.CPU.CoreCount)
+	fmt.Fprintf(w, "  负载: %.2f / %.2f / %.2f\n", report.CPU.Load1, report.CPU.Load5, report.CPU.Load15)
+	fmt.Fprintf(w, "  使用率: %.2f%%\n", report.CPU.UsagePercent)
+	fmt.Fprintf(w, "  IO等待: %.2f%%\n", report.CPU.IowaitPercent)
+
+	fmt.Fprintln(w, "\n内存:")
+	fmt.Fprintf(w, "  总量: %d MB\n", report.Memory.TotalMB)
+	fmt.Fprintf(w, "  已用: %d MB (%.2f%%)\n", report.Memory.UsedMB, report.Memory.UsagePercent)
+	fmt.Fprintf(w, "  可用: %d MB\n", report.Memory.AvailableMB)
+	fmt.Fprintf(w, "  Swap: %d / %d MB (%.2f%%)\n", report.Memory.SwapUsedMB, report.Memory.SwapTotalMB, report.Memory.SwapPercent)
+
+	fmt.Fprintln(w, "\n磁盘:")
 	for _, disk := range report.Disk {
-		fmt.Printf("  %s (%s): %.2f%% 使用\n", disk.MountPoint, disk.Device, disk.UsagePercent)
+		fmt.Fprintf(w, "  %s (%s): %.2f%% 使用\n", disk.MountPoint, disk.Device, disk.UsagePercent)
 	}
 
-	fmt.Println("\n网络:")
-	fmt.Printf("  TCP连接: ESTABLISHED=%d, TIME_WAIT=%d\n",
+	fmt.Fprintln(w, "\n网络:")
+	fmt.Fprintf(w, "  TCP连接: ESTABLISHED=%d, TIME_WAIT=%d\n",
 		report.Network.TCPConnections.Established,
 		report.Network.TCPConnections.TimeWait)
 
-	fmt.Println("\n系统:")
-	fmt.Printf("  文件句柄: %d / %d (%.2f%%)\n",
+	fmt.Fprintln(w, "\n系统:")
+	fmt.Fprintf(w, "  文件句柄: %d / %d (%.2f%%)\n",
 		report.System.FileHandlesAllocated,
 		report.System.FileHandlesMax,
 		report.System.FileHandlesPercent)
-	fmt.Printf("  进程数: %d\n", report.System.ProcessCount)
+	fmt.Fprintf(w, "  进程数: %d\n", report.System.ProcessCount)
+
+	if degraded := degradedCollectors(report); len(degraded) > 0 {
+		fmt.Fprintln(w, "\n采集状态:")
+		for _, c := range degraded {
+			fmt.Fprintf(w, "  %s %s: %s", getStatusIcon(c.Status), c.Name, c.Status)
+			if len(c.Missing) > 0 {
+				fmt.Fprintf(w, " (未采集: %s)", strings.Join(c.Missing, ", "))
+			}
+			fmt.Fprintln(w)
+		}
+	}
 
 	if len(report.Issues) > 0 {
-		fmt.Println("\n问题列表:")
+		fmt.Fprintln(w, "\n问题列表:")
 		for i, issue := range report.Issues {
 			if i >= 10 {
-				fmt.Printf("... 还有 %d 个问题\n", len(report.Issues)-10)
+				fmt.Fprintf(w, "... 还有 %d 个问题\n", len(report.Issues)-10)
 				break
 			}
-			fmt.Printf("  [%s] %s: %s\n", issue.Level, issue.Category, issue.Message)
+			fmt.Fprintf(w, "  [%s] %s: %s%s\n", issue.Level, issue.Category, issue.Message, waivedMark(issue))
 		}
 	}
-	fmt.Println("========================================")
+	fmt.Fprintln(w, "========================================")
 }
 
 // PrintK8sSummary 打印K8s巡检摘要
 func PrintK8sSummary(report *models.K8sReport) {
-	fmt.Println("\n========================================")
-	fmt.Println("Kubernetes巡检摘要")
-	fmt.Println("========================================")
-	fmt.Printf("集群版本: %s\n", report.ClusterInfo.Version)
-	fmt.Printf("节点数: %d\n", report.ClusterInfo.NodeCount)
-	fmt.Printf("命名空间数: %d\n", report.ClusterInfo.NamespaceCount)
-	fmt.Printf("Pod总数: %d\n", report.ClusterInfo.PodCount)
-	fmt.Println("========================================")
-
-	fmt.Println("\n节点状态:")
+	WriteK8sSummary(os.Stdout, report)
+}
+
+// WriteK8sSummary 输出K8s巡检摘要
+func WriteK8sSummary(w io.Writer, report *models.K8sReport) {
+	fmt.Fprintln(w, "\n========================================")
+	fmt.Fprintln(w, "Kubernetes巡检摘要")
+	fmt.Fprintln(w, "========================================")
+	fmt.Fprintf(w, "集群版本: %s\n", report.ClusterInfo.Version)
+	fmt.Fprintf(w, "节点数: %d\n", report.ClusterInfo.NodeCount)
+	fmt.Fprintf(w, "命名空间数: %d\n", report.ClusterInfo.NamespaceCount)
+	fmt.Fprintf(w, "Pod总数: %d\n", report.ClusterInfo.PodCount)
+	fmt.Fprintln(w, "========================================")
+
+	fmt.Fprintln(w, "\n节点状态:")
 	readyCount := 0
 	for _, node := range report.Nodes {
 		if node.Ready {
 			readyCount++
-			fmt.Printf("  ✓ %s: Ready (CPU: %.1f%%, Memory: %.1f%%, Pods: %d/%d)\n",
+			fmt.Fprintf(w, "  ✓ %s: Ready (CPU: %.1f%%, Memory: %.1f%%, Pods: %d/%d)\n",
 				node.Name, node.CPUPercent, node.MemoryPercent, node.PodCount, node.PodsCapacity)
 		} else {
-			fmt.Printf("  ✗ %s: NotReady\n", node.Name)
+			fmt.Fprintf(w, "  ✗ %s: NotReady\n", node.Name)
 		}
 	}
-	fmt.Printf("  Ready: %d / %d\n", readyCount, len(report.Nodes))
+	fmt.Fprintf(w, "  Ready: %d / %d\n", readyCount, len(report.Nodes))
 
-	fmt.Println("\n控制平面:")
-	fmt.Printf("  API Server: %s\n", getHealthStatus(report.APIServerStatus.Healthy))
-	fmt.Printf("  etcd: %s (成员: %d)\n", getHealthStatus(report.EtcdStatus.Healthy), report.EtcdStatus.ClusterSize)
-	fmt.Printf("  Controller Manager: %s\n", getHealthStatus(report.ControllerStatus.Healthy))
-	fmt.Printf("  Scheduler: %s\n", getHealthStatus(report.SchedulerStatus.Healthy))
+	fmt.Fprintln(w, "\n控制平面:")
+	fmt.Fprintf(w, "  API Server: %s\n", getHealthStatus(report.APIServerStatus.Healthy))
+	fmt.Fprintf(w, "  etcd: %s (成员: %d)\n", getHealthStatus(report.EtcdStatus.Healthy), report.EtcdStatus.ClusterSize)
+	fmt.Fprintf(w, "  Controller Manager: %s\n", getHealthStatus(report.ControllerStatus.Healthy))
+	fmt.Fprintf(w, "  Scheduler: %s\n", getHealthStatus(report.SchedulerStatus.Healthy))
 
 	if len(report.Issues) > 0 {
-		fmt.Println("\n问题列表:")
+		fmt.Fprintln(w, "\n问题列表:")
 		for i, issue := range report.Issues {
 			if i >= 10 {
-				fmt.Printf("... 还有 %d 个问题\n", len(report.Issues)-10)
+				fmt.Fprintf(w, "... 还有 %d 个问题\n", len(report.Issues)-10)
 				break
 			}
-			fmt.Printf("  [%s] %s: %s\n", issue.Level, issue.Category, issue.Message)
+			fmt.Fprintf(w, "  [%s] %s: %s%s\n", issue.Level, issue.Category, issue.Message, waivedMark(issue))
+		}
+	}
+	fmt.Fprintln(w, "========================================")
+}
+
+// degradedCollectors 返回未完整采集的步骤
+func degradedCollectors(report *models.ServerReport) []models.CollectorStatus {
+	var degraded []models.CollectorStatus
+	for _, c := range report.Collectors {
+		if c.Status != models.CollectorOK {
+			degraded = append(degraded, c)
 		}
 	}
-	fmt.Println("========================================")
+	return degraded
+}
+
+// waivedMark 被豁免问题的标记
+func waivedMark(issue models.Issue) string {
+	if !issue.Suppressed || issue.Waiver == nil {
+		return ""
+	}
+	return fmt.Sprintf(" (已豁免: %s, 负责人 %s, 至 %s)", issue.Waiver.Reason, issue.Waiver.Owner, issue.Waiver.Expires)
 }
 
 // getHealthStatus 获取健康状态字符串
@@ -228,6 +385,18 @@ func getHealthStatus(healthy bool) string {
 	return "✗ Unhealthy"
 }
 
+// getStatusIcon 获取状态图标
+func getStatusIcon(status string) string {
+	switch status {
+	case "healthy":
+		return "✓"
+	case "warning", "degraded":
+		return "!"
+	default:
+		return "✗"
+	}
+}
+
 // CleanupOldReports 清理旧报告
 func CleanupOldReports(outputDir string, retentionDays int) error {
 	if retentionDays <= 0 {
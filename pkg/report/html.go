@@ -0,0 +1,76 @@
+package report
+
+import (
+	"bytes"
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"html/template"
+	"math"
+)
+
+// HTML报告的模板、样式和脚本, 样式和脚本渲染时内联到报告中, 报告可离线打开
+var (
+	//go:embed assets/report.html.tmpl
+	htmlSource string
+	//go:embed assets/report.css
+	htmlCSS string
+	//go:embed assets/report.js
+	htmlJS string
+)
+
+// htmlTemplate 解析后的HTML报告模板
+var htmlTemplate = template.Must(template.New("report").Funcs(template.FuncMap{
+	"css":     func() template.CSS { return template.CSS(htmlCSS) },
+	"js":      func() template.JS { return template.JS(htmlJS) },
+	"gauge":   newGauge,
+	"maxDisk": maxDiskUsage,
+	"rawJSON": rawJSON,
+}).Parse(htmlSource))
+
+// renderHTML 渲染自包含的HTML报告
+func renderHTML(v *reportView) ([]byte, error) {
+	var buf bytes.Buffer
+	if err := htmlTemplate.Execute(&buf, v); err != nil {
+		return nil, fmt.Errorf("failed to render html: %w", err)
+	}
+	return buf.Bytes(), nil
+}
+
+// gauge 环形仪表
+type gauge struct {
+	Label   string
+	Percent float64
+}
+
+// newGauge 创建仪表, 模板中用法: {{template "gauge" (gauge "CPU" .CPU.UsagePercent)}}
+func newGauge(label string, percent float64) gauge {
+	return gauge{Label: label, Percent: percent}
+}
+
+// Dash 仪表弧长(stroke-dasharray), 圆周长为100
+func (g gauge) Dash() string {
+	p := math.Max(0, math.Min(100, g.Percent))
+	return fmt.Sprintf("%.1f %.1f", p, 100-p)
+}
+
+// Level 仪表颜色, 只用于展示, 问题判断以检查项阈值为准
+func (g gauge) Level() string {
+	switch {
+	case g.Percent >= 90:
+		return "critical"
+	case g.Percent >= 75:
+		return "warning"
+	default:
+		return "ok"
+	}
+}
+
+// rawJSON 原始指标, 放在可折叠区域中
+func rawJSON(v interface{}) (string, error) {
+	data, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return "", err
+	}
+	return string(data), nil
+}
@@ -0,0 +1,92 @@
+package report
+
+import (
+	"inspection-tool/pkg/models"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestGenerateHTMLReport(t *testing.T) {
+	tmpDir := t.TempDir()
+	gen := NewGenerator("html", tmpDir, true)
+
+	report := &models.InspectionReport{
+		Timestamp: time.Now(),
+		Type:      "all",
+		ServerReports: []*models.ServerReport{
+			{
+				Host:   "10.0.0.1",
+				Status: models.HostStatusCritical,
+				CPU:    models.CPUMetrics{CoreCount: 4, UsagePercent: 95},
+				Disk: []models.DiskMetrics{
+					{MountPoint: "/data", UsagePercent: 81.5},
+				},
+				Issues: []models.Issue{
+					{Level: models.SeverityCritical, Category: "cpu", Message: "CPU使用率过高: 95.00%"},
+					{Level: models.SeverityWarning, Category: "disk", Message: "<script>alert(1)</script>", Suppressed: true,
+						Waiver: &models.IssueWaiver{ID: "w1", Reason: "扩容中", Owner: "ops", Expires: "2027-01-01"}},
+				},
+			},
+		},
+		K8sReport: &models.K8sReport{
+			ClusterInfo: models.ClusterInfo{Version: "v1.28.0", NodeCount: 1},
+			Nodes:       []models.NodeMetrics{{Name: "node-1", Ready: true, CPUPercent: 40}},
+			Issues:      []models.Issue{{Level: models.SeverityInfo, Category: "pod", Message: "Pod重启"}},
+		},
+	}
+
+	filePath, err := gen.GenerateFullReport(report)
+	if err != nil {
+		t.Fatalf("Failed to generate html report: %v", err)
+	}
+	if filepath.Ext(filePath) != ".html" {
+		t.Errorf("Expected .html extension, got %s", filepath.Ext(filePath))
+	}
+
+	data, err := os.ReadFile(filePath)
+	if err != nil {
+		t.Fatalf("Failed to read html report: %v", err)
+	}
+	content := string(data)
+
+	for _, want := range []string{"10.0.0.1", "/data", "node-1", "CPU使用率过高", "扩容中", "<style>", "data-level=\"critical\"", "data-suppressed"} {
+		if !strings.Contains(content, want) {
+			t.Errorf("html report missing %q", want)
+		}
+	}
+	if strings.Contains(content, "<script>alert(1)</script>") {
+		t.Error("issue message should be escaped")
+	}
+	if strings.Contains(content, "src=\"http") || strings.Contains(content, "href=\"http") {
+		t.Error("html report should not reference external assets")
+	}
+	// 严重问题排在最前
+	if strings.Index(content, "CPU使用率过高") > strings.Index(content, "Pod重启") {
+		t.Error("issues should be sorted by severity")
+	}
+}
+
+func TestGaugeLevel(t *testing.T) {
+	tests := []struct {
+		percent float64
+		level   string
+		dash    string
+	}{
+		{10, "ok", "10.0 90.0"},
+		{80, "warning", "80.0 20.0"},
+		{120, "critical", "100.0 0.0"},
+	}
+
+	for _, tt := range tests {
+		g := newGauge("cpu", tt.percent)
+		if g.Level() != tt.level {
+			t.Errorf("Level(%v) = %s, want %s", tt.percent, g.Level(), tt.level)
+		}
+		if g.Dash() != tt.dash {
+			t.Errorf("Dash(%v) = %s, want %s", tt.percent, g.Dash(), tt.dash)
+		}
+	}
+}
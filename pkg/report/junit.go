@@ -0,0 +1,174 @@
+package report
+
+import (
+	"encoding/xml"
+	"fmt"
+	"inspection-tool/pkg/models"
+	"strings"
+	"time"
+)
+
+// JUnit XML 结构, 每个巡检对象一个testsuite, 每个检查项一个testcase
+type junitTestSuites struct {
+	XMLName  xml.Name         `xml:"testsuites"`
+	Name     string           `xml:"name,attr"`
+	Tests    int              `xml:"tests,attr"`
+	Failures int              `xml:"failures,attr"`
+	Skipped  int              `xml:"skipped,attr"`
+	Suites   []junitTestSuite `xml:"testsuite"`
+}
+
+type junitTestSuite struct {
+	Name      string          `xml:"name,attr"`
+	Tests     int             `xml:"tests,attr"`
+	Failures  int             `xml:"failures,attr"`
+	Skipped   int             `xml:"skipped,attr"`
+	Timestamp string          `xml:"timestamp,attr"`
+	Cases     []junitTestCase `xml:"testcase"`
+}
+
+type junitTestCase struct {
+	Name      string        `xml:"name,attr"`
+	ClassName string        `xml:"classname,attr"`
+	Failure   *junitFailure `xml:"failure,omitempty"`
+	Skipped   *junitSkipped `xml:"skipped,omitempty"`
+}
+
+type junitFailure struct {
+	Message string `xml:"message,attr"`
+	Type    string `xml:"type,attr"` // 最高的问题级别
+	Text    string `xml:",chardata"`
+}
+
+type junitSkipped struct {
+	Message string `xml:"message,attr"`
+}
+
+// inspectionTarget 一个巡检对象及其问题
+type inspectionTarget struct {
+	Name   string
+	Kind   string // server, k8s
+	Failed bool   // 未采集到指标, 检查项均未执行
+	Issues []models.Issue
+}
+
+// targets 报告中的所有巡检对象, 包括没有问题的对象
+func (v *reportView) targets() []inspectionTarget {
+	var targets []inspectionTarget
+	for _, sr := range v.Servers {
+		targets = append(targets, inspectionTarget{Name: sr.Host, Kind: "server", Failed: sr.Failed(), Issues: sr.Issues})
+	}
+	if v.K8s != nil {
+		targets = append(targets, inspectionTarget{Name: v.K8s.Target(), Kind: "k8s", Issues: v.K8s.Issues})
+	}
+	return targets
+}
+
+// issueKey 问题对应的检查项, 不是由检查项生成的问题(如连接失败、采集失败)使用类别
+func issueKey(issue models.Issue) string {
+	if issue.CheckID != "" {
+		return issue.CheckID
+	}
+	return issue.Category
+}
+
+// renderJUnit 渲染JUnit XML报告
+// 未被豁免的问题为failure, 全部被豁免的检查项和未能执行的检查项为skipped, 其余检查项为通过
+func renderJUnit(v *reportView, checks []CheckInfo) ([]byte, error) {
+	suites := junitTestSuites{Name: v.Title}
+	timestamp := v.Timestamp.Format(time.RFC3339)
+
+	for _, target := range v.targets() {
+		suite := junitTestSuite{Name: target.Name, Timestamp: timestamp}
+
+		// 先列出执行的检查项, 再列出其余问题来源, 保持与注册顺序一致
+		grouped := make(map[string][]models.Issue)
+		var keys []string
+		for _, c := range checks {
+			if c.Target == target.Kind {
+				keys = append(keys, c.ID)
+				grouped[c.ID] = nil
+			}
+		}
+		for _, issue := range target.Issues {
+			key := issueKey(issue)
+			if _, ok := grouped[key]; !ok {
+				keys = append(keys, key)
+			}
+			grouped[key] = append(grouped[key], issue)
+		}
+		if len(keys) == 0 {
+			keys = append(keys, "inspection")
+		}
+
+		for _, key := range keys {
+			tc := junitTestCase{Name: key, ClassName: target.Name}
+			issues := grouped[key]
+			switch {
+			case len(issues) > 0:
+				tc.Failure, tc.Skipped = junitResult(issues)
+			case target.Failed:
+				tc.Skipped = &junitSkipped{Message: "未采集到指标"}
+			}
+
+			if tc.Failure != nil {
+				suite.Failures++
+			} else if tc.Skipped != nil {
+				suite.Skipped++
+			}
+			suite.Cases = append(suite.Cases, tc)
+		}
+		suite.Tests = len(suite.Cases)
+
+		suites.Tests += suite.Tests
+		suites.Failures += suite.Failures
+		suites.Skipped += suite.Skipped
+		suites.Suites = append(suites.Suites, suite)
+	}
+
+	content, err := xml.MarshalIndent(suites, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal junit: %w", err)
+	}
+	return append([]byte(xml.Header), append(content, '\n')...), nil
+}
+
+// junitResult 由同一检查项的问题生成failure, 问题全部被豁免时生成skipped
+func junitResult(issues []models.Issue) (*junitFailure, *junitSkipped) {
+	var active []models.Issue
+	var waived []string
+	for _, issue := range issues {
+		if issue.Suppressed {
+			if issue.Waiver != nil {
+				waived = append(waived, issue.Waiver.Reason)
+			}
+			continue
+		}
+		active = append(active, issue)
+	}
+	if len(active) == 0 {
+		return nil, &junitSkipped{Message: "已豁免: " + strings.Join(waived, "; ")}
+	}
+
+	failure := &junitFailure{Message: active[0].Message}
+	if len(active) > 1 {
+		failure.Message = fmt.Sprintf("%s (共 %d 个问题)", active[0].Message, len(active))
+	}
+	var text strings.Builder
+	var level models.Severity
+	for _, issue := range active {
+		if issue.Level.Rank() > level.Rank() {
+			level = issue.Level
+		}
+		fmt.Fprintf(&text, "[%s] %s\n", issue.Level, issue.Message)
+		if issue.Details != "" {
+			fmt.Fprintf(&text, "  %s\n", issue.Details)
+		}
+		if issue.Suggestion != "" {
+			fmt.Fprintf(&text, "  建议: %s\n", issue.Suggestion)
+		}
+	}
+	failure.Type = string(level)
+	failure.Text = text.String()
+	return failure, nil
+}
@@ -0,0 +1,89 @@
+package report
+
+import (
+	"encoding/xml"
+	"inspection-tool/pkg/models"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// ciTestChecks 测试用的检查项列表
+var ciTestChecks = []CheckInfo{
+	{ID: "server.cpu.usage", Category: "cpu", Severity: models.SeverityWarning, Target: "server"},
+	{ID: "server.disk.usage", Category: "disk", Severity: models.SeverityCritical, Target: "server"},
+	{ID: "k8s.node.ready", Category: "node", Severity: models.SeverityCritical, Target: "k8s"},
+}
+
+func TestGenerateJUnitReport(t *testing.T) {
+	tmpDir := t.TempDir()
+	gen := NewGenerator("junit", tmpDir, true)
+	gen.SetChecks(ciTestChecks)
+
+	filePath, err := gen.GenerateFullReport(SampleReport())
+	if err != nil {
+		t.Fatalf("Failed to generate junit report: %v", err)
+	}
+	if filepath.Ext(filePath) != ".xml" {
+		t.Errorf("Expected .xml extension, got %s", filepath.Ext(filePath))
+	}
+
+	data, err := os.ReadFile(filePath)
+	if err != nil {
+		t.Fatalf("Failed to read junit report: %v", err)
+	}
+	var suites junitTestSuites
+	if err := xml.Unmarshal(data, &suites); err != nil {
+		t.Fatalf("junit report is not valid xml: %v", err)
+	}
+
+	if len(suites.Suites) != 3 {
+		t.Fatalf("Expected 3 testsuites, got %d", len(suites.Suites))
+	}
+	if suites.Tests != 7 || suites.Failures != 3 || suites.Skipped != 3 {
+		t.Errorf("tests/failures/skipped = %d/%d/%d, want 7/3/3", suites.Tests, suites.Failures, suites.Skipped)
+	}
+
+	cases := make(map[string]junitTestCase)
+	for _, suite := range suites.Suites {
+		for _, tc := range suite.Cases {
+			cases[suite.Name+" "+tc.Name] = tc
+		}
+	}
+
+	disk := cases["10.0.0.1 server.disk.usage"]
+	if disk.Failure == nil || disk.Failure.Type != "critical" {
+		t.Errorf("disk check should fail with the highest severity, got %+v", disk.Failure)
+	}
+	if cpu := cases["10.0.0.1 server.cpu.usage"]; cpu.Skipped == nil || cpu.Failure != nil {
+		t.Errorf("waived check should be skipped, got %+v", cpu)
+	}
+	if c := cases["10.0.0.2 connectivity"]; c.Failure == nil {
+		t.Error("connectivity failure should be a failed testcase")
+	}
+	if c := cases["10.0.0.2 server.disk.usage"]; c.Skipped == nil {
+		t.Error("checks on an unreachable host should be skipped")
+	}
+	if c := cases["https://10.0.0.10:6443 k8s.node.ready"]; c.Failure != nil || c.Skipped != nil {
+		t.Errorf("k8s check without issues should pass, got %+v", c)
+	}
+}
+
+func TestRenderJUnitWithoutChecks(t *testing.T) {
+	view, err := newReportView(&models.ServerReport{Host: "10.0.0.1"})
+	if err != nil {
+		t.Fatalf("newReportView failed: %v", err)
+	}
+
+	data, err := renderJUnit(view, nil)
+	if err != nil {
+		t.Fatalf("renderJUnit failed: %v", err)
+	}
+	var suites junitTestSuites
+	if err := xml.Unmarshal(data, &suites); err != nil {
+		t.Fatalf("junit report is not valid xml: %v", err)
+	}
+	if suites.Tests != 1 || suites.Suites[0].Cases[0].Name != "inspection" {
+		t.Errorf("target without checks should have a single passing testcase, got %+v", suites)
+	}
+}
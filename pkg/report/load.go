@@ -0,0 +1,82 @@
+package report
+
+import (
+	"encoding/json"
+	"fmt"
+	"inspection-tool/pkg/models"
+	"inspection-tool/pkg/utils"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Load 读取json或yaml格式的报告文件, 文件可以是完整报告、服务器报告或K8s报告
+// 服务器报告和K8s报告包装为完整报告并重新计算摘要
+func Load(path string) (*models.InspectionReport, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read report: %w", err)
+	}
+
+	unmarshal := json.Unmarshal
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yaml", ".yml":
+		unmarshal = yaml.Unmarshal
+	case ".json":
+	default:
+		return nil, fmt.Errorf("unsupported report file %s, only json and yaml reports can be loaded", path)
+	}
+
+	report, err := parseReport(data, unmarshal)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse report %s: %w", path, err)
+	}
+	return report, nil
+}
+
+// parseReport 按顶层字段判断报告类型
+func parseReport(data []byte, unmarshal func([]byte, interface{}) error) (*models.InspectionReport, error) {
+	var fields map[string]interface{}
+	if err := unmarshal(data, &fields); err != nil {
+		return nil, err
+	}
+
+	switch {
+	case has(fields, "server_report", "server_reports", "k8s_report", "summary"):
+		report := &models.InspectionReport{}
+		if err := unmarshal(data, report); err != nil {
+			return nil, err
+		}
+		return report, nil
+	case has(fields, "host"):
+		sr := &models.ServerReport{}
+		if err := unmarshal(data, sr); err != nil {
+			return nil, err
+		}
+		report := &models.InspectionReport{Timestamp: sr.Timestamp, Type: "server", ServerReport: sr}
+		utils.BuildInspectionSummary(report)
+		return report, nil
+	case has(fields, "cluster_info"):
+		kr := &models.K8sReport{}
+		if err := unmarshal(data, kr); err != nil {
+			return nil, err
+		}
+		report := &models.InspectionReport{Timestamp: kr.Timestamp, Type: "k8s", K8sReport: kr}
+		utils.BuildInspectionSummary(report)
+		return report, nil
+	default:
+		return nil, fmt.Errorf("unknown report type")
+	}
+}
+
+// has 判断是否包含任一字段
+func has(fields map[string]interface{}, keys ...string) bool {
+	for _, key := range keys {
+		if _, ok := fields[key]; ok {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,82 @@
+package report
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"gopkg.in/yaml.v3"
+)
+
+func TestLoad(t *testing.T) {
+	dir := t.TempDir()
+	full := SampleReport()
+	write := func(name string, v interface{}) string {
+		var data []byte
+		var err error
+		if filepath.Ext(name) == ".json" {
+			data, err = json.Marshal(v)
+		} else {
+			data, err = yaml.Marshal(v)
+		}
+		if err != nil {
+			t.Fatalf("marshal %s failed: %v", name, err)
+		}
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, data, 0644); err != nil {
+			t.Fatalf("write %s failed: %v", name, err)
+		}
+		return path
+	}
+
+	tests := []struct {
+		name        string
+		value       interface{}
+		wantType    string
+		wantServers int
+		wantK8s     bool
+	}{
+		{"all.json", full, "all", 2, true},
+		{"all.yaml", full, "all", 2, true},
+		{"server.json", full.ServerReports[0], "server", 1, false},
+		{"server.yml", full.ServerReports[0], "server", 1, false},
+		{"k8s.json", full.K8sReport, "k8s", 0, true},
+	}
+	for _, tt := range tests {
+		report, err := Load(write(tt.name, tt.value))
+		if err != nil {
+			t.Fatalf("Load %s failed: %v", tt.name, err)
+		}
+		if report.Type != tt.wantType {
+			t.Errorf("%s: type = %q, want %q", tt.name, report.Type, tt.wantType)
+		}
+		if len(report.Servers()) != tt.wantServers {
+			t.Errorf("%s: %d servers, want %d", tt.name, len(report.Servers()), tt.wantServers)
+		}
+		if (report.K8sReport != nil) != tt.wantK8s {
+			t.Errorf("%s: unexpected k8s report %v", tt.name, report.K8sReport)
+		}
+	}
+
+	// 单独的服务器报告重新计算摘要
+	report, err := Load(filepath.Join(dir, "server.json"))
+	if err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+	if report.Summary.CriticalIssues != 2 {
+		t.Errorf("summary not rebuilt, critical issues = %d", report.Summary.CriticalIssues)
+	}
+
+	if _, err := Load(write("unknown.json", map[string]string{"foo": "bar"})); err == nil {
+		t.Error("expected error for unknown report type")
+	}
+	if _, err := Load(filepath.Join(dir, "report.html")); err == nil {
+		t.Error("expected error for missing file")
+	}
+	htmlPath := filepath.Join(dir, "report.html")
+	os.WriteFile(htmlPath, []byte("<html></html>"), 0644)
+	if _, err := Load(htmlPath); err == nil {
+		t.Error("expected error for html report")
+	}
+}
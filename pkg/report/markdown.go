@@ -0,0 +1,88 @@
+package report
+
+import (
+	"bytes"
+	"fmt"
+	"inspection-tool/pkg/models"
+	"strings"
+)
+
+// severityBadges 问题级别标记, 在Git平台和聊天工具中都能直接显示
+var severityBadges = map[models.Severity]string{
+	models.SeverityCritical: "🔴 critical",
+	models.SeverityWarning:  "🟠 warning",
+	models.SeverityInfo:     "🔵 info",
+}
+
+// renderMarkdown 渲染Markdown报告, 问题按来源分组, 组内按类别排序
+func renderMarkdown(v *reportView) []byte {
+	var b bytes.Buffer
+
+	fmt.Fprintf(&b, "# %s\n\n", v.Title)
+	fmt.Fprintf(&b, "- 巡检时间: %s\n", v.Timestamp.Format("2006-01-02 15:04:05"))
+	fmt.Fprintf(&b, "- 巡检类型: %s\n", v.Type)
+	fmt.Fprintf(&b, "- 整体状态: **%s**\n\n", v.Summary.Status)
+
+	fmt.Fprintln(&b, "| 问题总数 | 严重 | 警告 | 信息 | 已豁免 |")
+	fmt.Fprintln(&b, "| ---: | ---: | ---: | ---: | ---: |")
+	fmt.Fprintf(&b, "| %d | %d | %d | %d | %d |\n\n",
+		v.Summary.TotalIssues, v.Summary.CriticalIssues, v.Summary.WarningIssues,
+		v.Summary.InfoIssues, v.Summary.WaivedIssues)
+
+	if fleet := v.Summary.Fleet; fleet != nil && len(fleet.Hosts) > 0 {
+		fmt.Fprintf(&b, "## 服务器\n\n共 %d 台 (正常: %d, 警告: %d, 严重: %d, 失败: %d)\n\n",
+			fleet.TotalServers, fleet.HealthyServers, fleet.WarningServers, fleet.CriticalServers, fleet.FailedServers)
+		fmt.Fprintln(&b, "| 主机 | 分组 | 状态 | 严重 | 警告 | 信息 |")
+		fmt.Fprintln(&b, "| --- | --- | --- | ---: | ---: | ---: |")
+		for _, host := range fleet.Hosts {
+			fmt.Fprintf(&b, "| %s | %s | %s %s | %d | %d | %d |\n",
+				markdownCell(host.Host), markdownCell(host.Group), getStatusIcon(host.Status), host.Status,
+				host.CriticalIssues, host.WarningIssues, host.InfoIssues)
+		}
+		fmt.Fprintln(&b)
+	}
+
+	if k := v.K8s; k != nil {
+		fmt.Fprintln(&b, "## Kubernetes")
+		fmt.Fprintln(&b)
+		fmt.Fprintf(&b, "- 集群版本: %s\n", k.ClusterInfo.Version)
+		fmt.Fprintf(&b, "- 节点数: %d, 命名空间数: %d, Pod总数: %d\n",
+			k.ClusterInfo.NodeCount, k.ClusterInfo.NamespaceCount, k.ClusterInfo.PodCount)
+		fmt.Fprintf(&b, "- 控制平面: API Server %s, etcd %s, Controller Manager %s, Scheduler %s\n\n",
+			getHealthStatus(k.APIServerStatus.Healthy), getHealthStatus(k.EtcdStatus.Healthy),
+			getHealthStatus(k.ControllerStatus.Healthy), getHealthStatus(k.SchedulerStatus.Healthy))
+	}
+
+	fmt.Fprintln(&b, "## 问题列表")
+	fmt.Fprintln(&b)
+	groups := v.IssueGroups()
+	if len(groups) == 0 {
+		fmt.Fprintln(&b, "未发现问题")
+	}
+	for _, group := range groups {
+		fmt.Fprintf(&b, "### %s\n\n", group.Source)
+		fmt.Fprintln(&b, "| 级别 | 类别 | 问题 | 建议 |")
+		fmt.Fprintln(&b, "| --- | --- | --- | --- |")
+		for _, issue := range group.Issues {
+			message := markdownCell(issue.Message)
+			if issue.Details != "" {
+				message += "<br>" + markdownCell(issue.Details)
+			}
+			if issue.Suppressed {
+				message = "~~" + message + "~~" + markdownCell(waivedMark(issue))
+			}
+			fmt.Fprintf(&b, "| %s | %s | %s | %s |\n",
+				severityBadges[issue.Level], markdownCell(issue.Category), message, markdownCell(issue.Suggestion))
+		}
+		fmt.Fprintln(&b)
+	}
+
+	return b.Bytes()
+}
+
+// markdownCell 转义表格单元格中的竖线和换行
+func markdownCell(s string) string {
+	s = strings.ReplaceAll(s, "|", "\\|")
+	s = strings.ReplaceAll(s, "\r\n", "<br>")
+	return strings.ReplaceAll(s, "\n", "<br>")
+}
@@ -0,0 +1,69 @@
+package report
+
+import (
+	"inspection-tool/pkg/models"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestGenerateMarkdownReport(t *testing.T) {
+	tmpDir := t.TempDir()
+	gen := NewGenerator("markdown", tmpDir, true)
+
+	report := &models.InspectionReport{
+		Timestamp: time.Now(),
+		Type:      "all",
+		ServerReports: []*models.ServerReport{
+			{
+				Host: "10.0.0.1",
+				Issues: []models.Issue{
+					{Level: models.SeverityWarning, Category: "memory", Message: "内存使用率过高"},
+					{Level: models.SeverityCritical, Category: "disk", Message: "磁盘 /data | 使用率过高", Suggestion: "清理磁盘"},
+					{Level: models.SeverityInfo, Category: "cpu", Message: "负载偏高", Suppressed: true,
+						Waiver: &models.IssueWaiver{Reason: "压测", Owner: "ops", Expires: "2027-01-01"}},
+				},
+			},
+		},
+		K8sReport: &models.K8sReport{
+			ClusterInfo: models.ClusterInfo{Version: "v1.28.0"},
+			Issues:      []models.Issue{{Level: models.SeverityCritical, Category: "node", Message: "节点NotReady"}},
+		},
+	}
+
+	filePath, err := gen.GenerateFullReport(report)
+	if err != nil {
+		t.Fatalf("Failed to generate markdown report: %v", err)
+	}
+	if filepath.Ext(filePath) != ".md" {
+		t.Errorf("Expected .md extension, got %s", filepath.Ext(filePath))
+	}
+
+	data, err := os.ReadFile(filePath)
+	if err != nil {
+		t.Fatalf("Failed to read markdown report: %v", err)
+	}
+	content := string(data)
+
+	for _, want := range []string{
+		"### 10.0.0.1",
+		"### k8s",
+		"| 🔴 critical | disk | 磁盘 /data \\| 使用率过高 | 清理磁盘 |",
+		"~~负载偏高~~",
+		"集群版本: v1.28.0",
+	} {
+		if !strings.Contains(content, want) {
+			t.Errorf("markdown report missing %q\n%s", want, content)
+		}
+	}
+
+	// 组内按类别排序, 被豁免的问题在最后
+	disk := strings.Index(content, "| disk |")
+	memory := strings.Index(content, "| memory |")
+	cpu := strings.Index(content, "| cpu |")
+	if !(disk < memory && memory < cpu) {
+		t.Errorf("unexpected issue order: disk=%d memory=%d cpu=%d", disk, memory, cpu)
+	}
+}
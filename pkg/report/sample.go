@@ -0,0 +1,57 @@
+package report
+
+import (
+	"inspection-tool/pkg/models"
+	"time"
+)
+
+// SampleReport 示例完整报告, 覆盖所有表格、问题级别、豁免和连接失败的情况, 供各报告格式和指标导出的测试共用
+// 每次调用返回新的副本, 调用方可以按需修改
+func SampleReport() *models.InspectionReport {
+	timestamp := time.Date(2024, 1, 1, 8, 0, 0, 0, time.UTC)
+	observed, threshold := 92.5, 90.0
+	return &models.InspectionReport{
+		Timestamp: timestamp,
+		Type:      "all",
+		ServerReports: []*models.ServerReport{
+			{
+				Host:      "10.0.0.1",
+				Group:     "db",
+				Status:    models.HostStatusCritical,
+				Timestamp: timestamp,
+				CPU:       models.CPUMetrics{CoreCount: 8, UsagePercent: 12.5, Load1: 0.5},
+				Memory:    models.MemoryMetrics{UsagePercent: 60},
+				Disk: []models.DiskMetrics{
+					{Device: "/dev/sda1", MountPoint: "/", TotalGB: 100, UsedGB: 40, UsagePercent: 40, InodesTotal: 1000, InodesPercent: 5},
+					{Device: "/dev/sdb1", MountPoint: "/data", TotalGB: 10, UsedGB: 9.5, UsagePercent: 95},
+				},
+				Network: models.NetworkMetrics{Interfaces: []models.NetworkInterface{{Name: "eth0", RxErrors: 3}}},
+				Issues: []models.Issue{
+					{CheckID: "server.memory.usage", Level: models.SeverityCritical, Category: "memory", Message: "内存使用率过高, \"swap\" 已满",
+						Target: "10.0.0.1", Observed: &observed, Threshold: &threshold, Unit: "%"},
+					{CheckID: "server.disk.usage", Level: models.SeverityWarning, Category: "disk", Message: "/ 使用率过高",
+						Target: "10.0.0.1", ResourceKind: models.ResourceDisk, ResourceName: "/", Fingerprint: "aaaa"},
+					{CheckID: "server.disk.usage", Level: models.SeverityCritical, Category: "disk", Message: "/data 使用率过高",
+						Target: "10.0.0.1", ResourceKind: models.ResourceDisk, ResourceName: "/data", Fingerprint: "bbbb"},
+					{CheckID: "server.cpu.usage", Level: models.SeverityWarning, Category: "cpu", Message: "CPU使用率过高", Suppressed: true,
+						Waiver: &models.IssueWaiver{ID: "w1", Reason: "压测", Owner: "ops", Expires: "2027-01-01"}},
+				},
+			},
+			{
+				Host:      "10.0.0.2",
+				Status:    models.HostStatusUnreachable,
+				Timestamp: timestamp,
+				Issues:    []models.Issue{{Level: models.SeverityCritical, Category: "connectivity", Message: "连接被拒绝"}},
+			},
+		},
+		K8sReport: &models.K8sReport{
+			ClusterInfo: models.ClusterInfo{Server: "https://10.0.0.10:6443", NodeCount: 2, PodCount: 20},
+			Nodes: []models.NodeMetrics{
+				{Name: "node-1", Ready: true, PodsCapacity: 110, PodCount: 20, PodPercent: 18.18},
+				{Name: "node-2", Ready: true, CPUUsage: "500m", CPUPercent: 25, MemoryUsage: "2Gi", MemoryPercent: 50},
+			},
+			Pods:      []models.PodMetrics{{Name: "web-0", Namespace: "default", Phase: "Running", RestartCount: 2}},
+			Timestamp: timestamp,
+		},
+	}
+}
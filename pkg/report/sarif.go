@@ -0,0 +1,184 @@
+package report
+
+import (
+	"encoding/json"
+	"fmt"
+	"inspection-tool/pkg/models"
+	"time"
+)
+
+// SARIF 2.1.0 结构, 只包含用到的字段
+type sarifLog struct {
+	Schema  string     `json:"$schema"`
+	Version string     `json:"version"`
+	Runs    []sarifRun `json:"runs"`
+}
+
+type sarifRun struct {
+	Tool        sarifTool         `json:"tool"`
+	Invocations []sarifInvocation `json:"invocations"`
+	Results     []sarifResult     `json:"results"`
+}
+
+type sarifTool struct {
+	Driver sarifDriver `json:"driver"`
+}
+
+type sarifDriver struct {
+	Name  string      `json:"name"`
+	Rules []sarifRule `json:"rules"`
+}
+
+type sarifRule struct {
+	ID                   string                 `json:"id"`
+	ShortDescription     sarifMessage           `json:"shortDescription"`
+	DefaultConfiguration sarifRuleConfiguration `json:"defaultConfiguration"`
+	Properties           map[string]string      `json:"properties,omitempty"`
+}
+
+type sarifRuleConfiguration struct {
+	Level string `json:"level"`
+}
+
+type sarifInvocation struct {
+	ExecutionSuccessful bool   `json:"executionSuccessful"`
+	StartTimeUTC        string `json:"startTimeUtc"`
+}
+
+type sarifMessage struct {
+	Text string `json:"text"`
+}
+
+type sarifResult struct {
+	RuleID              string                 `json:"ruleId"`
+	RuleIndex           int                    `json:"ruleIndex"`
+	Level               string                 `json:"level"`
+	Message             sarifMessage           `json:"message"`
+	Locations           []sarifLocation        `json:"locations"`
+	PartialFingerprints map[string]string      `json:"partialFingerprints,omitempty"`
+	Suppressions        []sarifSuppression     `json:"suppressions,omitempty"`
+	Properties          map[string]interface{} `json:"properties,omitempty"`
+}
+
+type sarifLocation struct {
+	LogicalLocations []sarifLogicalLocation `json:"logicalLocations"`
+}
+
+type sarifLogicalLocation struct {
+	Name               string `json:"name"`
+	FullyQualifiedName string `json:"fullyQualifiedName"`
+	Kind               string `json:"kind"`
+}
+
+type sarifSuppression struct {
+	Kind          string `json:"kind"`
+	Justification string `json:"justification,omitempty"`
+}
+
+// sarifLevels 问题级别对应的SARIF级别
+var sarifLevels = map[models.Severity]string{
+	models.SeverityCritical: "error",
+	models.SeverityWarning:  "warning",
+	models.SeverityInfo:     "note",
+}
+
+// renderSARIF 渲染SARIF报告
+// 检查项为规则, 问题为结果, 巡检对象和资源以逻辑位置表示, 被豁免的问题带外部抑制记录
+func renderSARIF(v *reportView, checks []CheckInfo) ([]byte, error) {
+	run := sarifRun{
+		Tool: sarifTool{Driver: sarifDriver{Name: "inspection-tool", Rules: []sarifRule{}}},
+		Invocations: []sarifInvocation{{
+			ExecutionSuccessful: true,
+			StartTimeUTC:        v.Timestamp.UTC().Format(time.RFC3339),
+		}},
+		Results: []sarifResult{},
+	}
+
+	ruleIndex := make(map[string]int)
+	addRule := func(id, category string, severity models.Severity) int {
+		if index, ok := ruleIndex[id]; ok {
+			return index
+		}
+		ruleIndex[id] = len(run.Tool.Driver.Rules)
+		run.Tool.Driver.Rules = append(run.Tool.Driver.Rules, sarifRule{
+			ID:                   id,
+			ShortDescription:     sarifMessage{Text: fmt.Sprintf("%s (%s)", id, category)},
+			DefaultConfiguration: sarifRuleConfiguration{Level: sarifLevels[severity]},
+			Properties:           map[string]string{"category": category},
+		})
+		return ruleIndex[id]
+	}
+	for _, c := range checks {
+		addRule(c.ID, c.Category, c.Severity)
+	}
+
+	for _, group := range v.IssueGroups() {
+		for _, issue := range group.Issues {
+			run.Results = append(run.Results, sarifIssue(issue, group.Source, addRule(issueKey(issue), issue.Category, issue.Level)))
+		}
+	}
+
+	content, err := json.MarshalIndent(sarifLog{
+		Schema:  "https://json.schemastore.org/sarif-2.1.0.json",
+		Version: "2.1.0",
+		Runs:    []sarifRun{run},
+	}, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal sarif: %w", err)
+	}
+	return content, nil
+}
+
+// sarifIssue 由问题生成结果, 问题未记录巡检对象时使用来源
+func sarifIssue(issue models.Issue, source string, ruleIndex int) sarifResult {
+	target := issue.Target
+	if target == "" {
+		target = source
+	}
+	location := sarifLogicalLocation{Name: target, FullyQualifiedName: target, Kind: "target"}
+	if issue.ResourceName != "" {
+		location = sarifLogicalLocation{
+			Name:               issue.ResourceName,
+			FullyQualifiedName: target + "/" + issue.Resource(),
+			Kind:               issue.ResourceKind,
+		}
+	}
+
+	message := issue.Message
+	if issue.Suggestion != "" {
+		message += "。建议: " + issue.Suggestion
+	}
+	result := sarifResult{
+		RuleID:    issueKey(issue),
+		RuleIndex: ruleIndex,
+		Level:     sarifLevels[issue.Level],
+		Message:   sarifMessage{Text: message},
+		Locations: []sarifLocation{{LogicalLocations: []sarifLogicalLocation{location}}},
+		Properties: map[string]interface{}{
+			"severity": issue.Level,
+			"category": issue.Category,
+			"target":   target,
+		},
+	}
+	if issue.Fingerprint != "" {
+		result.PartialFingerprints = map[string]string{"inspectionFingerprint/v1": issue.Fingerprint}
+	}
+	if issue.Observed != nil {
+		result.Properties["observed"] = *issue.Observed
+	}
+	if issue.Threshold != nil {
+		result.Properties["threshold"] = *issue.Threshold
+	}
+	if issue.Unit != "" {
+		result.Properties["unit"] = issue.Unit
+	}
+	if issue.Suppressed {
+		suppression := sarifSuppression{Kind: "external"}
+		if issue.Waiver != nil {
+			suppression.Justification = fmt.Sprintf("%s (%s, 负责人 %s, 至 %s)",
+				issue.Waiver.Reason, issue.Waiver.ID, issue.Waiver.Owner, issue.Waiver.Expires)
+		}
+		result.Suppressions = []sarifSuppression{suppression}
+	}
+	return result
+}
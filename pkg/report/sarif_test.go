@@ -0,0 +1,64 @@
+package report
+
+import (
+	"encoding/json"
+	"os"
+	"testing"
+)
+
+func TestGenerateSARIFReport(t *testing.T) {
+	tmpDir := t.TempDir()
+	gen := NewGenerator("sarif", tmpDir, true)
+	gen.SetChecks(ciTestChecks)
+
+	filePath, err := gen.GenerateFullReport(SampleReport())
+	if err != nil {
+		t.Fatalf("Failed to generate sarif report: %v", err)
+	}
+
+	data, err := os.ReadFile(filePath)
+	if err != nil {
+		t.Fatalf("Failed to read sarif report: %v", err)
+	}
+	var log sarifLog
+	if err := json.Unmarshal(data, &log); err != nil {
+		t.Fatalf("sarif report is not valid json: %v", err)
+	}
+
+	if log.Version != "2.1.0" || len(log.Runs) != 1 {
+		t.Fatalf("unexpected sarif log: version=%s runs=%d", log.Version, len(log.Runs))
+	}
+	run := log.Runs[0]
+
+	// 执行的检查项加上连接失败的类别
+	if len(run.Tool.Driver.Rules) != 5 {
+		t.Errorf("Expected 5 rules, got %d", len(run.Tool.Driver.Rules))
+	}
+	if len(run.Results) != 5 {
+		t.Fatalf("Expected 5 results, got %d", len(run.Results))
+	}
+
+	var errors, suppressed int
+	for _, result := range run.Results {
+		if run.Tool.Driver.Rules[result.RuleIndex].ID != result.RuleID {
+			t.Errorf("ruleIndex %d does not match ruleId %s", result.RuleIndex, result.RuleID)
+		}
+		if result.Level == "error" {
+			errors++
+		}
+		if len(result.Suppressions) > 0 {
+			suppressed++
+		}
+	}
+	if errors != 3 || suppressed != 1 {
+		t.Errorf("errors/suppressed = %d/%d, want 3/1", errors, suppressed)
+	}
+
+	first := run.Results[0]
+	if first.RuleID != "server.disk.usage" || first.PartialFingerprints["inspectionFingerprint/v1"] != "bbbb" {
+		t.Errorf("unexpected first result: %+v", first)
+	}
+	if loc := first.Locations[0].LogicalLocations[0]; loc.FullyQualifiedName != "10.0.0.1/disk///data" || loc.Kind != "disk" {
+		t.Errorf("unexpected location: %+v", loc)
+	}
+}
@@ -0,0 +1,175 @@
+package report
+
+import (
+	"inspection-tool/pkg/models"
+	"time"
+)
+
+// 表格类报告(csv、xlsx)的列, 列的顺序和名称保持稳定, 多次导出可以直接拼接, 新增列只能追加在末尾
+var (
+	serverColumns = []string{
+		"timestamp", "host", "group", "status", "hostname", "os", "kernel_version", "uptime_seconds",
+		"cpu_cores", "load_1min", "load_5min", "load_15min", "cpu_usage_percent", "iowait_percent",
+		"memory_total_mb", "memory_used_mb", "memory_usage_percent", "swap_usage_percent",
+		"disk_total_gb", "disk_used_gb", "disk_max_usage_percent", "file_handles_percent", "process_count",
+		"tcp_established", "tcp_time_wait", "critical_issues", "warning_issues", "info_issues",
+	}
+	diskColumns = []string{
+		"timestamp", "host", "device", "mount_point", "fs_type", "total_gb", "used_gb", "free_gb",
+		"usage_percent", "inodes_percent", "io_util_percent", "avg_await_ms", "io_errors",
+	}
+	interfaceColumns = []string{
+		"timestamp", "host", "name", "rx_bytes_per_sec", "tx_bytes_per_sec",
+		"rx_errors", "tx_errors", "rx_dropped", "tx_dropped", "error_rate",
+	}
+	nodeColumns = []string{
+		"timestamp", "cluster", "name", "internal_ip", "ready", "cpu_capacity", "memory_capacity",
+		"cpu_percent", "memory_percent", "pods_capacity", "pod_count", "pod_percent",
+		"kubelet_version", "os_image", "container_runtime",
+	}
+	podColumns = []string{
+		"timestamp", "cluster", "namespace", "name", "phase", "ready", "restart_count", "node",
+		"cpu_request", "memory_request", "cpu_limit", "memory_limit", "cpu_usage", "memory_usage", "age_seconds",
+	}
+	issueColumns = []string{
+		"timestamp", "target", "fingerprint", "check_id", "level", "category",
+		"resource_kind", "namespace", "resource_name", "message", "details",
+		"observed", "threshold", "unit", "suggestion", "suppressed", "waiver_id", "waiver_owner", "waiver_expires",
+	}
+)
+
+// table 表格类报告中的一张表, 对应一个csv文件或一个工作表
+type table struct {
+	Name    string
+	Columns []string
+	Rows    [][]interface{} // 单元格为 string、int、int64、float64、bool, nil 表示空值
+}
+
+// buildTables 由视图构建表格, 只包含与报告类型相关的表, 问题表总是存在
+func buildTables(v *reportView) []*table {
+	ts := v.Timestamp.Format(time.RFC3339)
+	var tables []*table
+
+	if len(v.Servers) > 0 {
+		servers := &table{Name: "servers", Columns: serverColumns}
+		disks := &table{Name: "disks", Columns: diskColumns}
+		interfaces := &table{Name: "interfaces", Columns: interfaceColumns}
+		for _, sr := range v.Servers {
+			servers.Rows = append(servers.Rows, serverRow(ts, sr))
+			for _, d := range sr.Disk {
+				disks.Rows = append(disks.Rows, []interface{}{
+					ts, sr.Host, d.Device, d.MountPoint, d.FsType, d.TotalGB, d.UsedGB, d.FreeGB,
+					d.UsagePercent, d.InodesPercent, d.IOUtilPercent, d.AvgAwaitMs, d.IOErrors,
+				})
+			}
+			for _, n := range sr.Network.Interfaces {
+				interfaces.Rows = append(interfaces.Rows, []interface{}{
+					ts, sr.Host, n.Name, n.RxBytesPS, n.TxBytesPS,
+					n.RxErrors, n.TxErrors, n.RxDropped, n.TxDropped, n.ErrorRate,
+				})
+			}
+		}
+		tables = append(tables, servers, disks, interfaces)
+	}
+
+	if k := v.K8s; k != nil {
+		cluster := k.Target()
+		nodes := &table{Name: "nodes", Columns: nodeColumns}
+		for _, n := range k.Nodes {
+			nodes.Rows = append(nodes.Rows, []interface{}{
+				ts, cluster, n.Name, n.InternalIP, n.Ready, n.CPUCapacity, n.MemoryCapacity,
+				n.CPUPercent, n.MemoryPercent, n.PodsCapacity, n.PodCount, n.PodPercent,
+				n.KubeletVersion, n.OSImage, n.ContainerRuntime,
+			})
+		}
+		pods := &table{Name: "pods", Columns: podColumns}
+		for _, p := range k.Pods {
+			pods.Rows = append(pods.Rows, []interface{}{
+				ts, cluster, p.Namespace, p.Name, p.Phase, p.Ready, p.RestartCount, p.Node,
+				p.CPURequest, p.MemoryRequest, p.CPULimit, p.MemoryLimit, p.CPUUsage, p.MemoryUsage, p.Age,
+			})
+		}
+		tables = append(tables, nodes, pods)
+	}
+
+	issues := &table{Name: "issues", Columns: issueColumns}
+	for _, group := range v.IssueGroups() {
+		for _, issue := range group.Issues {
+			issues.Rows = append(issues.Rows, issueTableRow(ts, group.Source, issue))
+		}
+	}
+	return append(tables, issues)
+}
+
+// serverRow 服务器汇总行, 磁盘容量为各挂载点之和
+func serverRow(ts string, sr *models.ServerReport) []interface{} {
+	var totalGB, usedGB float64
+	for _, d := range sr.Disk {
+		totalGB += d.TotalGB
+		usedGB += d.UsedGB
+	}
+	var critical, warning, info int
+	for _, issue := range sr.Issues {
+		if issue.Suppressed {
+			continue
+		}
+		switch issue.Level {
+		case models.SeverityCritical:
+			critical++
+		case models.SeverityWarning:
+			warning++
+		case models.SeverityInfo:
+			info++
+		}
+	}
+
+	return []interface{}{
+		ts, sr.Host, sr.Group, sr.Status, sr.OS.Hostname, joinNonEmpty(sr.OS.Family, sr.OS.Version), sr.OS.KernelVer, sr.OS.Uptime,
+		sr.CPU.CoreCount, sr.CPU.Load1, sr.CPU.Load5, sr.CPU.Load15, sr.CPU.UsagePercent, sr.CPU.IowaitPercent,
+		sr.Memory.TotalMB, sr.Memory.UsedMB, sr.Memory.UsagePercent, sr.Memory.SwapPercent,
+		totalGB, usedGB, maxDiskUsage(sr), sr.System.FileHandlesPercent, sr.System.ProcessCount,
+		sr.Network.TCPConnections.Established, sr.Network.TCPConnections.TimeWait, critical, warning, info,
+	}
+}
+
+// issueTableRow 问题行, 问题未记录巡检对象时使用来源
+func issueTableRow(ts, source string, issue models.Issue) []interface{} {
+	target := issue.Target
+	if target == "" {
+		target = source
+	}
+	var waiverID, waiverOwner, waiverExpires string
+	if issue.Waiver != nil {
+		waiverID, waiverOwner, waiverExpires = issue.Waiver.ID, issue.Waiver.Owner, issue.Waiver.Expires
+	}
+
+	return []interface{}{
+		ts, target, issue.Fingerprint, issue.CheckID, string(issue.Level), issue.Category,
+		issue.ResourceKind, issue.Namespace, issue.ResourceName, issue.Message, issue.Details,
+		optionalFloat(issue.Observed), optionalFloat(issue.Threshold), issue.Unit, issue.Suggestion,
+		issue.Suppressed, waiverID, waiverOwner, waiverExpires,
+	}
+}
+
+// optionalFloat 未设置的数值输出为空单元格
+func optionalFloat(f *float64) interface{} {
+	if f == nil {
+		return nil
+	}
+	return *f
+}
+
+// joinNonEmpty 用空格连接非空字符串
+func joinNonEmpty(parts ...string) string {
+	s := ""
+	for _, p := range parts {
+		if p == "" {
+			continue
+		}
+		if s != "" {
+			s += " "
+		}
+		s += p
+	}
+	return s
+}
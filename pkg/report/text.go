@@ -0,0 +1,31 @@
+package report
+
+import (
+	"bytes"
+	"fmt"
+	"inspection-tool/pkg/models"
+)
+
+// renderText 渲染纯文本报告, 内容与终端打印的摘要一致
+func renderText(data interface{}) ([]byte, error) {
+	var b bytes.Buffer
+
+	switch r := data.(type) {
+	case *models.InspectionReport:
+		WriteSummary(&b, r)
+		for _, sr := range r.Servers() {
+			WriteServerSummary(&b, sr)
+		}
+		if r.K8sReport != nil {
+			WriteK8sSummary(&b, r.K8sReport)
+		}
+	case *models.ServerReport:
+		WriteServerSummary(&b, r)
+	case *models.K8sReport:
+		WriteK8sSummary(&b, r)
+	default:
+		return nil, fmt.Errorf("unsupported report type %T", data)
+	}
+
+	return b.Bytes(), nil
+}
@@ -0,0 +1,67 @@
+package report
+
+import (
+	"inspection-tool/pkg/models"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestGenerateTextReport(t *testing.T) {
+	tmpDir := t.TempDir()
+	gen := NewGenerator("text", tmpDir, true)
+
+	report := &models.ServerReport{
+		Host:      "10.0.0.1",
+		Timestamp: time.Now(),
+		CPU:       models.CPUMetrics{CoreCount: 8},
+		Issues: []models.Issue{
+			{Level: models.SeverityWarning, Category: "memory", Message: "内存使用率过高"},
+		},
+	}
+
+	filePath, err := gen.GenerateServerReport(report)
+	if err != nil {
+		t.Fatalf("Failed to generate text report: %v", err)
+	}
+	if filepath.Ext(filePath) != ".txt" {
+		t.Errorf("Expected .txt extension, got %s", filepath.Ext(filePath))
+	}
+
+	data, err := os.ReadFile(filePath)
+	if err != nil {
+		t.Fatalf("Failed to read text report: %v", err)
+	}
+
+	var expected strings.Builder
+	WriteServerSummary(&expected, report)
+	if string(data) != expected.String() {
+		t.Errorf("text report should match the printed summary, got:\n%s", data)
+	}
+	if !strings.Contains(string(data), "[warning] memory: 内存使用率过高") {
+		t.Errorf("text report missing issue:\n%s", data)
+	}
+}
+
+func TestRenderTextFullReport(t *testing.T) {
+	report := &models.InspectionReport{
+		Timestamp:     time.Now(),
+		Type:          "all",
+		ServerReports: []*models.ServerReport{{Host: "10.0.0.1"}, {Host: "10.0.0.2"}},
+		K8sReport:     &models.K8sReport{ClusterInfo: models.ClusterInfo{Version: "v1.28.0"}},
+	}
+
+	data, err := renderText(report)
+	if err != nil {
+		t.Fatalf("renderText failed: %v", err)
+	}
+
+	content := string(data)
+	for _, want := range []string{"巡检报告摘要", "主机: 10.0.0.1", "主机: 10.0.0.2", "Kubernetes巡检摘要"} {
+		if !strings.Contains(content, want) {
+			t.Errorf("text report missing %q", want)
+		}
+	}
+}
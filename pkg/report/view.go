@@ -0,0 +1,139 @@
+package report
+
+import (
+	"fmt"
+	"inspection-tool/pkg/models"
+	"inspection-tool/pkg/utils"
+	"sort"
+	"time"
+)
+
+// reportView 文档类报告格式共用的视图, 服务器、K8s和完整报告统一为同一结构
+type reportView struct {
+	Title     string
+	Type      string // server, k8s, all
+	Timestamp time.Time
+	Summary   models.InspectionSummary
+	Servers   []*models.ServerReport
+	K8s       *models.K8sReport
+	Issues    []issueRow // 按级别从高到低排序, 被豁免的问题在最后
+}
+
+// issueRow 带来源的问题, Source为服务器地址或k8s
+type issueRow struct {
+	Source string
+	models.Issue
+}
+
+// newReportView 由 GenerateServerReport、GenerateK8sReport 或 GenerateFullReport 的数据构建视图
+func newReportView(data interface{}) (*reportView, error) {
+	var full *models.InspectionReport
+	switch r := data.(type) {
+	case *models.InspectionReport:
+		full = r
+	case *models.ServerReport:
+		full = &models.InspectionReport{Timestamp: r.Timestamp, Type: "server", ServerReport: r}
+		utils.BuildInspectionSummary(full)
+	case *models.K8sReport:
+		full = &models.InspectionReport{Timestamp: r.Timestamp, Type: "k8s", K8sReport: r}
+		utils.BuildInspectionSummary(full)
+	default:
+		return nil, fmt.Errorf("unsupported report type %T", data)
+	}
+
+	v := &reportView{
+		Type:      full.Type,
+		Timestamp: full.Timestamp,
+		Summary:   full.Summary,
+		Servers:   full.Servers(),
+		K8s:       full.K8sReport,
+	}
+	switch {
+	case full.Type == "server" && len(v.Servers) == 1:
+		v.Title = fmt.Sprintf("服务器巡检报告 - %s", v.Servers[0].Host)
+	case full.Type == "k8s":
+		v.Title = "Kubernetes巡检报告"
+	default:
+		v.Title = "巡检报告"
+	}
+
+	for _, sr := range v.Servers {
+		for _, issue := range sr.Issues {
+			v.Issues = append(v.Issues, issueRow{Source: sr.Host, Issue: issue})
+		}
+	}
+	if v.K8s != nil {
+		for _, issue := range v.K8s.Issues {
+			v.Issues = append(v.Issues, issueRow{Source: "k8s", Issue: issue})
+		}
+	}
+	sort.SliceStable(v.Issues, func(i, j int) bool {
+		a, b := v.Issues[i], v.Issues[j]
+		if a.Suppressed != b.Suppressed {
+			return !a.Suppressed
+		}
+		return a.Level.Rank() > b.Level.Rank()
+	})
+	return v, nil
+}
+
+// Categories 问题类别, 按名称排序
+func (v *reportView) Categories() []string {
+	seen := make(map[string]bool)
+	var categories []string
+	for _, row := range v.Issues {
+		if !seen[row.Category] {
+			seen[row.Category] = true
+			categories = append(categories, row.Category)
+		}
+	}
+	sort.Strings(categories)
+	return categories
+}
+
+// maxDiskUsage 服务器各挂载点中最高的磁盘使用率
+func maxDiskUsage(sr *models.ServerReport) float64 {
+	max := 0.0
+	for _, disk := range sr.Disk {
+		if disk.UsagePercent > max {
+			max = disk.UsagePercent
+		}
+	}
+	return max
+}
+
+// issueGroup 同一来源的问题
+type issueGroup struct {
+	Source string
+	Issues []models.Issue
+}
+
+// IssueGroups 按来源分组的问题, 组内按类别排序, 同类别中级别高的在前, 被豁免的问题在最后
+func (v *reportView) IssueGroups() []issueGroup {
+	var groups []issueGroup
+	add := func(source string, issues []models.Issue) {
+		if len(issues) == 0 {
+			return
+		}
+		sorted := append([]models.Issue(nil), issues...)
+		sort.SliceStable(sorted, func(i, j int) bool {
+			a, b := sorted[i], sorted[j]
+			if a.Suppressed != b.Suppressed {
+				return !a.Suppressed
+			}
+			if a.Category != b.Category {
+				return a.Category < b.Category
+			}
+			return a.Level.Rank() > b.Level.Rank()
+		})
+		groups = append(groups, issueGroup{Source: source, Issues: sorted})
+	}
+
+	for _, sr := range v.Servers {
+		add(sr.Host, sr.Issues)
+	}
+	if v.K8s != nil {
+		add("k8s", v.K8s.Issues)
+	}
+	return groups
+}
@@ -0,0 +1,79 @@
+package report
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/xml"
+	"io"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestGenerateXLSXReport(t *testing.T) {
+	tmpDir := t.TempDir()
+	gen := NewGenerator("xlsx", tmpDir, true)
+
+	filePath, err := gen.GenerateFullReport(SampleReport())
+	if err != nil {
+		t.Fatalf("Failed to generate xlsx report: %v", err)
+	}
+	if filepath.Ext(filePath) != ".xlsx" {
+		t.Errorf("Expected .xlsx extension, got %s", filepath.Ext(filePath))
+	}
+
+	zr, err := zip.OpenReader(filePath)
+	if err != nil {
+		t.Fatalf("xlsx is not a valid zip: %v", err)
+	}
+	defer zr.Close()
+
+	parts := make(map[string]string)
+	for _, f := range zr.File {
+		rc, err := f.Open()
+		if err != nil {
+			t.Fatalf("Failed to open %s: %v", f.Name, err)
+		}
+		data, _ := io.ReadAll(rc)
+		rc.Close()
+
+		// 每个部分都必须是合法的XML
+		dec := xml.NewDecoder(bytes.NewReader(data))
+		for {
+			if _, err := dec.Token(); err == io.EOF {
+				break
+			} else if err != nil {
+				t.Fatalf("%s is not valid xml: %v", f.Name, err)
+			}
+		}
+		parts[f.Name] = string(data)
+	}
+
+	for _, name := range []string{"[Content_Types].xml", "_rels/.rels", "xl/workbook.xml", "xl/_rels/workbook.xml.rels", "xl/styles.xml", "xl/worksheets/sheet6.xml"} {
+		if _, ok := parts[name]; !ok {
+			t.Errorf("xlsx missing part %s", name)
+		}
+	}
+	for _, sheet := range []string{"servers", "disks", "interfaces", "nodes", "pods", "issues"} {
+		if !strings.Contains(parts["xl/workbook.xml"], `name="`+sheet+`"`) {
+			t.Errorf("workbook missing sheet %s", sheet)
+		}
+	}
+
+	issues := parts["xl/worksheets/sheet6.xml"]
+	if !strings.Contains(issues, `<c r="L4"><v>92.5</v></c>`) {
+		t.Errorf("observed value should be a numeric cell:\n%s", issues)
+	}
+	if !strings.Contains(issues, "&#34;swap&#34;") {
+		t.Errorf("message should be escaped:\n%s", issues)
+	}
+}
+
+func TestColumnName(t *testing.T) {
+	tests := map[int]string{0: "A", 25: "Z", 26: "AA", 27: "AB", 701: "ZZ", 702: "AAA"}
+	for i, want := range tests {
+		if got := columnName(i); got != want {
+			t.Errorf("columnName(%d) = %s, want %s", i, got, want)
+		}
+	}
+}
@@ -13,44 +13,55 @@ func BuildInspectionSummary(report *models.InspectionReport) {
 	summary.CriticalIssues = 0
 	summary.WarningIssues = 0
 	summary.InfoIssues = 0
+	summary.WaivedIssues = 0
 	summary.Messages = []string{}
+	summary.Fleet = nil
 
 	// 统计服务器问题
-	if report.ServerReport != nil {
-		for _, issue := range report.ServerReport.Issues {
-			summary.TotalIssues++
+	servers := report.Servers()
+	if len(servers) > 0 {
+		summary.Fleet = &models.FleetSummary{Hosts: []models.HostSummary{}}
+	}
+	for _, sr := range servers {
+		host := models.HostSummary{Host: sr.Host, Group: sr.Group}
+		for _, issue := range sr.Issues {
+			if issue.Suppressed {
+				summary.WaivedIssues++
+				continue
+			}
+			countIssue(summary, issue.Level)
 			switch issue.Level {
 			case "critical":
-				summary.CriticalIssues++
+				host.CriticalIssues++
 			case "warning":
-				summary.WarningIssues++
+				host.WarningIssues++
 			case "info":
-				summary.InfoIssues++
+				host.InfoIssues++
 			}
 
 			// 添加关键和警告问题到消息列表
 			if issue.Level == "critical" || issue.Level == "warning" {
-				summary.Messages = append(summary.Messages, 
-					fmt.Sprintf("[%s/%s] %s", report.ServerReport.Host, issue.Category, issue.Message))
+				summary.Messages = append(summary.Messages,
+					fmt.Sprintf("[%s/%s] %s", sr.Host, issue.Category, issue.Message))
 			}
 		}
+
+		UpdateServerStatus(sr)
+		host.Status = sr.Status
+		addHostSummary(summary.Fleet, host)
 	}
 
 	// 统计K8s问题
 	if report.K8sReport != nil {
 		for _, issue := range report.K8sReport.Issues {
-			summary.TotalIssues++
-			switch issue.Level {
-			case "critical":
-				summary.CriticalIssues++
-			case "warning":
-				summary.WarningIssues++
-			case "info":
-				summary.InfoIssues++
+			if issue.Suppressed {
+				summary.WaivedIssues++
+				continue
 			}
+			countIssue(summary, issue.Level)
 
 			if issue.Level == "critical" || issue.Level == "warning" {
-				summary.Messages = append(summary.Messages, 
+				summary.Messages = append(summary.Messages,
 					fmt.Sprintf("[k8s/%s] %s", issue.Category, issue.Message))
 			}
 		}
@@ -66,6 +77,84 @@ func BuildInspectionSummary(report *models.InspectionReport) {
 	}
 }
 
+// countIssue 按级别累加问题数
+func countIssue(summary *models.InspectionSummary, level models.Severity) {
+	summary.TotalIssues++
+	switch level {
+	case "critical":
+		summary.CriticalIssues++
+	case "warning":
+		summary.WarningIssues++
+	case "info":
+		summary.InfoIssues++
+	}
+}
+
+// addHostSummary 将单台服务器计入整体汇总, 整体状态取最严重的主机状态
+func addHostSummary(fleet *models.FleetSummary, host models.HostSummary) {
+	fleet.Hosts = append(fleet.Hosts, host)
+	fleet.TotalServers++
+	switch host.Status {
+	case models.HostStatusUnreachable, models.HostStatusAuthFailed,
+		models.HostStatusTimeout, models.HostStatusInspectionFailed:
+		fleet.FailedServers++
+	case "critical":
+		fleet.CriticalServers++
+	case "warning":
+		fleet.WarningServers++
+	default:
+		fleet.HealthyServers++
+	}
+
+	switch {
+	case fleet.CriticalServers > 0 || fleet.FailedServers > 0:
+		fleet.Status = "critical"
+	case fleet.WarningServers > 0:
+		fleet.Status = "warning"
+	default:
+		fleet.Status = "healthy"
+	}
+}
+
+// UpdateServerStatus 根据问题设置服务器状态, 已标记为连接或巡检失败的保持不变
+func UpdateServerStatus(sr *models.ServerReport) {
+	if !sr.Failed() {
+		sr.Status = IssueStatus(sr.Issues)
+	}
+}
+
+// CountIssues 统计级别不低于min的问题数, 不含被豁免的问题和表示采集失败的问题
+func CountIssues(issues []models.Issue, min models.Severity) int {
+	count := 0
+	for _, issue := range issues {
+		if issue.Suppressed || issue.CollectionFailure() {
+			continue
+		}
+		if issue.Level.Rank() >= min.Rank() {
+			count++
+		}
+	}
+	return count
+}
+
+// IssueStatus 根据问题列表确定状态: 有严重问题为critical, 有警告为warning, 否则为healthy
+// 被豁免的问题不影响状态
+func IssueStatus(issues []models.Issue) string {
+	status := "healthy"
+	for _, issue := range issues {
+		if issue.Suppressed {
+			continue
+		}
+		switch issue.Level {
+		case "critical":
+			return "critical"
+		case "warning":
+			status = "warning"
+		}
+	}
+	return status
+}
+
 // FormatDuration 格式化持续时间
 func FormatDuration(seconds int64) string {
 	d := time.Duration(seconds) * time.Second
@@ -47,6 +47,112 @@ func TestBuildInspectionSummary(t *testing.T) {
 	}
 }
 
+func TestBuildInspectionSummaryFleet(t *testing.T) {
+	report := &models.InspectionReport{
+		Timestamp: time.Now(),
+		Type:      "all",
+		ServerReports: []*models.ServerReport{
+			{Host: "web01", Group: "web", Issues: []models.Issue{{Level: "warning", Category: "cpu", Message: "High CPU"}}},
+			{Host: "db01", Group: "db", Issues: []models.Issue{
+				{Level: "critical", Category: "disk", Message: "Disk full"},
+				{Level: "info", Category: "network", Message: "Info"},
+			}},
+			{Host: "cache01"},
+		},
+		K8sReport: &models.K8sReport{
+			Issues: []models.Issue{{Level: "warning", Category: "pod", Message: "Pending"}},
+		},
+	}
+
+	BuildInspectionSummary(report)
+
+	if report.Summary.TotalIssues != 4 || report.Summary.CriticalIssues != 1 || report.Summary.WarningIssues != 2 {
+		t.Errorf("Expected issues from every host to be counted, got %+v", report.Summary)
+	}
+
+	fleet := report.Summary.Fleet
+	if fleet == nil {
+		t.Fatal("Expected fleet summary")
+	}
+	if fleet.TotalServers != 3 || fleet.HealthyServers != 1 || fleet.WarningServers != 1 || fleet.CriticalServers != 1 {
+		t.Errorf("Unexpected fleet counts: %+v", fleet)
+	}
+	if fleet.Status != "critical" {
+		t.Errorf("Expected fleet status 'critical', got '%s'", fleet.Status)
+	}
+
+	if len(fleet.Hosts) != 3 || fleet.Hosts[1].Host != "db01" || fleet.Hosts[1].CriticalIssues != 1 || fleet.Hosts[1].Group != "db" {
+		t.Errorf("Unexpected host summaries: %+v", fleet.Hosts)
+	}
+	if report.ServerReports[0].Status != "warning" || report.ServerReports[2].Status != "healthy" {
+		t.Errorf("Expected per-host status to be set, got %s / %s",
+			report.ServerReports[0].Status, report.ServerReports[2].Status)
+	}
+}
+
+func TestBuildInspectionSummaryFailedHosts(t *testing.T) {
+	report := &models.InspectionReport{
+		Timestamp: time.Now(),
+		Type:      "all",
+		ServerReports: []*models.ServerReport{
+			{Host: "web01", Status: models.HostStatusUnreachable, Issues: []models.Issue{
+				{Level: "critical", Category: "connectivity", Message: "主机不可达: web01"},
+			}},
+			{Host: "web02", Status: models.HostStatusAuthFailed, Issues: []models.Issue{
+				{Level: "critical", Category: "connectivity", Message: "SSH认证失败: web02"},
+			}},
+		},
+	}
+
+	BuildInspectionSummary(report)
+
+	if report.Summary.Status != "critical" || report.Summary.CriticalIssues != 2 {
+		t.Errorf("Expected all-down fleet to be critical with 2 issues, got %s / %d",
+			report.Summary.Status, report.Summary.CriticalIssues)
+	}
+
+	fleet := report.Summary.Fleet
+	if fleet.FailedServers != 2 || fleet.HealthyServers != 0 || fleet.CriticalServers != 0 {
+		t.Errorf("Unexpected fleet counts: %+v", fleet)
+	}
+	if report.ServerReports[0].Status != models.HostStatusUnreachable || fleet.Hosts[1].Status != models.HostStatusAuthFailed {
+		t.Errorf("Expected failure status to be kept, got %s / %s", report.ServerReports[0].Status, fleet.Hosts[1].Status)
+	}
+}
+
+func TestBuildInspectionSummaryWaived(t *testing.T) {
+	waiver := &models.IssueWaiver{ID: "archive", Reason: "归档盘", Owner: "ops", Expires: "2099-01-01"}
+	report := &models.InspectionReport{
+		Timestamp: time.Now(),
+		Type:      "all",
+		ServerReports: []*models.ServerReport{
+			{Host: "archive01", Issues: []models.Issue{
+				{Level: "critical", Category: "disk", Message: "磁盘使用率过高", Suppressed: true, Waiver: waiver},
+				{Level: "warning", Category: "memory", Message: "内存使用率过高"},
+			}},
+		},
+		K8sReport: &models.K8sReport{Issues: []models.Issue{
+			{Level: "warning", Category: "pod", Message: "High restart count", Suppressed: true, Waiver: waiver},
+		}},
+	}
+
+	BuildInspectionSummary(report)
+
+	s := report.Summary
+	if s.TotalIssues != 1 || s.CriticalIssues != 0 || s.WarningIssues != 1 || s.WaivedIssues != 2 {
+		t.Errorf("Expected waived issues to be excluded from counts, got %+v", s)
+	}
+	if s.Status != "warning" || report.ServerReports[0].Status != "warning" {
+		t.Errorf("Expected waived critical issue not to affect status, got %s / %s", s.Status, report.ServerReports[0].Status)
+	}
+	if len(s.Messages) != 1 {
+		t.Errorf("Expected only the unwaived issue in messages, got %v", s.Messages)
+	}
+	if CountIssues(report.ServerReports[0].Issues, models.SeverityCritical) != 0 {
+		t.Error("Expected waived critical issue not to be counted")
+	}
+}
+
 func TestFormatDuration(t *testing.T) {
 	tests := []struct {
 		seconds  int64